@@ -0,0 +1,79 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// assertionLifetime is how long a client assertion is valid.
+const assertionLifetime = 5 * time.Minute
+
+// assertionClaims are claims of a client assertion, RFC 7523 section 3.
+type assertionClaims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	Audience  string `json:"aud"`
+	ID        string `json:"jti"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// assertionReuseMargin is how long before expiry a cached client assertion is replaced.
+const assertionReuseMargin = assertionLifetime / 5
+
+type cachedAssertion struct {
+	value     string
+	keyID     string
+	expiresAt time.Time
+}
+
+// clientAssertion returns a signed JWT authenticating the client at the token endpoint.
+func (c *Client) clientAssertion(ctx context.Context) (string, error) {
+	if c.config.Signer == nil {
+		return "", errors.New("oauth2: signer is not set")
+	}
+	if !c.config.ReuseClientAssertion {
+		return c.newClientAssertion(ctx)
+	}
+
+	c.assertionMu.Lock()
+	defer c.assertionMu.Unlock()
+
+	cached := c.assertion
+	if cached.keyID == c.config.Signer.KeyID() && timeNow().Add(assertionReuseMargin).Before(cached.expiresAt) {
+		return cached.value, nil
+	}
+
+	kid, now := c.config.Signer.KeyID(), timeNow()
+	assertion, err := c.newClientAssertion(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.assertion = cachedAssertion{
+		value:     assertion,
+		keyID:     kid,
+		expiresAt: now.Add(assertionLifetime),
+	}
+	return assertion, nil
+}
+
+func (c *Client) newClientAssertion(ctx context.Context) (string, error) {
+	var jti [16]byte
+	if _, err := rand.Read(jti[:]); err != nil {
+		return "", err
+	}
+
+	now := c.now() // the token endpoint checks `iat` and `exp` in its clock.
+	claims := assertionClaims{
+		Issuer:    c.config.ClientID,
+		Subject:   c.config.ClientID,
+		Audience:  c.config.TokenURL,
+		ID:        hex.EncodeToString(jti[:]),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(assertionLifetime).Unix(),
+	}
+	return signJWT(ctx, c.config.Signer, claims)
+}
@@ -0,0 +1,112 @@
+package oauth2
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrivateKeyJWTMode(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	mustOk(t, err)
+	signer, err := NewSigner(key, "RS256", "key-1")
+	mustOk(t, err)
+
+	var tokenURL string
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		mustEqual(t, r.Header.Get("Authorization"), "")
+		mustEqual(t, r.FormValue("client_id"), "CLIENT_ID")
+		mustEqual(t, r.FormValue("client_secret"), "")
+		mustEqual(t, r.FormValue("client_assertion_type"), "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+
+		assertion := r.FormValue("client_assertion")
+
+		var claims assertionClaims
+		header, err := decodeJWT(assertion, &claims)
+		mustOk(t, err)
+		mustEqual(t, header.Algorithm, "RS256")
+		mustEqual(t, header.KeyID, "key-1")
+		mustEqual(t, claims.Issuer, "CLIENT_ID")
+		mustEqual(t, claims.Subject, "CLIENT_ID")
+		mustEqual(t, claims.Audience, tokenURL)
+		mustEqual(t, claims.ExpiresAt-claims.IssuedAt, int64(300))
+
+		i := strings.LastIndexByte(assertion, '.')
+		sig, err := base64.RawURLEncoding.DecodeString(assertion[i+1:])
+		mustOk(t, err)
+		mustOk(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashOf(crypto.SHA256, []byte(assertion[:i])), sig))
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN"}`)
+	})
+	defer ts.Close()
+	tokenURL = ts.URL + "/token"
+
+	client := newClientWithConfig(Config{
+		ClientID: "CLIENT_ID",
+		TokenURL: tokenURL,
+		Mode:     PrivateKeyJWTMode,
+		Signer:   signer,
+	})
+
+	_, err = client.ClientCredentialsToken(context.Background())
+	mustOk(t, err)
+}
+
+func TestPrivateKeyJWTMode_NoSigner(t *testing.T) {
+	client := newClientWithConfig(Config{
+		ClientID: "CLIENT_ID",
+		TokenURL: "http://localhost/token",
+		Mode:     PrivateKeyJWTMode,
+	})
+
+	_, err := client.ClientCredentialsToken(context.Background())
+	mustFail(t, err)
+}
+
+func TestReuseClientAssertion(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	mustOk(t, err)
+	signer1, err := NewSigner(key, "RS256", "key-1")
+	mustOk(t, err)
+	signer2, err := NewSigner(key, "RS256", "key-2")
+	mustOk(t, err)
+	rs, err := NewRotatingSigner(signer1, signer2)
+	mustOk(t, err)
+
+	client := newClientWithConfig(Config{
+		ClientID:             "CLIENT_ID",
+		TokenURL:             "https://example.com/token",
+		Mode:                 PrivateKeyJWTMode,
+		Signer:               rs,
+		ReuseClientAssertion: true,
+	})
+	ctx := context.Background()
+
+	a1, err := client.clientAssertion(ctx)
+	mustOk(t, err)
+	a2, err := client.clientAssertion(ctx)
+	mustOk(t, err)
+	mustEqual(t, a2, a1)
+
+	now = now.Add(assertionLifetime - assertionReuseMargin)
+	a3, err := client.clientAssertion(ctx)
+	mustOk(t, err)
+	mustEqual(t, a3 != a1, true)
+
+	mustOk(t, rs.SetActive("key-2"))
+	a4, err := client.clientAssertion(ctx)
+	mustOk(t, err)
+	mustEqual(t, a4 != a3, true)
+}
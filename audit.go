@@ -0,0 +1,57 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// AuditSink receives audit events of Client and TokenSource, see Config.AuditSink.
+// Audit is called synchronously, implementations should not block for long.
+type AuditSink interface {
+	Audit(ctx context.Context, event AuditEvent)
+}
+
+// Audit operations.
+const (
+	AuditTokenRequest = "token_request" // AuditTokenRequest is a token endpoint request of Client.
+	AuditRefresh      = "refresh"       // AuditRefresh is a token refresh of TokenSource, it includes the token request.
+	AuditQuarantine   = "quarantine"    // AuditQuarantine is a refresh token quarantined by TokenSource, see QuarantineError.
+)
+
+// AuditEvent describes an operation with tokens. It never contains secrets or tokens.
+type AuditEvent struct {
+	Time          time.Time     // Time is when the operation started.
+	Duration      time.Duration // Duration is how long the operation took.
+	Operation     string        // Operation is AuditTokenRequest, AuditRefresh or AuditQuarantine.
+	GrantType     string        // GrantType is a grant type of the token request, like `client_credentials`.
+	ClientID      string        // ClientID is the application's ID.
+	Key           string        // Key is TokenSourceConfig.Key for TokenSource events.
+	CorrelationID string        // CorrelationID is the ID sent in Config.CorrelationHeader, if any.
+	Success       bool          // Success reports whether the operation succeeded.
+	StatusCode    int           // StatusCode is an HTTP status of a failed token request, if any.
+	ErrorCode     string        // ErrorCode is the provider's error code, like `invalid_grant`, if any.
+	Err           error         // Err is the error of a failed operation.
+}
+
+// audit sends the event to the audit sink, filling the common fields.
+func (c *Client) audit(ctx context.Context, event AuditEvent) {
+	sink := c.config.AuditSink
+	if sink == nil {
+		return
+	}
+
+	event.Duration = time.Since(event.Time)
+	event.ClientID = c.config.ClientID
+	event.Success = event.Err == nil
+	if id, ok := ctx.Value(correlationIDKey{}).(string); ok {
+		event.CorrelationID = id
+	}
+
+	var rerr *RetrieveError
+	if errors.As(event.Err, &rerr) {
+		event.StatusCode = rerr.StatusCode
+		event.ErrorCode = rerr.ErrorCode
+	}
+	sink.Audit(ctx, event)
+}
@@ -0,0 +1,96 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditSink(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		mustEqual(t, r.Header.Get("X-Request-Id"), "REQUEST_ID")
+		r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.PostForm.Get("grant_type") == "refresh_token" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "invalid_grant"}`)
+			return
+		}
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN", "expires_in": 3600}`)
+	})
+	defer ts.Close()
+
+	sink := &auditRecorder{}
+	client := newClientWithConfig(Config{
+		ClientID:          "CLIENT_ID",
+		ClientSecret:      "CLIENT_SECRET",
+		TokenURL:          ts.URL + "/token",
+		Mode:              InParamsMode,
+		CorrelationHeader: "X-Request-Id",
+		CorrelationID:     func(ctx context.Context) string { return "REQUEST_ID" },
+		AuditSink:         sink,
+	})
+
+	_, err := client.ClientCredentialsToken(context.Background())
+	mustOk(t, err)
+
+	source := NewTokenSource(client, &Token{RefreshToken: "REFRESH_TOKEN"}, TokenSourceConfig{Key: "user-1"})
+	_, err = source.Token(context.Background())
+	mustFail(t, err)
+
+	mustEqual(t, len(sink.events), 3)
+
+	e := sink.events[0]
+	mustEqual(t, e.Operation, AuditTokenRequest)
+	mustEqual(t, e.GrantType, "client_credentials")
+	mustEqual(t, e.ClientID, "CLIENT_ID")
+	mustEqual(t, e.CorrelationID, "REQUEST_ID")
+	mustEqual(t, e.Success, true)
+	mustEqual(t, e.Err, nil)
+
+	e = sink.events[1]
+	mustEqual(t, e.Operation, AuditTokenRequest)
+	mustEqual(t, e.GrantType, "refresh_token")
+	mustEqual(t, e.Success, false)
+	mustEqual(t, e.StatusCode, http.StatusBadRequest)
+	mustEqual(t, e.ErrorCode, "invalid_grant")
+
+	e = sink.events[2]
+	mustEqual(t, e.Operation, AuditRefresh)
+	mustEqual(t, e.Key, "user-1")
+	mustEqual(t, e.Success, false)
+	mustEqual(t, e.ErrorCode, "invalid_grant")
+
+	for _, e := range sink.events {
+		s := fmt.Sprintf("%+v", e)
+		for _, secret := range []string{"CLIENT_SECRET", "ACCESS_TOKEN", "REFRESH_TOKEN"} {
+			mustEqual(t, strings.Contains(s, secret), false)
+		}
+	}
+}
+
+func TestAuditSink_RefreshTokenExpired(t *testing.T) {
+	sink := &auditRecorder{}
+	client := newClientWithConfig(Config{ClientID: "CLIENT_ID", AuditSink: sink})
+
+	token := &Token{RefreshToken: "REFRESH_TOKEN", RefreshExpiry: time.Now().Add(-time.Minute)}
+	_, err := NewTokenSource(client, token, TokenSourceConfig{}).Token(context.Background())
+	mustEqual(t, err, ErrRefreshTokenExpired)
+
+	mustEqual(t, len(sink.events), 1)
+	mustEqual(t, sink.events[0].Operation, AuditRefresh)
+	mustEqual(t, errors.Is(sink.events[0].Err, ErrRefreshTokenExpired), true)
+}
+
+type auditRecorder struct {
+	events []AuditEvent
+}
+
+func (r *auditRecorder) Audit(ctx context.Context, event AuditEvent) {
+	r.events = append(r.events, event)
+}
@@ -0,0 +1,145 @@
+package oauth2
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBreakerOpen is returned when the circuit breaker considers the token endpoint unavailable.
+var ErrBreakerOpen = errors.New("oauth2: circuit breaker is open")
+
+// BreakerState represents a state of the token endpoint circuit breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed means requests are passed to the token endpoint.
+	BreakerClosed BreakerState = 0
+
+	// BreakerOpen means requests fail fast with ErrBreakerOpen.
+	BreakerOpen BreakerState = 1
+
+	// BreakerHalfOpen means the cool-down has passed and a single probe request is allowed.
+	BreakerHalfOpen BreakerState = 2
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// breaker is a circuit breaker, nil breaker allows everything.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	metrics   BreakerMetrics // metrics is nil if Config.Metrics doesn't implement BreakerMetrics.
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+}
+
+func newBreaker(threshold int, cooldown time.Duration, metrics Metrics) *breaker {
+	if threshold <= 0 {
+		return nil
+	}
+	b := &breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+	b.metrics, _ = metrics.(BreakerMetrics)
+	return b
+}
+
+// allow reports ErrBreakerOpen if a request must not be sent, otherwise it returns a function
+// to call when the request is done. It releases the half-open probe if no result was recorded,
+// like when the request couldn't be built, so the next request probes again.
+func (b *breaker) allow() (func(), error) {
+	if b == nil {
+		return func() {}, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if timeNow().Sub(b.openedAt) < b.cooldown {
+			return nil, ErrBreakerOpen
+		}
+		b.setState(BreakerHalfOpen)
+		return b.releaseProbe, nil
+	case BreakerHalfOpen:
+		// a probe request is in flight.
+		return nil, ErrBreakerOpen
+	default:
+		return func() {}, nil
+	}
+}
+
+// releaseProbe returns an unrecorded probe to the open state, its cool-down has already passed.
+func (b *breaker) releaseProbe() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen {
+		b.setState(BreakerOpen)
+	}
+}
+
+// record counts the result of a request.
+func (b *breaker) record(failed bool) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.setState(BreakerClosed)
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.setState(BreakerOpen)
+		b.openedAt = timeNow()
+	}
+}
+
+// setState changes the state and reports the change to BreakerMetrics, b.mu must be held
+// so changes are reported in order.
+func (b *breaker) setState(state BreakerState) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	if b.metrics != nil {
+		b.metrics.BreakerStateChanged(state)
+	}
+}
+
+func (b *breaker) currentState() BreakerState {
+	if b == nil {
+		return BreakerClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && timeNow().Sub(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
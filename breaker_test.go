@@ -0,0 +1,207 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	var calls int
+	healthy := false
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if !healthy {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN"}`)
+	})
+	defer ts.Close()
+
+	metrics := &metricsRecorder{}
+	client := newClientWithConfig(Config{
+		ClientID:         "CLIENT_ID",
+		TokenURL:         ts.URL,
+		Mode:             InHeaderMode,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Minute,
+		Metrics:          metrics,
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := client.ClientCredentialsToken(ctx)
+		mustFail(t, err)
+	}
+	mustEqual(t, client.BreakerState(), BreakerOpen)
+
+	_, err := client.ClientCredentialsToken(ctx)
+	mustEqual(t, err, ErrBreakerOpen)
+	mustEqual(t, calls, 2)
+
+	now = now.Add(time.Minute)
+	mustEqual(t, client.BreakerState(), BreakerHalfOpen)
+
+	_, err = client.ClientCredentialsToken(ctx)
+	mustFail(t, err)
+	mustEqual(t, client.BreakerState(), BreakerOpen)
+	mustEqual(t, calls, 3)
+
+	now = now.Add(time.Minute)
+	healthy = true
+	_, err = client.ClientCredentialsToken(ctx)
+	mustOk(t, err)
+	mustEqual(t, client.BreakerState(), BreakerClosed)
+	mustEqual(t, metrics.breakerStates, []BreakerState{
+		BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed,
+	})
+}
+
+func TestBreaker_ClientErrors(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		TokenURL:         ts.URL,
+		Mode:             InHeaderMode,
+		BreakerThreshold: 1,
+		BreakerCooldown:  time.Minute,
+	})
+
+	_, err := client.ClientCredentialsToken(context.Background())
+	mustFail(t, err)
+	mustEqual(t, client.BreakerState(), BreakerClosed)
+}
+
+func TestBreaker_ReleaseProbe(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	var calls int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	defer ts.Close()
+
+	secretErr := false
+	client := newClientWithConfig(Config{
+		ClientID: "CLIENT_ID",
+		TokenURL: ts.URL,
+		Mode:     InHeaderMode,
+		SecretProvider: func(ctx context.Context) (string, error) {
+			if secretErr {
+				return "", errors.New("vault is down")
+			}
+			return "SECRET", nil
+		},
+		BreakerThreshold: 1,
+		BreakerCooldown:  time.Minute,
+	})
+	ctx := context.Background()
+
+	_, err := client.ClientCredentialsToken(ctx)
+	mustFail(t, err)
+	mustEqual(t, client.BreakerState(), BreakerOpen)
+
+	// the probe fails before the request is sent.
+	now = now.Add(time.Minute)
+	secretErr = true
+	_, err = client.ClientCredentialsToken(ctx)
+	mustFail(t, err)
+	mustEqual(t, err == ErrBreakerOpen, false)
+
+	// the next request probes again.
+	secretErr = false
+	_, err = client.ClientCredentialsToken(ctx)
+	mustFail(t, err)
+	mustEqual(t, err == ErrBreakerOpen, false)
+	mustEqual(t, calls, 2)
+}
+
+func TestBreaker_Timeout(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	var calls atomic.Int32
+	stuck := make(chan struct{})
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			<-stuck
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN"}`)
+	})
+	defer ts.Close()
+	defer close(stuck)
+
+	client := newClientWithConfig(Config{
+		ClientID:         "CLIENT_ID",
+		TokenURL:         ts.URL,
+		Mode:             InHeaderMode,
+		BreakerThreshold: 1,
+		BreakerCooldown:  time.Minute,
+	})
+	timeout := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := client.ClientCredentialsToken(ctx)
+		return err
+	}
+
+	mustEqual(t, errors.Is(timeout(), context.DeadlineExceeded), true)
+	mustEqual(t, client.BreakerState(), BreakerOpen)
+
+	// a timed out probe opens the breaker again.
+	now = now.Add(time.Minute)
+	mustEqual(t, errors.Is(timeout(), context.DeadlineExceeded), true)
+	mustEqual(t, client.BreakerState(), BreakerOpen)
+
+	now = now.Add(time.Minute)
+	_, err := client.ClientCredentialsToken(context.Background())
+	mustOk(t, err)
+	mustEqual(t, client.BreakerState(), BreakerClosed)
+}
+
+func TestBreaker_Canceled(t *testing.T) {
+	started := make(chan struct{}, 1)
+	stuck := make(chan struct{})
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-stuck
+	})
+	defer ts.Close()
+	defer close(stuck)
+
+	client := newClientWithConfig(Config{
+		ClientID:         "CLIENT_ID",
+		TokenURL:         ts.URL,
+		Mode:             InHeaderMode,
+		BreakerThreshold: 1,
+		BreakerCooldown:  time.Minute,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	_, err := client.ClientCredentialsToken(ctx)
+	mustEqual(t, errors.Is(err, context.Canceled), true)
+	mustEqual(t, client.BreakerState(), BreakerClosed)
+}
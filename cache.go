@@ -0,0 +1,177 @@
+package oauth2
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TokenKey identifies a token in TokenCache.
+type TokenKey struct {
+	Scopes   []string // Scopes are requested permissions, Config.Scopes are used if empty.
+	Audience string   // Audience is an `audience` parameter, a target API of the token.
+	Resource string   // Resource is a `resource` parameter, RFC 8707 resource indicator.
+	Tenant   string   // Tenant separates tokens of different tenants, it's not sent to the provider.
+}
+
+// TokenCacheConfig describes how TokenCache keeps tokens.
+type TokenCacheConfig struct {
+	// MaxEntries limits the number of cached tokens, the least recently used ones are evicted
+	// when expired ones aren't enough. Zero means no limit, expired tokens are still removed.
+	MaxEntries int
+
+	_ struct{} // enforce explicit field names.
+}
+
+// TokenCache caches client credentials tokens per TokenKey,
+// so a single client can serve tokens for several APIs.
+// It is safe for concurrent use.
+type TokenCache struct {
+	client *Client
+	config TokenCacheConfig
+
+	mu        sync.Mutex
+	entries   map[cacheKey]*cacheEntry
+	sweepSize int
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+type cacheKey struct {
+	scopes   string
+	audience string
+	resource string
+	tenant   string
+}
+
+type cacheEntry struct {
+	mu    sync.Mutex
+	token *Token
+
+	lastUsed time.Time // guarded by TokenCache.mu.
+}
+
+// NewTokenCache instantiates a new token cache for a given client and config.
+func NewTokenCache(client *Client, config TokenCacheConfig) *TokenCache {
+	tc := &TokenCache{
+		client:    client,
+		config:    config,
+		entries:   make(map[cacheKey]*cacheEntry),
+		sweepSize: minSweepSize,
+	}
+	return tc
+}
+
+// Token returns a valid token for the key, retrieving a new one if needed.
+func (tc *TokenCache) Token(ctx context.Context, key TokenKey) (*Token, error) {
+	entry := tc.entry(key)
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	valid := entry.token.validAt(timeNow())
+	if valid {
+		tc.hits.Add(1)
+	} else {
+		tc.misses.Add(1)
+	}
+	if m := tc.client.config.Metrics; m != nil {
+		m.CacheLookup(valid)
+	}
+	if valid {
+		return entry.token, nil
+	}
+
+	params := url.Values{}
+	if len(key.Scopes) > 0 {
+		params.Set("scope", tc.client.joinScopes(key.Scopes))
+	}
+	if key.Audience != "" {
+		params.Set("audience", key.Audience)
+	}
+	if key.Resource != "" {
+		params.Set("resource", key.Resource)
+	}
+
+	token, err := tc.client.ClientCredentialsTokenWithParams(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	entry.token = token
+	return token, nil
+}
+
+// Warmup eagerly retrieves tokens for the keys, for example at startup,
+// so the first Token calls don't pay for the round-trip.
+func (tc *TokenCache) Warmup(ctx context.Context, keys ...TokenKey) error {
+	for _, key := range keys {
+		if _, err := tc.Token(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tc *TokenCache) entry(key TokenKey) *cacheEntry {
+	ck := cacheKey{
+		scopes:   JoinScopes(key.Scopes),
+		audience: key.Audience,
+		resource: key.Resource,
+		tenant:   key.Tenant,
+	}
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	now := timeNow()
+
+	entry, ok := tc.entries[ck]
+	if !ok {
+		tc.shrink(now)
+
+		entry = &cacheEntry{}
+		tc.entries[ck] = entry
+	}
+	entry.lastUsed = now
+	return entry
+}
+
+// shrink makes room for a new entry: it removes expired tokens once there are enough entries,
+// then the least recently used ones above TokenCacheConfig.MaxEntries. tc.mu must be held.
+func (tc *TokenCache) shrink(now time.Time) {
+	maxEntries := tc.config.MaxEntries
+	if len(tc.entries) < tc.sweepSize && (maxEntries <= 0 || len(tc.entries) < maxEntries) {
+		return
+	}
+
+	for ck, entry := range tc.entries {
+		// a locked entry is being refreshed, it's going to have a fresh token.
+		if !entry.mu.TryLock() {
+			continue
+		}
+		expired := !entry.token.validAt(now)
+		entry.mu.Unlock()
+
+		if expired {
+			delete(tc.entries, ck)
+		}
+	}
+	tc.sweepSize = 2 * len(tc.entries)
+	if tc.sweepSize < minSweepSize {
+		tc.sweepSize = minSweepSize
+	}
+
+	for maxEntries > 0 && len(tc.entries) >= maxEntries {
+		var oldest cacheKey
+		var oldestUsed time.Time
+		for ck, entry := range tc.entries {
+			if oldestUsed.IsZero() || entry.lastUsed.Before(oldestUsed) {
+				oldest, oldestUsed = ck, entry.lastUsed
+			}
+		}
+		delete(tc.entries, oldest)
+	}
+}
@@ -0,0 +1,104 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTokenCache(t *testing.T) {
+	var calls int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		mustEqual(t, r.FormValue("grant_type"), "client_credentials")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "%s|%s|%s", "expires_in": 3600}`,
+			r.FormValue("scope"), r.FormValue("audience"), r.FormValue("resource"))
+	})
+	defer ts.Close()
+
+	cache := NewTokenCache(newClient(ts.URL), TokenCacheConfig{})
+	ctx := context.Background()
+
+	tok, err := cache.Token(ctx, TokenKey{Scopes: []string{"read", "write"}, Audience: "api-1"})
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "read write|api-1|")
+
+	tok, err = cache.Token(ctx, TokenKey{Scopes: []string{"write", "read", "read"}, Audience: "api-1"})
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "read write|api-1|")
+	mustEqual(t, calls, 1)
+
+	tok, err = cache.Token(ctx, TokenKey{Resource: "https://graph.example.com"})
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "scope1 scope2||https://graph.example.com")
+	mustEqual(t, calls, 2)
+
+	_, err = cache.Token(ctx, TokenKey{Resource: "https://graph.example.com", Tenant: "other"})
+	mustOk(t, err)
+	mustEqual(t, calls, 3)
+}
+
+func TestTokenCache_Warmup(t *testing.T) {
+	var calls int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "%s", "expires_in": 3600}`, r.FormValue("audience"))
+	})
+	defer ts.Close()
+
+	cache := NewTokenCache(newClient(ts.URL), TokenCacheConfig{})
+	ctx := context.Background()
+
+	err := cache.Warmup(ctx, TokenKey{Audience: "api-1"}, TokenKey{Audience: "api-2"})
+	mustOk(t, err)
+	mustEqual(t, calls, 2)
+
+	tok, err := cache.Token(ctx, TokenKey{Audience: "api-2"})
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "api-2")
+	mustEqual(t, calls, 2)
+}
+
+func TestTokenCache_MaxEntries(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	var calls int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "%s", "expires_in": 3600}`, r.FormValue("audience"))
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{ClientID: "CLIENT_ID", TokenURL: ts.URL, Mode: InHeaderMode})
+	cache := NewTokenCache(client, TokenCacheConfig{MaxEntries: 2})
+	ctx := context.Background()
+
+	for _, audience := range []string{"api-1", "api-2", "api-1", "api-3"} {
+		now = now.Add(time.Second)
+		_, err := cache.Token(ctx, TokenKey{Audience: audience})
+		mustOk(t, err)
+	}
+	mustEqual(t, calls, 3)
+	mustEqual(t, len(cache.entries), 2)
+
+	// api-2 was the least recently used.
+	_, err := cache.Token(ctx, TokenKey{Audience: "api-1"})
+	mustOk(t, err)
+	_, err = cache.Token(ctx, TokenKey{Audience: "api-2"})
+	mustOk(t, err)
+	mustEqual(t, calls, 4)
+
+	// expired tokens go first.
+	now = now.Add(2 * time.Hour)
+	_, err = cache.Token(ctx, TokenKey{Audience: "api-4"})
+	mustOk(t, err)
+	mustEqual(t, len(cache.entries), 1)
+}
@@ -0,0 +1,67 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ErrStateMismatch is returned by ExchangeFromURL when the state of the callback is not the expected one.
+var ErrStateMismatch = errors.New("oauth2: callback state mismatch")
+
+// AuthorizationError is an error response of the authorization endpoint, RFC 6749 section 4.1.2.1.
+type AuthorizationError struct {
+	ErrorCode        string // ErrorCode is `error`, like `access_denied`.
+	ErrorDescription string // ErrorDescription is `error_description`.
+	ErrorURI         string // ErrorURI is `error_uri`.
+}
+
+func (e *AuthorizationError) Error() string {
+	msg := "oauth2: authorization failed: " + e.ErrorCode
+	if e.ErrorDescription != "" {
+		msg += ": " + e.ErrorDescription
+	}
+	return msg
+}
+
+// ExchangeFromURL parses the callback URL the provider redirected the user to, validates it
+// and converts the authorization code into a token, for scripts and tests.
+// The issuer is validated with ValidateResponseIssuer, the state must be the expected one,
+// an error response is returned as *AuthorizationError.
+func (c *Client) ExchangeFromURL(ctx context.Context, callbackURL, expectedState string) (*Token, error) {
+	return c.ExchangeFromURLWithParams(ctx, callbackURL, expectedState, nil)
+}
+
+// ExchangeFromURLWithParams is ExchangeFromURL with additional parameters of the token request,
+// like `code_verifier` of PKCE.
+func (c *Client) ExchangeFromURLWithParams(ctx context.Context, callbackURL, expectedState string, params url.Values) (*Token, error) {
+	if expectedState == "" {
+		return nil, errors.New("oauth2: expected state is not set")
+	}
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: malformed callback URL: %w", err)
+	}
+
+	q := u.Query()
+	if err := c.ValidateResponseIssuer(q); err != nil {
+		return nil, err
+	}
+	if !secretEqual(q.Get("state"), expectedState) {
+		return nil, ErrStateMismatch
+	}
+	if code := q.Get("error"); code != "" {
+		return nil, &AuthorizationError{
+			ErrorCode:        code,
+			ErrorDescription: q.Get("error_description"),
+			ErrorURI:         q.Get("error_uri"),
+		}
+	}
+
+	code := q.Get("code")
+	if code == "" {
+		return nil, errors.New("oauth2: callback missing code")
+	}
+	return c.ExchangeWithParams(ctx, code, params)
+}
@@ -0,0 +1,60 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestExchangeFromURL(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		mustEqual(t, r.FormValue("code"), "CODE")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ProperToken"}`)
+	})
+	defer ts.Close()
+
+	client := newClient(ts.URL)
+	ctx := context.Background()
+
+	tok, err := client.ExchangeFromURL(ctx, "http://localhost:8080/callback?code=CODE&state=STATE", "STATE")
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "ProperToken")
+
+	_, err = client.ExchangeFromURL(ctx, "http://localhost:8080/callback?code=CODE&state=OTHER", "STATE")
+	mustEqual(t, err, ErrStateMismatch)
+
+	_, err = client.ExchangeFromURL(ctx, "http://localhost:8080/callback?error=access_denied&error_description=User+denied&state=STATE", "STATE")
+	var authErr *AuthorizationError
+	mustEqual(t, errors.As(err, &authErr), true)
+	mustEqual(t, authErr.ErrorCode, "access_denied")
+	mustEqual(t, err.Error(), "oauth2: authorization failed: access_denied: User denied")
+
+	_, err = client.ExchangeFromURL(ctx, "http://localhost:8080/callback?state=STATE", "STATE")
+	mustFail(t, err)
+
+	_, err = client.ExchangeFromURL(ctx, "http://localhost:8080/callback?code=CODE", "")
+	mustFail(t, err)
+}
+
+func TestExchangeFromURLWithParams(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		mustEqual(t, r.FormValue("code"), "CODE")
+		mustEqual(t, r.FormValue("code_verifier"), "VERIFIER")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ProperToken"}`)
+	})
+	defer ts.Close()
+
+	client := newClient(ts.URL)
+	params := url.Values{"code_verifier": []string{"VERIFIER"}}
+
+	tok, err := client.ExchangeFromURLWithParams(context.Background(), "http://localhost:8080/callback?code=CODE&state=STATE", "STATE", params)
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "ProperToken")
+}
@@ -0,0 +1,176 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrorCategory is a normalized category of a token endpoint error, see RetrieveError.Category.
+type ErrorCategory int
+
+const (
+	// CategoryUnknown means the error is not in the catalog.
+	CategoryUnknown ErrorCategory = 0
+
+	// CategoryReauthRequired means the grant is no longer valid, the user must sign in again
+	// (expired or revoked refresh token, MFA or reauthentication demanded by a policy).
+	CategoryReauthRequired ErrorCategory = 1
+
+	// CategoryConsentRequired means the user or an administrator must consent to the scopes.
+	CategoryConsentRequired ErrorCategory = 2
+
+	// CategoryThrottled means the client sends too many requests.
+	CategoryThrottled ErrorCategory = 3
+
+	// CategoryConfigError means the client is misconfigured: wrong credentials, redirect URI, scopes or tenant.
+	CategoryConfigError ErrorCategory = 4
+
+	// CategoryUnavailable means the provider has a transient failure.
+	CategoryUnavailable ErrorCategory = 5
+)
+
+func (c ErrorCategory) String() string {
+	switch c {
+	case CategoryUnknown:
+		return "unknown"
+	case CategoryReauthRequired:
+		return "reauth-required"
+	case CategoryConsentRequired:
+		return "consent-required"
+	case CategoryThrottled:
+		return "throttled"
+	case CategoryConfigError:
+		return "config-error"
+	case CategoryUnavailable:
+		return "unavailable"
+	default:
+		return "invalid"
+	}
+}
+
+// errorCatalog maps error codes of RFC 6749 and provider-specific ones to categories.
+// Provider codes are matched before `error`: Google `error_subtype`
+// and Microsoft `AADSTS` codes from `error_description`.
+var errorCatalog = map[string]ErrorCategory{
+	// RFC 6749 section 5.2, OIDC Core section 3.1.2.6.
+	"invalid_grant":           CategoryReauthRequired,
+	"invalid_client":          CategoryConfigError,
+	"unauthorized_client":     CategoryConfigError,
+	"unsupported_grant_type":  CategoryConfigError,
+	"invalid_scope":           CategoryConfigError,
+	"login_required":          CategoryReauthRequired,
+	"interaction_required":    CategoryReauthRequired,
+	"consent_required":        CategoryConsentRequired,
+	"temporarily_unavailable": CategoryUnavailable,
+	"server_error":            CategoryUnavailable,
+	"slow_down":               CategoryThrottled,
+
+	// Google.
+	"invalid_rapt":          CategoryReauthRequired,
+	"rapt_required":         CategoryReauthRequired,
+	"admin_policy_enforced": CategoryConsentRequired,
+
+	// GitHub.
+	"bad_verification_code":        CategoryReauthRequired,
+	"incorrect_client_credentials": CategoryConfigError,
+	"redirect_uri_mismatch":        CategoryConfigError,
+	"application_suspended":        CategoryConfigError,
+
+	// Microsoft Entra ID.
+	"AADSTS50076":   CategoryReauthRequired,  // MFA is required.
+	"AADSTS50078":   CategoryReauthRequired,  // MFA has expired.
+	"AADSTS50079":   CategoryReauthRequired,  // MFA registration is required.
+	"AADSTS50133":   CategoryReauthRequired,  // session is invalid after a password change.
+	"AADSTS50173":   CategoryReauthRequired,  // grant has expired after a password change.
+	"AADSTS70008":   CategoryReauthRequired,  // code or refresh token has expired.
+	"AADSTS700082":  CategoryReauthRequired,  // refresh token has expired due to inactivity.
+	"AADSTS65001":   CategoryConsentRequired, // consent is not granted.
+	"AADSTS65004":   CategoryConsentRequired, // user declined consent.
+	"AADSTS90094":   CategoryConsentRequired, // admin consent is required.
+	"AADSTS50196":   CategoryThrottled,       // request loop is detected.
+	"AADSTS50011":   CategoryConfigError,     // redirect URI mismatch.
+	"AADSTS90002":   CategoryConfigError,     // tenant is not found.
+	"AADSTS700016":  CategoryConfigError,     // application is not found in the tenant.
+	"AADSTS7000215": CategoryConfigError,     // invalid client secret.
+	"AADSTS7000222": CategoryConfigError,     // client secret has expired.
+}
+
+// categorize sets the category of a *RetrieveError which has none.
+func (c *Client) categorize(err error) error {
+	var rerr *RetrieveError
+	if errors.As(err, &rerr) && rerr.Category == CategoryUnknown {
+		rerr.Category = c.errorCategory(rerr)
+	}
+	return err
+}
+
+// errorCategory looks up the error in Config.ErrorCategories and the catalog,
+// a 429 status is CategoryThrottled and a 5xx status is CategoryUnavailable otherwise.
+func (c *Client) errorCategory(e *RetrieveError) ErrorCategory {
+	for _, code := range providerErrorCodes(e) {
+		if cat, ok := c.config.ErrorCategories[code]; ok {
+			return cat
+		}
+		if cat, ok := errorCatalog[code]; ok {
+			return cat
+		}
+	}
+
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return CategoryThrottled
+	case e.StatusCode >= 500:
+		return CategoryUnavailable
+	default:
+		return CategoryUnknown
+	}
+}
+
+// providerErrorCodes returns error codes of the response, the most specific first.
+func providerErrorCodes(e *RetrieveError) []string {
+	var codes []string
+
+	var ej struct {
+		Subtype string `json:"error_subtype"`
+	}
+	if json.Unmarshal(e.Body, &ej) == nil && ej.Subtype != "" {
+		codes = append(codes, ej.Subtype)
+	}
+
+	if desc := e.ErrorDescription; strings.HasPrefix(desc, "AADSTS") {
+		n := len("AADSTS")
+		for n < len(desc) && '0' <= desc[n] && desc[n] <= '9' {
+			n++
+		}
+		codes = append(codes, desc[:n])
+	}
+
+	if e.ErrorCode != "" {
+		codes = append(codes, e.ErrorCode)
+	}
+	return codes
+}
+
+// ErrorFieldResponseError is a Config.ResponseError detecting RFC 6749 error responses
+// returned with a 200 status, like GitHub does. They are returned as *RetrieveError.
+func ErrorFieldResponseError(resp *http.Response, body []byte) error {
+	var ej struct {
+		Code        string `json:"error"`
+		Description string `json:"error_description"`
+		URI         string `json:"error_uri"`
+	}
+	if err := json.Unmarshal(body, &ej); err != nil || ej.Code == "" {
+		return nil
+	}
+
+	return &RetrieveError{
+		StatusCode:       resp.StatusCode,
+		Body:             body,
+		ContentType:      responseContentType(resp),
+		ErrorCode:        ej.Code,
+		ErrorDescription: ej.Description,
+		ErrorURI:         ej.URI,
+	}
+}
@@ -0,0 +1,71 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestErrorCategory(t *testing.T) {
+	testCases := []struct {
+		status int
+		body   string
+		want   ErrorCategory
+	}{
+		{http.StatusBadRequest, `{"error": "invalid_grant", "error_description": "Token has been expired or revoked."}`, CategoryReauthRequired},
+		{http.StatusBadRequest, `{"error": "invalid_grant", "error_subtype": "invalid_rapt"}`, CategoryReauthRequired},
+		{http.StatusBadRequest, `{"error": "invalid_grant", "error_description": "AADSTS65001: The user or administrator has not consented.", "error_codes": [65001]}`, CategoryConsentRequired},
+		{http.StatusUnauthorized, `{"error": "invalid_client", "error_description": "AADSTS7000222: The provided client secret keys are expired."}`, CategoryConfigError},
+		{http.StatusBadRequest, `{"error": "custom_error"}`, CategoryUnknown},
+		{http.StatusTooManyRequests, `{"error": "custom_error"}`, CategoryThrottled},
+		{http.StatusBadGateway, `<html>Bad Gateway</html>`, CategoryUnavailable},
+	}
+
+	client := newClient("")
+	for _, tc := range testCases {
+		resp := &http.Response{
+			StatusCode: tc.status,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+		}
+		err := client.newRetrieveError(resp, []byte(tc.body))
+		mustEqual(t, err.Category, tc.want)
+	}
+
+	client = newClientWithConfig(Config{
+		ErrorCategories: map[string]ErrorCategory{
+			"custom_error":  CategoryThrottled,
+			"invalid_grant": CategoryConfigError,
+		},
+	})
+	for body, want := range map[string]ErrorCategory{
+		`{"error": "custom_error"}`:                                   CategoryThrottled,
+		`{"error": "invalid_grant"}`:                                  CategoryConfigError,
+		`{"error": "invalid_grant", "error_subtype": "invalid_rapt"}`: CategoryReauthRequired,
+	} {
+		resp := &http.Response{StatusCode: http.StatusBadRequest}
+		mustEqual(t, client.newRetrieveError(resp, []byte(body)).Category, want)
+	}
+
+	mustEqual(t, CategoryReauthRequired.String(), "reauth-required")
+}
+
+func TestErrorFieldResponseError(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"error": "bad_verification_code", "error_description": "The code passed is incorrect or expired."}`)
+	})
+	defer ts.Close()
+
+	cfg := GitHubConfig()
+	cfg.ClientID = "CLIENT_ID"
+	cfg.TokenURL = ts.URL
+
+	_, err := newClientWithConfig(cfg).Exchange(context.Background(), "code")
+	var rerr *RetrieveError
+	mustEqual(t, errors.As(err, &rerr), true)
+	mustEqual(t, rerr.StatusCode, http.StatusOK)
+	mustEqual(t, rerr.ErrorCode, "bad_verification_code")
+	mustEqual(t, rerr.Category, CategoryReauthRequired)
+}
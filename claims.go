@@ -0,0 +1,96 @@
+package oauth2
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// UnverifiedClaims are claims of a JWT access token decoded without verification, see PeekClaims.
+type UnverifiedClaims map[string]interface{}
+
+// PeekClaims decodes the claims of a JWT access token WITHOUT verifying its signature,
+// expiry, issuer or audience. Anyone can forge them: use them only for logging and routing
+// decisions, never for authorization. Tokens which are not JWTs fail with an error.
+func PeekClaims(accessToken string) (UnverifiedClaims, error) {
+	var claims UnverifiedClaims
+	if _, err := decodeJWT(accessToken, &claims); err != nil {
+		return nil, err
+	}
+	if claims == nil {
+		return nil, errMalformedJWT
+	}
+	return claims, nil
+}
+
+// Subject returns the unverified `sub` claim.
+func (c UnverifiedClaims) Subject() string {
+	return c.string("sub")
+}
+
+// Issuer returns the unverified `iss` claim.
+func (c UnverifiedClaims) Issuer() string {
+	return c.string("iss")
+}
+
+// Expiry returns the unverified `exp` claim, zero if there is none.
+func (c UnverifiedClaims) Expiry() time.Time {
+	return c.time("exp")
+}
+
+// Audience returns the unverified `aud` claim, which is a string or an array.
+func (c UnverifiedClaims) Audience() []string {
+	return c.strings("aud")
+}
+
+// Scopes returns the unverified scopes from `scope` (RFC 9068) or `scp` (Microsoft, Okta) claim,
+// which are a space-separated string or an array.
+func (c UnverifiedClaims) Scopes() []string {
+	if _, ok := c["scope"]; ok {
+		return c.strings("scope")
+	}
+	return c.strings("scp")
+}
+
+// Tenant returns the unverified tenant from `tid` (Microsoft), `tenant_id` or `tenant` claim.
+func (c UnverifiedClaims) Tenant() string {
+	for _, name := range []string{"tid", "tenant_id", "tenant"} {
+		if v := c.string(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// time returns a NumericDate claim, zero if there is none.
+func (c UnverifiedClaims) time(name string) time.Time {
+	v, ok := c[name].(float64)
+	if !ok {
+		return time.Time{}
+	}
+	sec, frac := math.Modf(v)
+	return time.Unix(int64(sec), int64(frac*1e9))
+}
+
+func (c UnverifiedClaims) string(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// strings returns a claim which is a space-separated string or an array of strings.
+func (c UnverifiedClaims) strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
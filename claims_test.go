@@ -0,0 +1,41 @@
+package oauth2
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeekClaims(t *testing.T) {
+	raw := makeJWT(t, jwtHeader{Algorithm: "RS256"}, map[string]any{
+		"sub":   "user",
+		"iss":   "https://example.com",
+		"exp":   1700000000,
+		"aud":   "api",
+		"scope": "read write",
+		"tid":   "tenant-1",
+	})
+
+	claims, err := PeekClaims(raw)
+	mustOk(t, err)
+	mustEqual(t, claims.Subject(), "user")
+	mustEqual(t, claims.Issuer(), "https://example.com")
+	mustEqual(t, claims.Expiry(), time.Unix(1700000000, 0))
+	mustEqual(t, claims.Audience(), []string{"api"})
+	mustEqual(t, claims.Scopes(), []string{"read", "write"})
+	mustEqual(t, claims.Tenant(), "tenant-1")
+	mustEqual(t, claims["tid"], any("tenant-1"))
+
+	raw = makeJWT(t, jwtHeader{Algorithm: "RS256"}, map[string]any{
+		"aud": []string{"api", "web"},
+		"scp": []string{"read"},
+	})
+	claims, err = PeekClaims(raw)
+	mustOk(t, err)
+	mustEqual(t, claims.Audience(), []string{"api", "web"})
+	mustEqual(t, claims.Scopes(), []string{"read"})
+	mustEqual(t, claims.Expiry().IsZero(), true)
+	mustEqual(t, claims.Tenant(), "")
+
+	_, err = PeekClaims("opaque-token")
+	mustFail(t, err)
+}
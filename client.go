@@ -1,29 +1,135 @@
 package oauth2
 
 import (
-	"bytes"
 	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Client represents an OAuth2 HTTP client.
 type Client struct {
-	client *http.Client
-	config Config
+	client   *http.Client
+	config   Config
+	breaker  *breaker
+	retries  *retryBudget
+	skew     int64 // clock skew in nanoseconds, see ClockSkew.
+	counters clientCounters
+	detected atomic.Int32 // mode found by AutoDetectMode, see DetectedMode.
+
+	subscribers subscribers
+
+	assertionMu sync.Mutex
+	assertion   cachedAssertion
+
+	// precomputed parts of token requests.
+	basicAuth   []string // Authorization header for InHeaderMode.
+	credentials string   // encoded form fields for InParamsMode.
 }
 
 // NewClient instantiates a new client with a given config.
 func NewClient(client *http.Client, config Config) *Client {
 	c := &Client{
-		client: client,
-		config: config,
+		client:  configureTransport(client, config),
+		config:  config,
+		breaker: newBreaker(config.BreakerThreshold, config.BreakerCooldown, config.Metrics),
+		retries: newRetryBudget(config.RetryBudget, config.RetryBudgetInterval),
 	}
+
+	c.basicAuth, c.credentials = c.encodeCredentials(config.ClientSecret)
 	return c
 }
 
+// encodeCredentials returns the Authorization header for InHeaderMode and form fields for InParamsMode.
+func (c *Client) encodeCredentials(clientSecret string) ([]string, string) {
+	clientID := c.config.ClientID
+
+	id, secret := clientID, clientSecret
+	if !c.config.RawBasicAuth {
+		id, secret = url.QueryEscape(clientID), url.QueryEscape(clientSecret)
+	}
+	basicAuth := []string{"Basic " + base64.StdEncoding.EncodeToString([]byte(id+":"+secret))}
+
+	creds := url.Values{}
+	if clientID != "" {
+		creds.Set("client_id", clientID)
+	}
+	if clientSecret != "" {
+		creds.Set("client_secret", clientSecret)
+	}
+	return basicAuth, creds.Encode()
+}
+
+// configureTransport returns a copy of the client with TLS and proxy settings from the config applied.
+func configureTransport(client *http.Client, config Config) *http.Client {
+	if config.TLSConfig == nil && config.Proxy == nil {
+		return client
+	}
+
+	var transport *http.Transport
+	switch t := client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		// unknown transport, cannot be configured.
+		return client
+	}
+
+	if config.TLSConfig != nil {
+		transport.TLSClientConfig = config.TLSConfig.Clone()
+	}
+	if config.Proxy != nil {
+		transport.Proxy = config.Proxy
+	}
+
+	c := *client
+	c.Transport = transport
+	return &c
+}
+
+// BreakerState returns the state of the token endpoint circuit breaker.
+func (c *Client) BreakerState() BreakerState {
+	return c.breaker.currentState()
+}
+
+// ClockSkew returns how far the token endpoint's clock is ahead of the local clock,
+// as measured by the last token response. It's always zero unless Config.CompensateClockSkew is set.
+func (c *Client) ClockSkew() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.skew))
+}
+
+// now returns the current time in the token endpoint's clock, for times compared by the server,
+// like JWT `iat` and `exp`. Token expiry is in the local clock and is checked with timeNow.
+func (c *Client) now() time.Time {
+	return timeNow().Add(c.ClockSkew())
+}
+
+// measureSkew updates the clock skew from the Date header of a token response.
+func (c *Client) measureSkew(resp *http.Response, received time.Time) {
+	if !c.config.CompensateClockSkew {
+		return
+	}
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return
+	}
+	// Date has a second precision, don't treat the truncation as a skew.
+	skew := date.Sub(received.Truncate(time.Second))
+	if skew >= -time.Second && skew <= time.Second {
+		skew = 0
+	}
+	atomic.StoreInt64(&c.skew, int64(skew))
+}
+
 // AuthCodeURL returns a URL to OAuth 2.0 provider's consent page
 // that asks for permissions for the required scopes explicitly.
 //
@@ -33,38 +139,102 @@ func NewClient(client *http.Client, config Config) *Client {
 // the state query parameter on your redirect callback.
 //
 // See http://tools.ietf.org/html/rfc6749#section-10.12 for more info.
+//
+// Returns an empty string if the URL cannot be built, like for an insecure AuthURL
+// or invalid scopes, use BuildAuthCodeURL to get the error.
 func (c *Client) AuthCodeURL(state string) string {
 	return c.AuthCodeURLWithParams(state, nil)
 }
 
 // AuthCodeURLWithParams same as AuthCodeURL but allows to pass additional URL parameters.
+// Returns an empty string if the URL cannot be built, see BuildAuthCodeURL.
 func (c *Client) AuthCodeURLWithParams(state string, params url.Values) string {
+	u, _ := c.BuildAuthCodeURL(state, params)
+	return u
+}
+
+// BuildAuthCodeURL same as AuthCodeURLWithParams but reports why the URL cannot be built:
+// a malformed or insecure AuthURL, invalid scopes or a too long URL.
+// Query parameters of AuthURL are kept, a fragment is not allowed (RFC 6749 section 3.1).
+// The `scope`, `max_age` and `acr_values` params take precedence over the config.
+func (c *Client) BuildAuthCodeURL(state string, params url.Values) (string, error) {
+	u, err := c.parseAuthURL()
+	if err != nil {
+		return "", err
+	}
+	v, err := c.authCodeParams(state, params)
+	if err != nil {
+		return "", err
+	}
+
+	s := encodeAuthURL(u, v)
+	if max := c.config.MaxAuthURLLength; max > 0 && len(s) > max {
+		return "", &AuthURLTooLongError{Length: len(s), Max: max}
+	}
+	return s, nil
+}
+
+// parseAuthURL returns the checked Config.AuthURL.
+func (c *Client) parseAuthURL() (*url.URL, error) {
+	if c.config.AuthURL == "" {
+		return nil, errors.New("oauth2: auth URL is not set")
+	}
+	if err := c.config.checkEndpoint("auth URL", c.config.AuthURL); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(c.config.AuthURL)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: malformed auth URL: %w", err)
+	}
+	if u.Fragment != "" {
+		return nil, errors.New("oauth2: auth URL must not have a fragment")
+	}
+	return u, nil
+}
+
+// authCodeParams returns parameters of an authorization request.
+func (c *Client) authCodeParams(state string, params url.Values) (url.Values, error) {
 	// TODO(cristaloleg): can be set once (except state).
 	v := cloneURLValues(params)
-	v.Add("response_type", "code")
+	if _, ok := v["response_type"]; !ok {
+		v.Set("response_type", "code")
+	}
 	v.Add("client_id", c.config.ClientID)
 
 	if c.config.RedirectURL != "" {
 		v.Set("redirect_uri", c.config.RedirectURL)
 	}
-	if len(c.config.Scopes) > 0 {
-		v.Set("scope", strings.Join(c.config.Scopes, " "))
+	if scope, ok := v["scope"]; ok {
+		if err := ValidateScopes(c.splitScopes(strings.Join(scope, " "))); err != nil {
+			return nil, err
+		}
+	} else if len(c.config.Scopes) > 0 {
+		if err := ValidateScopes(c.splitScopes(strings.Join(c.config.Scopes, " "))); err != nil {
+			return nil, err
+		}
+		v.Set("scope", c.joinScopes(c.config.Scopes))
 	}
 	if state != "" {
 		v.Set("state", state)
 	}
+	if _, ok := v["max_age"]; !ok && c.config.MaxAge > 0 {
+		v.Set("max_age", strconv.FormatInt(int64(c.config.MaxAge/time.Second), 10))
+	}
+	if _, ok := v["acr_values"]; !ok && len(c.config.ACRValues) > 0 {
+		v.Set("acr_values", strings.Join(c.config.ACRValues, " "))
+	}
+	return v, nil
+}
 
-	var buf bytes.Buffer
-	buf.WriteString(c.config.AuthURL)
-
-	if strings.Contains(c.config.AuthURL, "?") {
-		buf.WriteByte('&')
+// encodeAuthURL appends the parameters to the query of the auth URL.
+func encodeAuthURL(u *url.URL, v url.Values) string {
+	if u.RawQuery != "" {
+		u.RawQuery += "&" + v.Encode()
 	} else {
-		buf.WriteByte('?')
+		u.RawQuery = v.Encode()
 	}
-
-	buf.WriteString(v.Encode())
-	return buf.String()
+	u.ForceQuery = false
+	return u.String()
 }
 
 // Exchange converts an authorization code into an OAuth2 token.
@@ -75,7 +245,7 @@ func (c *Client) Exchange(ctx context.Context, code string) (*Token, error) {
 // ExchangeWithParams converts an authorization code into an OAuth2 token.
 func (c *Client) ExchangeWithParams(ctx context.Context, code string, params url.Values) (*Token, error) {
 	params = cloneURLValues(params)
-	params.Add("grant_type", "authorization_code")
+	params.Add("grant_type", GrantTypeAuthorizationCode)
 	params.Add("code", code)
 
 	if c.config.RedirectURL != "" {
@@ -84,20 +254,70 @@ func (c *Client) ExchangeWithParams(ctx context.Context, code string, params url
 	return c.retrieveToken(ctx, params)
 }
 
+// ExchangeWithScopes converts an authorization code into an OAuth2 token with given scopes,
+// for providers accepting `scope` in the token request (like Microsoft identity platform).
+func (c *Client) ExchangeWithScopes(ctx context.Context, code string, scopes ...string) (*Token, error) {
+	return c.ExchangeWithParams(ctx, code, c.scopeParams(scopes))
+}
+
+// ExchangeWithResponse is ExchangeWithParams which also returns the HTTP response of the token endpoint,
+// for provider-specific headers like rate limits or session IDs. The response body is already read and closed.
+// The response is returned on errors too, when the token endpoint responded.
+func (c *Client) ExchangeWithResponse(ctx context.Context, code string, params url.Values) (*Token, *http.Response, error) {
+	var resp *http.Response
+	ctx = context.WithValue(ctx, responseKey{}, &resp)
+
+	token, err := c.ExchangeWithParams(ctx, code, params)
+	return token, resp, err
+}
+
+// responseKey is a context key of *http.Response variable to keep the token endpoint response in.
+type responseKey struct{}
+
 // CredentialsToken retrieves a token for given username and password.
 func (c *Client) CredentialsToken(ctx context.Context, username, password string) (*Token, error) {
 	params := url.Values{
-		"grant_type": []string{"password"},
+		"grant_type": []string{GrantTypePassword},
 		"username":   []string{username},
 		"password":   []string{password},
 	}
 
 	if len(c.config.Scopes) > 0 {
-		params.Set("scope", strings.Join(c.config.Scopes, " "))
+		params.Set("scope", c.joinScopes(c.config.Scopes))
+	}
+	return c.retrieveToken(ctx, params)
+}
+
+// ClientCredentialsToken retrieves a token for the client itself using the client credentials grant.
+func (c *Client) ClientCredentialsToken(ctx context.Context) (*Token, error) {
+	return c.ClientCredentialsTokenWithParams(ctx, nil)
+}
+
+// ClientCredentialsTokenWithParams same as ClientCredentialsToken but allows to pass additional URL parameters.
+func (c *Client) ClientCredentialsTokenWithParams(ctx context.Context, params url.Values) (*Token, error) {
+	params = cloneURLValues(params)
+	params.Set("grant_type", GrantTypeClientCredentials)
+
+	if _, ok := params["scope"]; !ok && len(c.config.Scopes) > 0 {
+		params.Set("scope", c.joinScopes(c.config.Scopes))
 	}
 	return c.retrieveToken(ctx, params)
 }
 
+// ClientCredentialsTokenWithScopes same as ClientCredentialsToken but requests given scopes instead of Config.Scopes.
+func (c *Client) ClientCredentialsTokenWithScopes(ctx context.Context, scopes ...string) (*Token, error) {
+	return c.ClientCredentialsTokenWithParams(ctx, c.scopeParams(scopes))
+}
+
+// scopeParams returns request parameters with the scopes, if any.
+func (c *Client) scopeParams(scopes []string) url.Values {
+	params := url.Values{}
+	if len(scopes) > 0 {
+		params.Set("scope", c.joinScopes(scopes))
+	}
+	return params
+}
+
 // Token renews a token based on previous token.
 func (c *Client) Token(ctx context.Context, refreshToken string) (*Token, error) {
 	if refreshToken == "" {
@@ -105,77 +325,356 @@ func (c *Client) Token(ctx context.Context, refreshToken string) (*Token, error)
 	}
 
 	params := url.Values{
-		"grant_type":    []string{"refresh_token"},
+		"grant_type":    []string{GrantTypeRefreshToken},
 		"refresh_token": []string{refreshToken},
 	}
 	return c.retrieveToken(ctx, params)
 }
 
-func (c *Client) retrieveToken(ctx context.Context, params url.Values) (*Token, error) {
-	mode := c.config.Mode
+func (c *Client) retrieveToken(ctx context.Context, params url.Values) (token *Token, err error) {
+	if c.config.AuditSink != nil {
+		// generate the correlation ID once, so the event has the same ID as the request.
+		if id := c.correlationID(ctx); id != "" {
+			ctx = ContextWithCorrelationID(ctx, id)
+		}
+		event := AuditEvent{
+			Time:      time.Now(),
+			Operation: AuditTokenRequest,
+			GrantType: params.Get("grant_type"),
+		}
+		defer func() {
+			// the device flow audits only the final result of polling.
+			if isDevicePending(params, err) {
+				return
+			}
+			event.Err = err
+			c.audit(ctx, event)
+		}()
+	}
+
+	defer func() {
+		c.emitTokenRequest(ctx, params.Get("grant_type"), err)
+		if err != nil {
+			if !isDevicePending(params, err) {
+				c.counters.failures.Add(1)
+			}
+			return
+		}
+		c.counters.tokensIssued.Add(1)
+		if params.Get("grant_type") == GrantTypeRefreshToken {
+			c.counters.refreshes.Add(1)
+		}
+	}()
+
+	if m := c.config.Metrics; m != nil {
+		start := time.Now()
+		defer func() {
+			m.TokenRequest(params.Get("grant_type"), err, time.Since(start))
+		}()
+	}
+
+	if _, ok := ctx.Deadline(); !ok && c.config.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.RequestTimeout)
+		defer cancel()
+	}
+	if extra, ok := ctx.Value(paramsKey{}).(url.Values); ok {
+		for k, v := range extra {
+			if _, ok := params[k]; !ok {
+				params[k] = v
+			}
+		}
+	}
+	if scope, ok := params["scope"]; ok {
+		if err := ValidateScopes(c.splitScopes(strings.Join(scope, " "))); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := params["audience"]; !ok && c.config.Audience != "" {
+		params.Set("audience", c.config.Audience)
+	}
+
+	token, err = c.retrieveTokenWithMode(ctx, params)
+	if err != nil {
+		return nil, c.interactionRequired(err)
+	}
+	token.ObtainedAt = time.Now()
+	token.GrantType = params.Get("grant_type")
+	token.Issuer = c.config.Issuer
+	token.TokenURL = c.config.TokenURL
+	c.adjustExpiry(token)
+
+	if c.config.RejectScopeDowngrade {
+		granted := normalizeScopes(c.splitScopes(token.scope()))
+		if missing := missingScopes(granted, c.splitScopes(params.Get("scope"))); len(missing) > 0 {
+			return nil, &ScopeDowngradeError{Token: token, Missing: missing}
+		}
+	}
+	return token, nil
+}
+
+// adjustExpiry applies the expiry policy of the config to a retrieved token.
+func (c *Client) adjustExpiry(token *Token) {
+	if token.Expiry.IsZero() {
+		if c.config.AssumeExpiryIfMissing > 0 {
+			token.Expiry = time.Now().Add(c.config.AssumeExpiryIfMissing)
+		}
+		return
+	}
+
+	now := time.Now()
+	lifetime := token.Expiry.Sub(now)
+
+	switch {
+	case c.config.MinExpiry > 0 && lifetime < c.config.MinExpiry:
+		token.Expiry = now.Add(c.config.MinExpiry)
+	case c.config.MaxExpiry > 0 && lifetime > c.config.MaxExpiry:
+		token.Expiry = now.Add(c.config.MaxExpiry)
+	}
+}
+
+func (c *Client) retrieveTokenWithMode(ctx context.Context, params url.Values) (*Token, error) {
+	mode, override := ctx.Value(modeKey{}).(Mode)
+	if !override {
+		mode = c.mode()
+	}
 
 	shouldGuessAuthMode := mode == AutoDetectMode
 	if shouldGuessAuthMode {
 		mode = InHeaderMode
 	}
 
-	token, err := c.doRequest(ctx, mode, params)
+	token, err := c.doRequestRetry(ctx, mode, params)
 	if err == nil {
-		c.config.Mode = mode
+		if shouldGuessAuthMode && !override {
+			c.setDetectedMode(mode)
+		}
 		return token, nil
 	}
 	if !shouldGuessAuthMode {
 		return nil, err
 	}
 	mode = InParamsMode
+	c.counters.autoDetectFallbacks.Add(1)
 
-	token, err = c.doRequest(ctx, mode, params)
+	token, err = c.doRequestRetry(ctx, mode, params)
 	if err != nil {
 		return nil, err
 	}
-	c.config.Mode = mode
+	if !override {
+		c.setDetectedMode(mode)
+	}
 	return token, nil
 }
 
+// DetectedMode returns the mode found by AutoDetectMode, or AutoDetectMode if there is none yet.
+// Persist it and pass it as Config.Mode (or keep tokens with Token.Mode in a TokenStore)
+// so a restarted client doesn't pay for the detection again.
+func (c *Client) DetectedMode() Mode {
+	return Mode(c.detected.Load())
+}
+
+// mode returns Config.Mode or the detected mode if it's AutoDetectMode.
+func (c *Client) mode() Mode {
+	if c.config.Mode != AutoDetectMode {
+		return c.config.Mode
+	}
+	return c.DetectedMode()
+}
+
+// setDetectedMode remembers the mode found by AutoDetectMode and reports changes to ModeMetrics.
+func (c *Client) setDetectedMode(mode Mode) {
+	if old := Mode(c.detected.Swap(int32(mode))); old != mode {
+		if m, ok := c.config.Metrics.(ModeMetrics); ok {
+			m.ModeDetected(mode)
+		}
+	}
+}
+
+// seedDetectedMode reuses the mode of a stored token retrieved from the same endpoint,
+// unless the mode is already known.
+func (c *Client) seedDetectedMode(token *Token) {
+	if c.config.Mode != AutoDetectMode || token == nil || token.TokenURL != c.config.TokenURL {
+		return
+	}
+	if token.Mode != InParamsMode && token.Mode != InHeaderMode {
+		return
+	}
+	if c.detected.CompareAndSwap(int32(AutoDetectMode), int32(token.Mode)) {
+		if m, ok := c.config.Metrics.(ModeMetrics); ok {
+			m.ModeDetected(token.Mode)
+		}
+	}
+}
+
 func (c *Client) doRequest(ctx context.Context, mode Mode, params url.Values) (*Token, error) {
-	req, err := c.newTokenRequest(ctx, mode, params)
+	done, err := c.breaker.allow()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	req, err := c.newTokenRequest(ctx, c.config.TokenURL, mode, params)
 	if err != nil {
 		return nil, err
 	}
 
 	resp, err := c.client.Do(req)
+	switch {
+	case err != nil && hedgeLost(ctx):
+		// a losing hedged request says nothing about the endpoint.
+		return nil, errHedgeLost
+	case err != nil && errors.Is(ctx.Err(), context.Canceled):
+		// neither does a request cancelled by the caller, timeouts are still failures.
+		return nil, err
+	}
+	c.breaker.record(err != nil || resp.StatusCode >= 500)
 	if err != nil {
 		return nil, err
 	}
+	c.measureSkew(resp, time.Now())
 
-	token, err := parseResponse(resp)
+	if r, ok := ctx.Value(responseKey{}).(**http.Response); ok {
+		*r = resp
+	}
+
+	token, err := c.parseResponse(resp)
 	if err != nil {
 		return nil, err
 	}
+	token.Mode = mode
 	return token, nil
 }
 
-func (c *Client) newTokenRequest(ctx context.Context, mode Mode, v url.Values) (*http.Request, error) {
-	clientID, clientSecret := c.config.ClientID, c.config.ClientSecret
+// postForm sends an authenticated form to an endpoint other than the token endpoint
+// and returns the body of a successful response, otherwise a *RetrieveError.
+func (c *Client) postForm(ctx context.Context, endpoint string, mode Mode, params url.Values) ([]byte, error) {
+	req, err := c.newTokenRequest(ctx, endpoint, mode, params)
+	if err != nil {
+		return nil, err
+	}
+	return c.sendForm(req)
+}
+
+// sendForm sends a form request and returns the body of a successful response, otherwise a *RetrieveError.
+func (c *Client) sendForm(req *http.Request) ([]byte, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, c.newRetrieveError(resp, body)
+	}
+	if detect := c.config.ResponseError; detect != nil {
+		if err := detect(resp, body); err != nil {
+			return nil, c.categorize(err)
+		}
+	}
+	return body, nil
+}
+
+func (c *Client) newTokenRequest(ctx context.Context, endpoint string, mode Mode, v url.Values) (*http.Request, error) {
+	if err := c.config.checkEndpoint("endpoint", endpoint); err != nil {
+		return nil, err
+	}
+
+	var body string
+
+	basicAuth, credentials := c.basicAuth, c.credentials
+	if c.config.SecretProvider != nil && (mode == InParamsMode || mode == InHeaderMode) {
+		secret, err := c.config.SecretProvider(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("oauth2: cannot get client secret: %w", err)
+		}
+		basicAuth, credentials = c.encodeCredentials(secret)
+	}
+
+	var static string
+	var skip []string
+
+	switch mode {
+	case InParamsMode:
+		static, skip = credentials, credentialParams
 
-	if mode == InParamsMode {
+	case PrivateKeyJWTMode:
+		assertion, err := c.clientAssertion(ctx)
+		if err != nil {
+			return nil, err
+		}
 		v = cloneURLValues(v)
-		if clientID != "" {
-			v.Set("client_id", clientID)
+		v.Set("client_id", c.config.ClientID)
+		v.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+		v.Set("client_assertion", assertion)
+	}
+
+	if method := c.config.Methods[endpoint]; method != "" && method != http.MethodPost {
+		req, err := c.newQueryRequest(ctx, method, endpoint, encodeForm(v, static, skip...))
+		if err == nil && mode == InHeaderMode {
+			req.Header["Authorization"] = basicAuth
 		}
-		if clientSecret != "" {
-			v.Set("client_secret", clientSecret)
+		return req, err
+	}
+
+	contentType := formContentType
+	if enc := c.config.BodyEncoder; enc != nil {
+		b, ct, err := enc.EncodeBody(ctx, withEncodedParams(v, static))
+		if err != nil {
+			return nil, fmt.Errorf("oauth2: cannot encode request: %w", err)
 		}
+		body, contentType = string(b), []string{ct}
+	} else {
+		body = encodeForm(v, static, skip...)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.TokenURL, strings.NewReader(v.Encode()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header["Content-Type"] = contentType
+	c.setHeaders(ctx, req)
 
 	if mode == InHeaderMode {
-		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+		req.Header["Authorization"] = basicAuth
 	}
 	return req, nil
 }
+
+// newQueryRequest returns a request with parameters in the query instead of the body, see Config.Methods.
+func (c *Client) newQueryRequest(ctx context.Context, method, endpoint, query string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL.RawQuery != "" {
+		req.URL.RawQuery += "&" + query
+	} else {
+		req.URL.RawQuery = query
+	}
+	c.setHeaders(ctx, req)
+	return req, nil
+}
+
+// setHeaders sets headers common to all requests to the provider.
+func (c *Client) setHeaders(ctx context.Context, req *http.Request) {
+	req.Header["Accept-Encoding"] = acceptEncoding
+
+	for k, v := range c.config.Header {
+		req.Header[http.CanonicalHeaderKey(k)] = v
+	}
+
+	if h := c.config.CorrelationHeader; h != "" {
+		if id := c.correlationID(ctx); id != "" {
+			req.Header.Set(h, id)
+		}
+	}
+}
+
+var formContentType = []string{"application/x-www-form-urlencoded"}
+
+// credentialParams are replaced by client credentials in InParamsMode.
+var credentialParams = []string{"client_id", "client_secret"}
@@ -1,12 +1,22 @@
 package oauth2
 
 import (
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -189,6 +199,47 @@ func TestRetrieveToken_AutoDetect(t *testing.T) {
 	mustOk(t, err)
 }
 
+func TestRetrieveToken_AutoDetectReusesConnection(t *testing.T) {
+	// larger than maxBodySize, so the body is not fully read.
+	errorBody := `{"error": "invalid_client", "error_description": "` + strings.Repeat("x", maxBodySize) + `"}`
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("client_id") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, errorBody)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN", "token_type": "bearer"}`)
+	}))
+
+	var conns int32
+	ts.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	httpClient := &http.Client{Transport: &http.Transport{}}
+	defer httpClient.CloseIdleConnections()
+
+	for i := 0; i < 10; i++ {
+		// a new client for each request, so the auth mode is detected every time.
+		client := NewClient(httpClient, Config{
+			ClientID:     "CLIENT_ID",
+			ClientSecret: "CLIENT_SECRET",
+			TokenURL:     ts.URL,
+			Mode:         AutoDetectMode,
+		})
+
+		_, err := client.ClientCredentialsToken(context.Background())
+		mustOk(t, err)
+	}
+	mustEqual(t, atomic.LoadInt32(&conns), int32(1))
+}
+
 func TestExchangeRequest_WithParams(t *testing.T) {
 	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
 		mustEqual(t, r.URL.String(), "/token")
@@ -218,6 +269,37 @@ func TestExchangeRequest_WithParams(t *testing.T) {
 	mustEqual(t, tok.Extra("scope"), "user")
 }
 
+func TestExchangeRequest_WithResponse(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "exchange-code" {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-RateLimit-Remaining", "99")
+		fmt.Fprint(w, `{"access_token": "ProperToken", "token_type": "bearer"}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		ClientID: "CLIENT_ID",
+		TokenURL: ts.URL + "/token",
+		Mode:     InParamsMode,
+	})
+
+	tok, resp, err := client.ExchangeWithResponse(context.Background(), "exchange-code", nil)
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "ProperToken")
+	mustEqual(t, resp.StatusCode, http.StatusOK)
+	mustEqual(t, resp.Header.Get("X-RateLimit-Remaining"), "99")
+
+	_, resp, err = client.ExchangeWithResponse(context.Background(), "bad-code", nil)
+	mustFail(t, err)
+	mustEqual(t, resp.StatusCode, http.StatusTooManyRequests)
+	mustEqual(t, resp.Header.Get("X-RateLimit-Remaining"), "0")
+}
+
 func TestExchangeRequest_JSONResponse(t *testing.T) {
 	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
 		mustEqual(t, r.URL.String(), "/token")
@@ -328,6 +410,47 @@ func TestPasswordCredentialsTokenRequest(t *testing.T) {
 	mustEqual(t, tok.TokenType, "bearer")
 }
 
+func TestClientCredentialsTokenRequest(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		headerAuth := r.Header.Get("Authorization")
+		mustEqual(t, headerAuth, "Basic Q0xJRU5UX0lEOkNMSUVOVF9TRUNSRVQ=")
+
+		body, err := io.ReadAll(r.Body)
+		mustOk(t, err)
+		mustEqual(t, string(body), "grant_type=client_credentials&scope=scope1+scope2")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ProperToken", "token_type": "bearer"}`)
+	})
+	defer ts.Close()
+
+	client := newClient(ts.URL)
+	tok, err := client.ClientCredentialsToken(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "ProperToken")
+}
+
+func TestAudience(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		mustOk(t, err)
+		mustEqual(t, string(body), "audience=https%3A%2F%2Fapi.example.com&grant_type=refresh_token&refresh_token=REFRESH_TOKEN")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ProperToken", "token_type": "bearer"}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		ClientID: "CLIENT_ID",
+		TokenURL: ts.URL,
+		Mode:     InHeaderMode,
+		Audience: "https://api.example.com",
+	})
+	_, err := client.Token(context.Background(), "REFRESH_TOKEN")
+	mustOk(t, err)
+}
+
 // func TestTokenRefreshRequest(t *testing.T) {
 // 	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
 // 		if r.URL.String() == "/somethingelse" {
@@ -468,6 +591,362 @@ func TestRetrieveTokenWithContexts(t *testing.T) {
 	mustFail(t, err)
 }
 
+func TestRequestTimeout(t *testing.T) {
+	done := make(chan struct{})
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	})
+	defer ts.Close()
+	defer close(done)
+
+	client := newClientWithConfig(Config{
+		TokenURL:       ts.URL,
+		Mode:           InHeaderMode,
+		RequestTimeout: 50 * time.Millisecond,
+	})
+
+	_, err := client.ClientCredentialsToken(context.Background())
+	mustEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+}
+
+func TestAssumeExpiryIfMissing(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.FormValue("scope") == "short" {
+			fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN", "expires_in": 60}`)
+			return
+		}
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN"}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		TokenURL:              ts.URL,
+		Mode:                  InHeaderMode,
+		AssumeExpiryIfMissing: time.Hour,
+	})
+
+	tok, err := client.ClientCredentialsToken(context.Background())
+	mustOk(t, err)
+	mustEqual(t, time.Until(tok.Expiry).Round(time.Minute), time.Hour)
+
+	tok, err = client.ClientCredentialsTokenWithParams(context.Background(), url.Values{"scope": {"short"}})
+	mustOk(t, err)
+	mustEqual(t, time.Until(tok.Expiry).Round(time.Minute), time.Minute)
+}
+
+func TestExpiryClamping(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "ACCESS_TOKEN", "expires_in": %s}`, r.FormValue("scope"))
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		TokenURL:  ts.URL,
+		Mode:      InHeaderMode,
+		MinExpiry: 30 * time.Second,
+		MaxExpiry: 24 * time.Hour,
+	})
+
+	testCases := []struct {
+		expiresIn string
+		want      time.Duration
+	}{
+		{"1", 30 * time.Second},
+		{"3600", time.Hour},
+		{"31536000", 24 * time.Hour},
+	}
+
+	for _, tc := range testCases {
+		params := url.Values{"scope": {tc.expiresIn}}
+		tok, err := client.ClientCredentialsTokenWithParams(context.Background(), params)
+		mustOk(t, err)
+		mustEqual(t, time.Until(tok.Expiry).Round(time.Second), tc.want)
+	}
+}
+
+func TestCompensateClockSkew(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		// the token endpoint is 2 hours ahead.
+		w.Header().Set("Date", time.Now().Add(2*time.Hour).UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN", "expires_in": 3600}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		TokenURL: ts.URL,
+		Mode:     InHeaderMode,
+	})
+	_, err := client.ClientCredentialsToken(context.Background())
+	mustOk(t, err)
+	mustEqual(t, client.ClockSkew(), time.Duration(0))
+
+	client = newClientWithConfig(Config{
+		TokenURL:            ts.URL,
+		Mode:                InHeaderMode,
+		CompensateClockSkew: true,
+	})
+	tok, err := client.ClientCredentialsToken(context.Background())
+	mustOk(t, err)
+	mustEqual(t, client.ClockSkew().Round(time.Hour), 2*time.Hour)
+	mustEqual(t, client.now().Sub(time.Now()).Round(time.Hour), 2*time.Hour)
+
+	// the expiry stays in the local clock.
+	mustEqual(t, time.Until(tok.Expiry).Round(time.Hour), time.Hour)
+	mustEqual(t, tok.Valid(), true)
+	mustEqual(t, tok.validAt(time.Now().Add(time.Hour)), false)
+}
+
+func TestConfigTLS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN"}`)
+	}))
+	defer ts.Close()
+
+	cfg := Config{
+		TokenURL: ts.URL,
+		Mode:     InHeaderMode,
+	}
+
+	// private CA is unknown to the default client.
+	_, err := NewClient(http.DefaultClient, cfg).ClientCredentialsToken(context.Background())
+	mustFail(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.Certificate())
+	cfg.TLSConfig = &tls.Config{RootCAs: pool}
+
+	client := NewClient(http.DefaultClient, cfg)
+	tok, err := client.ClientCredentialsToken(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "ACCESS_TOKEN")
+	mustEqual(t, http.DefaultClient.Transport, nil)
+}
+
+func TestConfigProxy(t *testing.T) {
+	proxy := newServer(func(w http.ResponseWriter, r *http.Request) {
+		mustEqual(t, r.URL.String(), "http://idp.internal/token")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "VIA_PROXY"}`)
+	})
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	mustOk(t, err)
+
+	client := NewClient(http.DefaultClient, Config{
+		TokenURL:               "http://idp.internal/token",
+		Mode:                   InHeaderMode,
+		Proxy:                  http.ProxyURL(proxyURL),
+		AllowInsecureEndpoints: true,
+	})
+
+	tok, err := client.ClientCredentialsToken(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "VIA_PROXY")
+}
+
+func TestSecretProvider(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok {
+			r.ParseForm()
+			id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "` + id + ":" + secret + `"}`))
+	})
+	defer ts.Close()
+
+	var calls int
+	for _, mode := range []Mode{InHeaderMode, InParamsMode} {
+		client := newClientWithConfig(Config{
+			ClientID:     "CLIENT_ID",
+			ClientSecret: "STATIC_SECRET",
+			TokenURL:     ts.URL + "/token",
+			Mode:         mode,
+			SecretProvider: func(ctx context.Context) (string, error) {
+				calls++
+				return fmt.Sprintf("SECRET_%d", calls), nil
+			},
+		})
+
+		tok, err := client.ClientCredentialsToken(context.Background())
+		mustOk(t, err)
+		mustEqual(t, tok.AccessToken, fmt.Sprintf("CLIENT_ID:SECRET_%d", calls))
+
+		tok, err = client.ClientCredentialsToken(context.Background())
+		mustOk(t, err)
+		mustEqual(t, tok.AccessToken, fmt.Sprintf("CLIENT_ID:SECRET_%d", calls))
+	}
+	mustEqual(t, calls, 4)
+
+	client := newClientWithConfig(Config{
+		ClientID: "CLIENT_ID",
+		TokenURL: ts.URL + "/token",
+		Mode:     InHeaderMode,
+		SecretProvider: func(ctx context.Context) (string, error) {
+			return "", errors.New("vault is sealed")
+		},
+	})
+	_, err := client.ClientCredentialsToken(context.Background())
+	mustFail(t, err)
+	mustEqual(t, err.Error(), "oauth2: cannot get client secret: vault is sealed")
+}
+
+func TestCompressedResponse(t *testing.T) {
+	body := `{"access_token": "COMPRESSED", "token_type": "bearer"}`
+
+	encoders := map[string]func(w io.Writer) io.WriteCloser{
+		"gzip":    func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+		"raw-deflate": func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		},
+	}
+
+	for name, encoder := range encoders {
+		ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+			mustEqual(t, r.Header.Get("Accept-Encoding"), "gzip, deflate")
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Encoding", strings.TrimPrefix(name, "raw-"))
+			enc := encoder(w)
+			io.WriteString(enc, body)
+			enc.Close()
+		})
+
+		// a custom transport without transparent decompression.
+		transport := &http.Transport{DisableCompression: true}
+		client := NewClient(&http.Client{Transport: transport}, Config{
+			ClientID: "CLIENT_ID",
+			TokenURL: ts.URL + "/token",
+			Mode:     InParamsMode,
+		})
+
+		tok, err := client.ClientCredentialsToken(context.Background())
+		mustOk(t, err)
+		mustEqual(t, tok.AccessToken, "COMPRESSED")
+
+		ts.Close()
+		transport.CloseIdleConnections()
+	}
+}
+
+func TestCompressedResponse_Unsupported(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "br")
+		w.Write([]byte("not really brotli"))
+	})
+	defer ts.Close()
+
+	_, err := newClient(ts.URL).ClientCredentialsToken(context.Background())
+	mustFail(t, err)
+}
+
+func TestContextWithMode(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		_, _, basic := r.BasicAuth()
+		r.ParseForm()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/token":
+			if basic {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			mustEqual(t, r.PostForm.Get("client_secret"), "CLIENT_SECRET")
+			fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN"}`)
+		case "/introspect":
+			mustEqual(t, basic, true)
+			mustEqual(t, r.PostForm.Get("client_secret"), "")
+			fmt.Fprint(w, `{"active": true}`)
+		}
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		ClientID:         "CLIENT_ID",
+		ClientSecret:     "CLIENT_SECRET",
+		TokenURL:         ts.URL + "/token",
+		IntrospectionURL: ts.URL + "/introspect",
+		Mode:             InHeaderMode,
+	})
+
+	ctx := ContextWithMode(context.Background(), InParamsMode)
+	_, err := client.ClientCredentialsToken(ctx)
+	mustOk(t, err)
+	mustEqual(t, client.config.Mode, InHeaderMode)
+
+	_, err = client.IntrospectToken(context.Background(), "ACCESS_TOKEN", "")
+	mustOk(t, err)
+
+	// auto-detected mode of an override isn't remembered.
+	client.config.Mode = PrivateKeyJWTMode
+	ctx = ContextWithMode(context.Background(), AutoDetectMode)
+	_, err = client.IntrospectToken(ctx, "ACCESS_TOKEN", "")
+	mustOk(t, err)
+	_, err = client.ClientCredentialsToken(ctx)
+	mustOk(t, err)
+	mustEqual(t, client.config.Mode, PrivateKeyJWTMode)
+}
+
+func TestRawBasicAuth(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		mustEqual(t, ok, true)
+		mustEqual(t, id, "client id")
+		mustEqual(t, secret, "p@ss:w+rd%")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN"}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		ClientID:     "client id",
+		ClientSecret: "p@ss:w+rd%",
+		TokenURL:     ts.URL + "/token",
+		Mode:         InHeaderMode,
+		RawBasicAuth: true,
+	})
+
+	_, err := client.ClientCredentialsToken(context.Background())
+	mustOk(t, err)
+}
+
+func TestContextWithParams(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		mustEqual(t, r.PostForm.Get("tenant"), "acme")
+		mustEqual(t, r.PostForm.Get("trace"), "inner")
+		mustEqual(t, r.PostForm.Get("scope"), "read")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN"}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		ClientID: "CLIENT_ID",
+		TokenURL: ts.URL + "/token",
+		Mode:     InParamsMode,
+	})
+
+	ctx := ContextWithParams(context.Background(), url.Values{"tenant": {"acme"}, "trace": {"outer"}})
+	ctx = ContextWithParams(ctx, url.Values{"trace": {"inner"}, "scope": {"admin"}})
+
+	_, err := client.ClientCredentialsTokenWithParams(ctx, url.Values{"scope": {"read"}})
+	mustOk(t, err)
+}
+
 func newClient(url string) *Client {
 	cfg := Config{
 		ClientID:     "CLIENT_ID",
@@ -488,3 +967,144 @@ func newClientWithConfig(cfg Config) *Client {
 func newServer(h func(w http.ResponseWriter, r *http.Request)) *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(h))
 }
+
+func BenchmarkNewTokenRequest(b *testing.B) {
+	client := newClientWithConfig(Config{
+		ClientID:     "CLIENT_ID",
+		ClientSecret: "CLIENT_SECRET",
+		TokenURL:     "https://example.com/token",
+		Scopes:       []string{"scope1", "scope2"},
+	})
+	params := url.Values{
+		"grant_type": {"client_credentials"},
+		"scope":      {"scope1 scope2"},
+	}
+	ctx := context.Background()
+
+	for _, mode := range []Mode{InParamsMode, InHeaderMode} {
+		b.Run(fmt.Sprint(mode), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, err := client.newTokenRequest(ctx, client.config.TokenURL, mode, params)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenGrantMetadata(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ProperToken", "token_type": "bearer"}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		ClientID:     "CLIENT_ID",
+		ClientSecret: "CLIENT_SECRET",
+		Issuer:       "https://issuer.example.com",
+		TokenURL:     ts.URL + "/token",
+		Mode:         InParamsMode,
+	})
+
+	before := time.Now()
+	tok, err := client.ClientCredentialsToken(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.GrantType, "client_credentials")
+	mustEqual(t, tok.Issuer, "https://issuer.example.com")
+	mustEqual(t, tok.TokenURL, ts.URL+"/token")
+	mustEqual(t, tok.Mode, InParamsMode)
+	mustEqual(t, tok.ObtainedAt.Before(before), false)
+
+	data, err := json.Marshal(tok)
+	mustOk(t, err)
+
+	var decoded Token
+	mustOk(t, json.Unmarshal(data, &decoded))
+	mustEqual(t, decoded.GrantType, "client_credentials")
+	mustEqual(t, decoded.TokenURL, ts.URL+"/token")
+	mustEqual(t, decoded.Mode, InParamsMode)
+	mustEqual(t, decoded.ObtainedAt.Equal(tok.ObtainedAt), true)
+	mustEqual(t, strings.Contains(string(data), `"mode":"client_secret_post"`), true)
+}
+
+func TestDetectedMode(t *testing.T) {
+	var requests int32
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN", "refresh_token": "REFRESH_TOKEN", "expires_in": 1}`)
+	})
+	defer ts.Close()
+
+	m := &metricsRecorder{}
+	client := newClientWithConfig(Config{
+		ClientID:     "CLIENT_ID",
+		ClientSecret: "CLIENT_SECRET",
+		TokenURL:     ts.URL,
+		Metrics:      m,
+	})
+	mustEqual(t, client.DetectedMode(), AutoDetectMode)
+
+	token, err := client.ClientCredentialsToken(context.Background())
+	mustOk(t, err)
+	mustEqual(t, client.DetectedMode(), InParamsMode)
+	mustEqual(t, m.detectedModes, []Mode{InParamsMode})
+	mustEqual(t, atomic.LoadInt32(&requests), int32(2))
+
+	_, err = client.ClientCredentialsToken(context.Background())
+	mustOk(t, err)
+	mustEqual(t, m.detectedModes, []Mode{InParamsMode})
+	mustEqual(t, atomic.LoadInt32(&requests), int32(3))
+
+	// a restarted client reuses the mode of the stored token.
+	restarted := newClientWithConfig(Config{
+		ClientID:     "CLIENT_ID",
+		ClientSecret: "CLIENT_SECRET",
+		TokenURL:     ts.URL,
+	})
+	ts2 := NewTokenSource(restarted, token, TokenSourceConfig{})
+	mustEqual(t, restarted.DetectedMode(), InParamsMode)
+
+	_, err = ts2.Token(context.Background())
+	mustOk(t, err)
+	mustEqual(t, atomic.LoadInt32(&requests), int32(4))
+}
+
+func TestScopesPerCall(t *testing.T) {
+	var body string
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		mustOk(t, err)
+		body = string(b)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ProperToken", "token_type": "bearer"}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		ClientID: "CLIENT_ID",
+		TokenURL: ts.URL,
+		Scopes:   []string{"scope1", "scope2"},
+		Mode:     InParamsMode,
+	})
+
+	_, err := client.ClientCredentialsTokenWithScopes(context.Background(), "read", "write")
+	mustOk(t, err)
+	mustEqual(t, body, "grant_type=client_credentials&scope=read+write&client_id=CLIENT_ID")
+
+	_, err = client.ClientCredentialsTokenWithScopes(context.Background())
+	mustOk(t, err)
+	mustEqual(t, body, "grant_type=client_credentials&scope=scope1+scope2&client_id=CLIENT_ID")
+
+	_, err = client.ExchangeWithScopes(context.Background(), "CODE", "openid")
+	mustOk(t, err)
+	mustEqual(t, body, "code=CODE&grant_type=authorization_code&scope=openid&client_id=CLIENT_ID")
+}
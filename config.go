@@ -0,0 +1,310 @@
+package oauth2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigFromEnv returns a config from environment variables named as the prefix
+// followed by an upper-case field name, like `OAUTH2_CLIENT_ID` and `OAUTH2_TOKEN_URL` for the prefix `OAUTH2_`.
+// See ConfigFromJSON for the field names and value formats, lists are separated by spaces or commas.
+// Fields like Signer and TLSConfig can't be loaded and must be set in code.
+func ConfigFromEnv(prefix string) (Config, error) {
+	var config Config
+	for _, f := range configFields {
+		name := prefix + strings.ToUpper(f.name)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := f.set(&config, value); err != nil {
+			return Config{}, fmt.Errorf("oauth2: invalid %s: %w", name, err)
+		}
+	}
+	return config, nil
+}
+
+// ConfigFromJSON returns a config from a JSON object with snake case field names,
+// like `client_id`, `token_url`, `userinfo_url` and `request_timeout`.
+// Durations are strings like `30s`, mode is a name accepted by ParseMode,
+// the proxy is set with `proxy_url`. Unknown fields are rejected.
+//
+// `header`, `methods` and `error_categories` are objects of strings (JSON objects in environment variables too),
+// error categories are names like `reauth-required`, see ErrorCategory.String.
+// `expires_in_compat` is a list of `string`, `fraction`, `alias` and `ignore_malformed`,
+// `userinfo_token_placement` is `header`, `body` or `query`.
+func ConfigFromJSON(data []byte) (Config, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return Config{}, fmt.Errorf("oauth2: malformed config: %w", err)
+	}
+
+	values := make(map[string]string, len(fields))
+	for name, raw := range fields {
+		value, err := jsonFieldValue(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("oauth2: invalid %s: %w", name, err)
+		}
+		values[name] = value
+	}
+	return configFromValues(values)
+}
+
+// configFromValues returns a config from field values in the form of environment variables.
+func configFromValues(values map[string]string) (Config, error) {
+	var config Config
+	for _, f := range configFields {
+		value, ok := values[f.name]
+		if !ok {
+			continue
+		}
+		delete(values, f.name)
+
+		if err := f.set(&config, value); err != nil {
+			return Config{}, fmt.Errorf("oauth2: invalid %s: %w", f.name, err)
+		}
+	}
+	for name := range values {
+		return Config{}, fmt.Errorf("oauth2: unknown config field %q", name)
+	}
+	return config, nil
+}
+
+// jsonFieldValue converts a JSON value to the form of an environment variable.
+func jsonFieldValue(raw json.RawMessage) (string, error) {
+	raw = bytes.TrimSpace(raw)
+	switch {
+	case len(raw) == 0:
+		return "", nil
+	case raw[0] == '"':
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	case raw[0] == '[':
+		var list []string
+		err := json.Unmarshal(raw, &list)
+		return strings.Join(list, " "), err
+	case string(raw) == "null":
+		return "", nil
+	default:
+		return string(raw), nil
+	}
+}
+
+type configField struct {
+	name string
+	set  func(c *Config, value string) error
+}
+
+var configFields = []configField{
+	stringField("client_id", func(c *Config) *string { return &c.ClientID }),
+	stringField("client_secret", func(c *Config) *string { return &c.ClientSecret }),
+	stringField("issuer", func(c *Config) *string { return &c.Issuer }),
+	listField("expected_issuers", func(c *Config) *[]string { return &c.ExpectedIssuers }),
+	listField("expected_audiences", func(c *Config) *[]string { return &c.ExpectedAudiences }),
+	stringField("access_token_type", func(c *Config) *string { return &c.AccessTokenType }),
+	stringField("auth_url", func(c *Config) *string { return &c.AuthURL }),
+	stringField("pushed_auth_url", func(c *Config) *string { return &c.PushedAuthURL }),
+	stringField("token_url", func(c *Config) *string { return &c.TokenURL }),
+	stringField("device_url", func(c *Config) *string { return &c.DeviceURL }),
+	stringField("introspection_url", func(c *Config) *string { return &c.IntrospectionURL }),
+	stringField("revocation_url", func(c *Config) *string { return &c.RevocationURL }),
+	stringField("userinfo_url", func(c *Config) *string { return &c.UserInfoURL }),
+	{"userinfo_token_placement", func(c *Config, v string) (err error) {
+		c.UserInfoTokenPlacement, err = parseTokenPlacement(v)
+		return err
+	}},
+	{"mode", func(c *Config, v string) (err error) {
+		c.Mode, err = ParseMode(v)
+		return err
+	}},
+	{"introspection_mode", func(c *Config, v string) (err error) {
+		c.IntrospectionMode, err = ParseMode(v)
+		return err
+	}},
+	boolField("allow_insecure_endpoints", func(c *Config) *bool { return &c.AllowInsecureEndpoints }),
+	boolField("raw_basic_auth", func(c *Config) *bool { return &c.RawBasicAuth }),
+	boolField("require_response_issuer", func(c *Config) *bool { return &c.RequireResponseIssuer }),
+	boolField("reuse_client_assertion", func(c *Config) *bool { return &c.ReuseClientAssertion }),
+	{"expires_in_compat", func(c *Config, v string) (err error) {
+		c.ExpiresInCompat, err = parseExpiresInCompat(v)
+		return err
+	}},
+	boolField("strict_expires_in", func(c *Config) *bool { return &c.StrictExpiresIn }),
+	stringField("redirect_url", func(c *Config) *string { return &c.RedirectURL }),
+	listField("scopes", func(c *Config) *[]string { return &c.Scopes }),
+	stringField("scope_separator", func(c *Config) *string { return &c.ScopeSeparator }),
+	stringField("audience", func(c *Config) *string { return &c.Audience }),
+	boolField("reject_scope_downgrade", func(c *Config) *bool { return &c.RejectScopeDowngrade }),
+	durationField("assume_expiry_if_missing", func(c *Config) *time.Duration { return &c.AssumeExpiryIfMissing }),
+	durationField("min_expiry", func(c *Config) *time.Duration { return &c.MinExpiry }),
+	durationField("max_expiry", func(c *Config) *time.Duration { return &c.MaxExpiry }),
+	boolField("compensate_clock_skew", func(c *Config) *bool { return &c.CompensateClockSkew }),
+	durationField("max_age", func(c *Config) *time.Duration { return &c.MaxAge }),
+	listField("acr_values", func(c *Config) *[]string { return &c.ACRValues }),
+	{"max_auth_url_length", func(c *Config, v string) (err error) {
+		c.MaxAuthURLLength, err = strconv.Atoi(v)
+		return err
+	}},
+	{"proxy_url", func(c *Config, v string) error {
+		if v == "" {
+			c.Proxy = nil
+			return nil
+		}
+		u, err := url.Parse(v)
+		if err != nil {
+			return err
+		}
+		c.Proxy = http.ProxyURL(u)
+		return nil
+	}},
+	durationField("request_timeout", func(c *Config) *time.Duration { return &c.RequestTimeout }),
+	{"max_error_body_size", func(c *Config, v string) (err error) {
+		c.MaxErrorBodySize, err = strconv.Atoi(v)
+		return err
+	}},
+	stringField("correlation_header", func(c *Config) *string { return &c.CorrelationHeader }),
+	{"header", func(c *Config, v string) error {
+		m, err := parseStringMap(v)
+		if err != nil || m == nil {
+			c.Header = nil
+			return err
+		}
+		c.Header = http.Header{}
+		for k, v := range m {
+			c.Header.Set(k, v)
+		}
+		return nil
+	}},
+	{"methods", func(c *Config, v string) (err error) {
+		c.Methods, err = parseStringMap(v)
+		return err
+	}},
+	{"error_categories", func(c *Config, v string) error {
+		m, err := parseStringMap(v)
+		if err != nil || m == nil {
+			c.ErrorCategories = nil
+			return err
+		}
+		c.ErrorCategories = make(map[string]ErrorCategory, len(m))
+		for code, name := range m {
+			category, err := parseErrorCategory(name)
+			if err != nil {
+				return err
+			}
+			c.ErrorCategories[code] = category
+		}
+		return nil
+	}},
+	{"breaker_threshold", func(c *Config, v string) (err error) {
+		c.BreakerThreshold, err = strconv.Atoi(v)
+		return err
+	}},
+	durationField("breaker_cooldown", func(c *Config) *time.Duration { return &c.BreakerCooldown }),
+	{"max_retries", func(c *Config, v string) (err error) {
+		c.MaxRetries, err = strconv.Atoi(v)
+		return err
+	}},
+	durationField("retry_backoff", func(c *Config) *time.Duration { return &c.RetryBackoff }),
+	{"retry_budget", func(c *Config, v string) (err error) {
+		c.RetryBudget, err = strconv.Atoi(v)
+		return err
+	}},
+	durationField("retry_budget_interval", func(c *Config) *time.Duration { return &c.RetryBudgetInterval }),
+	durationField("hedge_delay", func(c *Config) *time.Duration { return &c.HedgeDelay }),
+}
+
+func stringField(name string, field func(c *Config) *string) configField {
+	return configField{name, func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}}
+}
+
+func boolField(name string, field func(c *Config) *bool) configField {
+	return configField{name, func(c *Config, v string) (err error) {
+		*field(c), err = strconv.ParseBool(v)
+		return err
+	}}
+}
+
+func durationField(name string, field func(c *Config) *time.Duration) configField {
+	return configField{name, func(c *Config, v string) (err error) {
+		*field(c), err = time.ParseDuration(v)
+		return err
+	}}
+}
+
+func listField(name string, field func(c *Config) *[]string) configField {
+	return configField{name, func(c *Config, v string) error {
+		*field(c) = splitList(v)
+		return nil
+	}}
+}
+
+// splitList splits a list separated by spaces or commas.
+func splitList(v string) []string {
+	return strings.FieldsFunc(v, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+}
+
+// parseStringMap parses a JSON object of strings, an empty value is a nil map.
+func parseStringMap(v string) (map[string]string, error) {
+	if strings.TrimSpace(v) == "" {
+		return nil, nil
+	}
+	var m map[string]string
+	if err := json.Unmarshal([]byte(v), &m); err != nil {
+		return nil, fmt.Errorf("want an object of strings: %w", err)
+	}
+	return m, nil
+}
+
+var expiresInCompatNames = map[string]ExpiresInCompat{
+	"string":           ExpiresInString,
+	"fraction":         ExpiresInFraction,
+	"alias":            ExpiresAlias,
+	"ignore_malformed": ExpiresInIgnoreMalformed,
+}
+
+func parseExpiresInCompat(v string) (ExpiresInCompat, error) {
+	var compat ExpiresInCompat
+	for _, name := range splitList(v) {
+		c, ok := expiresInCompatNames[strings.ToLower(name)]
+		if !ok {
+			return 0, fmt.Errorf("unknown expires_in compatibility %q", name)
+		}
+		compat |= c
+	}
+	return compat, nil
+}
+
+func parseTokenPlacement(v string) (TokenPlacement, error) {
+	switch strings.ToLower(v) {
+	case "", "header":
+		return TokenInHeader, nil
+	case "body":
+		return TokenInBody, nil
+	case "query":
+		return TokenInQuery, nil
+	default:
+		return 0, fmt.Errorf("unknown token placement %q", v)
+	}
+}
+
+func parseErrorCategory(v string) (ErrorCategory, error) {
+	for c := CategoryUnknown; c <= CategoryUnavailable; c++ {
+		if strings.EqualFold(c.String(), v) {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown error category %q", v)
+}
@@ -0,0 +1,258 @@
+package oauth2
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("APP_CLIENT_ID", "CLIENT_ID")
+	t.Setenv("APP_CLIENT_SECRET", "CLIENT_SECRET")
+	t.Setenv("APP_TOKEN_URL", "https://example.com/token")
+	t.Setenv("APP_USERINFO_URL", "https://example.com/userinfo")
+	t.Setenv("APP_MODE", "client_secret_basic")
+	t.Setenv("APP_SCOPES", "openid, email profile")
+	t.Setenv("APP_REQUEST_TIMEOUT", "5s")
+	t.Setenv("APP_COMPENSATE_CLOCK_SKEW", "true")
+	t.Setenv("APP_BREAKER_THRESHOLD", "3")
+	t.Setenv("APP_PROXY_URL", "http://proxy.internal:3128")
+	t.Setenv("OTHER_CLIENT_ID", "OTHER")
+
+	config, err := ConfigFromEnv("APP_")
+	mustOk(t, err)
+	mustEqual(t, config.ClientID, "CLIENT_ID")
+	mustEqual(t, config.ClientSecret, "CLIENT_SECRET")
+	mustEqual(t, config.TokenURL, "https://example.com/token")
+	mustEqual(t, config.UserInfoURL, "https://example.com/userinfo")
+	mustEqual(t, config.Mode, InHeaderMode)
+	mustEqual(t, config.Scopes, []string{"openid", "email", "profile"})
+	mustEqual(t, config.RequestTimeout, 5*time.Second)
+	mustEqual(t, config.CompensateClockSkew, true)
+	mustEqual(t, config.BreakerThreshold, 3)
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com", http.NoBody)
+	proxy, err := config.Proxy(req)
+	mustOk(t, err)
+	mustEqual(t, proxy.Host, "proxy.internal:3128")
+
+	t.Setenv("APP_MAX_EXPIRY", "an hour")
+	_, err = ConfigFromEnv("APP_")
+	mustFail(t, err)
+	mustEqual(t, err.Error(), `oauth2: invalid APP_MAX_EXPIRY: time: invalid duration "an hour"`)
+}
+
+func TestConfigFromJSON(t *testing.T) {
+	config, err := ConfigFromJSON([]byte(`{
+		"client_id": "CLIENT_ID",
+		"issuer": "https://example.com",
+		"device_url": "https://example.com/device",
+		"introspection_url": "https://example.com/introspect",
+		"mode": "params",
+		"introspection_mode": "client_secret_basic",
+		"scopes": ["openid", "email"],
+		"acr_values": null,
+		"reject_scope_downgrade": true,
+		"max_age": "1h",
+		"breaker_threshold": 5,
+		"breaker_cooldown": "30s",
+		"max_retries": 3,
+		"retry_budget": 10
+	}`))
+	mustOk(t, err)
+	mustEqual(t, config.ClientID, "CLIENT_ID")
+	mustEqual(t, config.Issuer, "https://example.com")
+	mustEqual(t, config.DeviceURL, "https://example.com/device")
+	mustEqual(t, config.IntrospectionURL, "https://example.com/introspect")
+	mustEqual(t, config.Mode, InParamsMode)
+	mustEqual(t, config.IntrospectionMode, InHeaderMode)
+	mustEqual(t, config.Scopes, []string{"openid", "email"})
+	mustEqual(t, len(config.ACRValues), 0)
+	mustEqual(t, config.RejectScopeDowngrade, true)
+	mustEqual(t, config.MaxAge, time.Hour)
+	mustEqual(t, config.BreakerThreshold, 5)
+	mustEqual(t, config.BreakerCooldown, 30*time.Second)
+	mustEqual(t, config.MaxRetries, 3)
+	mustEqual(t, config.RetryBudget, 10)
+
+	_, err = ConfigFromJSON([]byte(`{"client_id": "CLIENT_ID", "tokn_url": "https://example.com/token"}`))
+	mustFail(t, err)
+	mustEqual(t, err.Error(), `oauth2: unknown config field "tokn_url"`)
+
+	_, err = ConfigFromJSON([]byte(`{"mode": "mtls"}`))
+	mustFail(t, err)
+
+	_, err = ConfigFromJSON([]byte(`{"breaker_threshold": "many"}`))
+	mustFail(t, err)
+
+	_, err = ConfigFromJSON([]byte(`[]`))
+	mustFail(t, err)
+}
+
+func TestConfigFromJSON_Maps(t *testing.T) {
+	config, err := ConfigFromJSON([]byte(`{
+		"header": {"x-tenant": "TENANT"},
+		"methods": {"token": "PUT"},
+		"error_categories": {"temporarily_unavailable": "throttled", "login_required": "reauth-required"},
+		"expires_in_compat": ["string", "alias"],
+		"userinfo_token_placement": "body"
+	}`))
+	mustOk(t, err)
+	mustEqual(t, config.Header, http.Header{"X-Tenant": {"TENANT"}})
+	mustEqual(t, config.Methods, map[string]string{"token": "PUT"})
+	mustEqual(t, config.ErrorCategories, map[string]ErrorCategory{
+		"temporarily_unavailable": CategoryThrottled,
+		"login_required":          CategoryReauthRequired,
+	})
+	mustEqual(t, config.ExpiresInCompat, ExpiresInString|ExpiresAlias)
+	mustEqual(t, config.UserInfoTokenPlacement, TokenInBody)
+
+	t.Setenv("APP_METHODS", `{"userinfo": "POST"}`)
+	t.Setenv("APP_EXPIRES_IN_COMPAT", "fraction,ignore_malformed")
+	t.Setenv("APP_USERINFO_TOKEN_PLACEMENT", "query")
+	config, err = ConfigFromEnv("APP_")
+	mustOk(t, err)
+	mustEqual(t, config.Methods, map[string]string{"userinfo": "POST"})
+	mustEqual(t, config.ExpiresInCompat, ExpiresInFraction|ExpiresInIgnoreMalformed)
+	mustEqual(t, config.UserInfoTokenPlacement, TokenInQuery)
+
+	_, err = ConfigFromJSON([]byte(`{"error_categories": {"login_required": "later"}}`))
+	mustFail(t, err)
+	_, err = ConfigFromJSON([]byte(`{"expires_in_compat": ["lenient"]}`))
+	mustFail(t, err)
+	_, err = ConfigFromJSON([]byte(`{"userinfo_token_placement": "cookie"}`))
+	mustFail(t, err)
+	_, err = ConfigFromJSON([]byte(`{"header": ["x-tenant"]}`))
+	mustFail(t, err)
+}
+
+func TestConfigFromYAML(t *testing.T) {
+	config, err := ConfigFromYAML([]byte(`---
+# the provider
+client_id: CLIENT_ID
+client_secret: "SECRET # not a comment"
+token_url: https://example.com/token # a comment
+mode: params
+scopes: [openid, email]
+acr_values:
+  - urn:mace:incommon:iap:silver
+  - 'urn:mace:incommon:iap:bronze'
+audience: ~
+request_timeout: 5s
+breaker_threshold: 3
+compensate_clock_skew: true
+header:
+  X-Tenant: TENANT
+error_categories:
+  login_required: reauth-required
+`))
+	mustOk(t, err)
+	mustEqual(t, config.ClientID, "CLIENT_ID")
+	mustEqual(t, config.ClientSecret, "SECRET # not a comment")
+	mustEqual(t, config.TokenURL, "https://example.com/token")
+	mustEqual(t, config.Mode, InParamsMode)
+	mustEqual(t, config.Scopes, []string{"openid", "email"})
+	mustEqual(t, config.ACRValues, []string{"urn:mace:incommon:iap:silver", "urn:mace:incommon:iap:bronze"})
+	mustEqual(t, config.Audience, "")
+	mustEqual(t, config.RequestTimeout, 5*time.Second)
+	mustEqual(t, config.BreakerThreshold, 3)
+	mustEqual(t, config.CompensateClockSkew, true)
+	mustEqual(t, config.Header, http.Header{"X-Tenant": {"TENANT"}})
+	mustEqual(t, config.ErrorCategories, map[string]ErrorCategory{"login_required": CategoryReauthRequired})
+
+	testCases := []struct {
+		data string
+		err  string
+	}{
+		{"client_id: A\nclient_id: B", `oauth2: malformed config: line 2: duplicate field "client_id"`},
+		{"client_id", `oauth2: malformed config: line 1: want a key`},
+		{"client_id: A\n  - B", `oauth2: malformed config: line 2: unexpected indentation`},
+		{"scopes: [openid", `oauth2: malformed config: line 1: unterminated list "[openid"`},
+		{"client_secret: \"SECRET", `oauth2: malformed config: line 1: malformed string "SECRET`},
+		{"header: {x: y}", `oauth2: malformed config: line 1: unsupported value {x: y}`},
+		{"tokn_url: https://example.com/token", `oauth2: unknown config field "tokn_url"`},
+		{"breaker_threshold: many", `oauth2: invalid breaker_threshold: strconv.Atoi: parsing "many": invalid syntax`},
+	}
+	for _, tc := range testCases {
+		_, err := ConfigFromYAML([]byte(strings.ReplaceAll(tc.data, `\n`, "\n")))
+		mustFail(t, err)
+		mustEqual(t, err.Error(), tc.err)
+	}
+}
+
+// TestConfigFields checks that every Config field can be loaded, except the ones that can only be set in code.
+func TestConfigFields(t *testing.T) {
+	codeOnly := map[string]bool{
+		"SecretProvider":     true,
+		"Signer":             true,
+		"IntrospectionToken": true,
+		"TLSConfig":          true,
+		"ResponseError":      true,
+		"BodyEncoder":        true,
+		"CorrelationID":      true,
+		"AuditSink":          true,
+		"Metrics":            true,
+		"_":                  true,
+	}
+	renamed := map[string]string{
+		"UserInfoURL":            "userinfo_url",
+		"UserInfoTokenPlacement": "userinfo_token_placement",
+		"Proxy":                  "proxy_url",
+	}
+
+	var want []string
+	typ := reflect.TypeOf(Config{})
+	for i := 0; i < typ.NumField(); i++ {
+		name := typ.Field(i).Name
+		switch {
+		case codeOnly[name]:
+		case renamed[name] != "":
+			want = append(want, renamed[name])
+		default:
+			want = append(want, snakeCase(name))
+		}
+	}
+
+	var have []string
+	for _, f := range configFields {
+		have = append(have, f.name)
+	}
+	sort.Strings(want)
+	sort.Strings(have)
+	mustEqual(t, have, want)
+}
+
+// snakeCase converts a Go name to snake case, keeping acronyms together: ACRValues is acr_values.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+func TestParseMode(t *testing.T) {
+	for _, mode := range []Mode{AutoDetectMode, InParamsMode, InHeaderMode, PrivateKeyJWTMode} {
+		m, err := ParseMode(mode.String())
+		mustOk(t, err)
+		mustEqual(t, m, mode)
+	}
+
+	m, err := ParseMode("Header")
+	mustOk(t, err)
+	mustEqual(t, m, InHeaderMode)
+
+	mustEqual(t, Mode(42).String(), "Mode(42)")
+}
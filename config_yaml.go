@@ -0,0 +1,183 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ConfigFromYAML returns a config from a YAML mapping with the field names and value formats of ConfigFromJSON.
+// Only a subset of YAML is supported: plain and quoted scalars, flow `[a, b]` and block `- a` lists,
+// one level of nested mappings for `header`, `methods` and `error_categories`, and comments.
+// Unknown fields are rejected.
+func ConfigFromYAML(data []byte) (Config, error) {
+	values, err := parseYAML(string(data))
+	if err != nil {
+		return Config{}, fmt.Errorf("oauth2: malformed config: %w", err)
+	}
+	return configFromValues(values)
+}
+
+// parseYAML converts a YAML mapping to values in the form of environment variables.
+func parseYAML(data string) (map[string]string, error) {
+	values := map[string]string{}
+
+	var key string
+	var list []string
+	var nested map[string]string
+	flush := func() error {
+		switch {
+		case list != nil:
+			values[key] = strings.Join(list, " ")
+		case nested != nil:
+			b, err := json.Marshal(nested)
+			if err != nil {
+				return err
+			}
+			values[key] = string(b)
+		}
+		list, nested = nil, nil
+		return nil
+	}
+
+	for i, line := range strings.Split(data, "\n") {
+		lineNo := i + 1
+		line = strings.TrimRight(stripYAMLComment(line), " \t\r")
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || line == "---" {
+			continue
+		}
+		if strings.HasPrefix(line, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", lineNo)
+		}
+
+		if trimmed != line {
+			if key == "" || values[key] != "" {
+				return nil, fmt.Errorf("line %d: unexpected indentation", lineNo)
+			}
+			if item, ok := cutYAMLListItem(trimmed); ok {
+				if nested != nil {
+					return nil, fmt.Errorf("line %d: list item in a mapping", lineNo)
+				}
+				v, err := yamlScalar(item)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNo, err)
+				}
+				list = append(list, v)
+				continue
+			}
+			k, v, ok := strings.Cut(trimmed, ":")
+			if !ok || list != nil {
+				return nil, fmt.Errorf("line %d: want a list item or a key", lineNo)
+			}
+			k, err := yamlScalar(strings.TrimSpace(k))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			v, err = yamlScalar(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			if nested == nil {
+				nested = map[string]string{}
+			}
+			nested[k] = v
+			continue
+		}
+
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: want a key", lineNo)
+		}
+		key = strings.TrimSpace(k)
+		if _, ok := values[key]; ok {
+			return nil, fmt.Errorf("line %d: duplicate field %q", lineNo, key)
+		}
+		v, err := yamlValue(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		values[key] = v
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// yamlValue converts a scalar or a flow list.
+func yamlValue(v string) (string, error) {
+	if !strings.HasPrefix(v, "[") {
+		return yamlScalar(v)
+	}
+	if !strings.HasSuffix(v, "]") {
+		return "", fmt.Errorf("unterminated list %q", v)
+	}
+	var list []string
+	for _, item := range strings.Split(v[1:len(v)-1], ",") {
+		item, err := yamlScalar(strings.TrimSpace(item))
+		if err != nil {
+			return "", err
+		}
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+	return strings.Join(list, " "), nil
+}
+
+func yamlScalar(v string) (string, error) {
+	switch {
+	case v == "" || v == "~" || v == "null":
+		return "", nil
+	case v[0] == '"':
+		s, err := strconv.Unquote(v)
+		if err != nil {
+			return "", fmt.Errorf("malformed string %s", v)
+		}
+		return s, nil
+	case v[0] == '\'':
+		if len(v) < 2 || v[len(v)-1] != '\'' {
+			return "", fmt.Errorf("malformed string %s", v)
+		}
+		return strings.ReplaceAll(v[1:len(v)-1], "''", "'"), nil
+	case v[0] == '{' || v[0] == '|' || v[0] == '>' || v[0] == '&' || v[0] == '*':
+		return "", fmt.Errorf("unsupported value %s", v)
+	default:
+		return v, nil
+	}
+}
+
+func cutYAMLListItem(line string) (string, bool) {
+	if line == "-" {
+		return "", true
+	}
+	if strings.HasPrefix(line, "- ") {
+		return strings.TrimSpace(line[2:]), true
+	}
+	return "", false
+}
+
+// stripYAMLComment removes a comment that starts a line or follows a space outside of quotes.
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,24 @@
+package oauth2
+
+import (
+	"context"
+)
+
+type correlationIDKey struct{}
+
+// ContextWithCorrelationID returns a context with a correlation ID for token requests.
+// See Config.CorrelationHeader.
+func ContextWithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// correlationID returns a correlation ID from the context or from the generator.
+func (c *Client) correlationID(ctx context.Context) string {
+	if id, ok := ctx.Value(correlationIDKey{}).(string); ok && id != "" {
+		return id
+	}
+	if c.config.CorrelationID != nil {
+		return c.config.CorrelationID(ctx)
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCorrelationID(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Correlation-Id")
+		w.Header().Set("X-Correlation-Id", id)
+
+		if id == "from-context" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "%s"}`, id)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		TokenURL:          ts.URL,
+		Mode:              InHeaderMode,
+		CorrelationHeader: "X-Correlation-Id",
+		CorrelationID: func(ctx context.Context) string {
+			return "generated"
+		},
+	})
+
+	tok, err := client.ClientCredentialsToken(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "generated")
+
+	ctx := ContextWithCorrelationID(context.Background(), "from-context")
+	_, err = client.ClientCredentialsToken(ctx)
+
+	var rerr *RetrieveError
+	mustEqual(t, errors.As(err, &rerr), true)
+	mustEqual(t, rerr.RequestID, "from-context")
+}
@@ -0,0 +1,191 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// DeviceAuth represents a device authorization response, RFC 8628 section 3.2.
+type DeviceAuth struct {
+	DeviceCode              string    `json:"device_code"`                         // DeviceCode is used to poll for a token.
+	UserCode                string    `json:"user_code"`                           // UserCode is entered by the user on the verification page.
+	VerificationURI         string    `json:"verification_uri"`                    // VerificationURI is a page where the user enters the code.
+	VerificationURIComplete string    `json:"verification_uri_complete,omitempty"` // VerificationURIComplete is VerificationURI with the code included, optional.
+	Expiry                  time.Time `json:"expiry,omitempty"`                    // Expiry is when the device code expires.
+	Interval                int64     `json:"interval,omitempty"`                  // Interval is a minimal polling interval in seconds.
+}
+
+// URL returns a URL to show to the user (for example, as a link or a QR code, see oauth2qr):
+// VerificationURIComplete if the provider returned it, VerificationURI otherwise.
+func (d *DeviceAuth) URL() string {
+	if d.VerificationURIComplete != "" {
+		return d.VerificationURIComplete
+	}
+	return d.VerificationURI
+}
+
+// DeviceAuth starts the device authorization flow, RFC 8628.
+func (c *Client) DeviceAuth(ctx context.Context) (*DeviceAuth, error) {
+	if c.config.DeviceURL == "" {
+		return nil, errors.New("oauth2: device URL is not set")
+	}
+
+	params := url.Values{
+		"client_id": []string{c.config.ClientID},
+	}
+	if len(c.config.Scopes) > 0 {
+		params.Set("scope", c.joinScopes(c.config.Scopes))
+	}
+
+	// public clients are the most common for the device flow.
+	mode := c.requestMode(ctx, InParamsMode)
+
+	body, err := c.postForm(ctx, c.config.DeviceURL, mode, params)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: cannot auth device: %w", err)
+	}
+	return parseDeviceAuth(body)
+}
+
+func parseDeviceAuth(body []byte) (*DeviceAuth, error) {
+	var dj struct {
+		DeviceCode              string         `json:"device_code"`
+		UserCode                string         `json:"user_code"`
+		VerificationURI         string         `json:"verification_uri"`
+		VerificationURL         string         `json:"verification_url"` // Google
+		VerificationURIComplete string         `json:"verification_uri_complete"`
+		ExpiresIn               expirationTime `json:"expires_in"`
+		Interval                expirationTime `json:"interval"`
+	}
+	if err := json.Unmarshal(body, &dj); err != nil {
+		return nil, err
+	}
+
+	d := &DeviceAuth{
+		DeviceCode:              dj.DeviceCode,
+		UserCode:                dj.UserCode,
+		VerificationURI:         dj.VerificationURI,
+		VerificationURIComplete: dj.VerificationURIComplete,
+		Interval:                int64(dj.Interval),
+	}
+	if d.VerificationURI == "" {
+		d.VerificationURI = dj.VerificationURL
+	}
+	if dj.ExpiresIn != 0 {
+		d.Expiry = time.Now().Add(time.Duration(dj.ExpiresIn) * time.Second)
+	}
+
+	if d.DeviceCode == "" {
+		return nil, errors.New("oauth2: server response missing device_code")
+	}
+	return d, nil
+}
+
+// isDevicePending reports whether the error of a device token request means to poll again, RFC 8628 section 3.5.
+func isDevicePending(params url.Values, err error) bool {
+	var rerr *RetrieveError
+	if params.Get("grant_type") != GrantTypeDeviceCode || !errors.As(err, &rerr) {
+		return false
+	}
+	return rerr.ErrorCode == "authorization_pending" || rerr.ErrorCode == "slow_down"
+}
+
+var (
+	// ErrDeviceExpired is returned when the device code has expired before the user authorized the device.
+	ErrDeviceExpired = errors.New("oauth2: device code expired")
+
+	// ErrDeviceMaxWait is returned when DevicePoll.MaxWait has passed before the user authorized the device,
+	// the device code may still be valid.
+	ErrDeviceMaxWait = errors.New("oauth2: device authorization wait exceeded")
+
+	// ErrDeviceDenied is returned when the user denied the device authorization.
+	ErrDeviceDenied = errors.New("oauth2: device authorization denied")
+)
+
+// DevicePoll controls polling in DeviceAccessToken.
+type DevicePoll struct {
+	// Interval overrides the polling interval returned by the provider.
+	// Defaults to the provider's interval or 5 seconds.
+	Interval time.Duration
+
+	// MaxWait limits the total polling time, zero means until the device code expires.
+	// DeviceAccessToken returns ErrDeviceMaxWait when it has passed.
+	MaxWait time.Duration
+
+	// OnPending is called after each `authorization_pending` or `slow_down` response,
+	// for example to update a spinner. Attempt starts from 1.
+	OnPending func(attempt int)
+
+	_ struct{} // enforce explicit field names.
+}
+
+// defaultDeviceInterval is a polling interval if the provider didn't specify one, RFC 8628 section 3.2.
+const defaultDeviceInterval = 5 * time.Second
+
+// DeviceAccessToken polls the token endpoint until the user authorizes the device, RFC 8628 section 3.4.
+func (c *Client) DeviceAccessToken(ctx context.Context, da *DeviceAuth, poll DevicePoll) (*Token, error) {
+	interval := poll.Interval
+	if interval == 0 {
+		interval = time.Duration(da.Interval) * time.Second
+	}
+	if interval == 0 {
+		interval = defaultDeviceInterval
+	}
+
+	deadline, deadlineErr := da.Expiry, ErrDeviceExpired
+	if poll.MaxWait > 0 {
+		if d := timeNow().Add(poll.MaxWait); deadline.IsZero() || d.Before(deadline) {
+			deadline, deadlineErr = d, ErrDeviceMaxWait
+		}
+	}
+
+	// public clients are the most common for the device flow.
+	ctx = ContextWithMode(ctx, c.requestMode(ctx, InParamsMode))
+
+	for attempt := 1; ; attempt++ {
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		token, err := c.retrieveToken(ctx, url.Values{
+			"grant_type":  []string{GrantTypeDeviceCode},
+			"device_code": []string{da.DeviceCode},
+			"client_id":   []string{c.config.ClientID},
+		})
+		if err == nil {
+			return token, nil
+		}
+
+		var rerr *RetrieveError
+		if !errors.As(err, &rerr) {
+			return nil, err
+		}
+
+		switch rerr.ErrorCode {
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		case "expired_token":
+			return nil, ErrDeviceExpired
+		case "access_denied":
+			return nil, ErrDeviceDenied
+		default:
+			return nil, err
+		}
+
+		if poll.OnPending != nil {
+			poll.OnPending(attempt)
+		}
+		if !deadline.IsZero() && timeNow().Add(interval).After(deadline) {
+			return nil, deadlineErr
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDeviceAuth(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		mustEqual(t, r.URL.String(), "/device")
+
+		body, err := io.ReadAll(r.Body)
+		mustOk(t, err)
+		mustEqual(t, string(body), "scope=scope1+scope2&client_id=CLIENT_ID&client_secret=CLIENT_SECRET")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{
+			"device_code": "DEVICE_CODE",
+			"user_code": "WDJB-MJHT",
+			"verification_uri": "https://example.com/device",
+			"verification_uri_complete": "https://example.com/device?user_code=WDJB-MJHT",
+			"expires_in": 1800,
+			"interval": 5
+		}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		ClientID:     "CLIENT_ID",
+		ClientSecret: "CLIENT_SECRET",
+		DeviceURL:    ts.URL + "/device",
+		Scopes:       []string{"scope1", "scope2"},
+	})
+
+	da, err := client.DeviceAuth(context.Background())
+	mustOk(t, err)
+	mustEqual(t, da.DeviceCode, "DEVICE_CODE")
+	mustEqual(t, da.UserCode, "WDJB-MJHT")
+	mustEqual(t, da.Interval, int64(5))
+	mustEqual(t, da.Expiry.IsZero(), false)
+	mustEqual(t, da.URL(), "https://example.com/device?user_code=WDJB-MJHT")
+}
+
+func TestParseDeviceAuth(t *testing.T) {
+	da, err := parseDeviceAuth([]byte(`{"device_code": "DC", "user_code": "UC", "verification_url": "https://www.google.com/device"}`))
+	mustOk(t, err)
+	mustEqual(t, da.URL(), "https://www.google.com/device")
+
+	_, err = parseDeviceAuth([]byte(`{"user_code": "UC"}`))
+	mustFail(t, err)
+}
+
+func TestDeviceAccessToken(t *testing.T) {
+	var calls int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		mustEqual(t, r.FormValue("grant_type"), "urn:ietf:params:oauth:grant-type:device_code")
+		mustEqual(t, r.FormValue("device_code"), "DEVICE_CODE")
+
+		w.Header().Set("Content-Type", "application/json")
+		if calls < 3 {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "authorization_pending"}`)
+			return
+		}
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN"}`)
+	})
+	defer ts.Close()
+
+	var pending []int
+	audit := &auditRecorder{}
+	client := newClientWithConfig(Config{ClientID: "CLIENT_ID", TokenURL: ts.URL, AuditSink: audit})
+	tok, err := client.DeviceAccessToken(context.Background(), &DeviceAuth{DeviceCode: "DEVICE_CODE"}, DevicePoll{
+		Interval: time.Millisecond,
+		OnPending: func(attempt int) {
+			pending = append(pending, attempt)
+		},
+	})
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "ACCESS_TOKEN")
+	mustEqual(t, tok.GrantType, GrantTypeDeviceCode)
+	mustEqual(t, tok.ObtainedAt.IsZero(), false)
+	mustEqual(t, pending, []int{1, 2})
+
+	// pending polls are neither failures nor audited.
+	mustEqual(t, client.Stats().Failures, uint64(0))
+	mustEqual(t, client.Stats().TokensIssued, uint64(1))
+	mustEqual(t, len(audit.events), 1)
+	mustEqual(t, audit.events[0].Err, nil)
+}
+
+func TestDeviceAccessToken_Errors(t *testing.T) {
+	testCases := []struct {
+		code string
+		want error
+	}{
+		{"expired_token", ErrDeviceExpired},
+		{"access_denied", ErrDeviceDenied},
+	}
+
+	for _, tc := range testCases {
+		ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"error": %q}`, tc.code)
+		})
+
+		client := newClient(ts.URL)
+		_, err := client.DeviceAccessToken(context.Background(), &DeviceAuth{DeviceCode: "DEVICE_CODE"}, DevicePoll{
+			Interval: time.Millisecond,
+		})
+		mustEqual(t, err, tc.want)
+		ts.Close()
+	}
+}
+
+func TestDeviceAccessToken_MaxWait(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error": "authorization_pending"}`)
+	})
+	defer ts.Close()
+
+	client := newClient(ts.URL)
+	_, err := client.DeviceAccessToken(context.Background(), &DeviceAuth{DeviceCode: "DEVICE_CODE"}, DevicePoll{
+		Interval: 10 * time.Millisecond,
+		MaxWait:  50 * time.Millisecond,
+	})
+	mustEqual(t, err, ErrDeviceMaxWait)
+
+	_, err = client.DeviceAccessToken(context.Background(), &DeviceAuth{
+		DeviceCode: "DEVICE_CODE",
+		Expiry:     time.Now().Add(50 * time.Millisecond),
+	}, DevicePoll{
+		Interval: 10 * time.Millisecond,
+		MaxWait:  time.Minute,
+	})
+	mustEqual(t, err, ErrDeviceExpired)
+}
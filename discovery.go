@@ -0,0 +1,99 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ProviderMetadata is OpenID Provider metadata, OIDC Discovery section 3 and RFC 8414.
+type ProviderMetadata struct {
+	Issuer                             string   `json:"issuer"`
+	AuthorizationEndpoint              string   `json:"authorization_endpoint"`
+	TokenEndpoint                      string   `json:"token_endpoint"`
+	UserInfoEndpoint                   string   `json:"userinfo_endpoint,omitempty"`
+	JWKSURI                            string   `json:"jwks_uri,omitempty"`
+	DeviceAuthorizationEndpoint        string   `json:"device_authorization_endpoint,omitempty"`
+	IntrospectionEndpoint              string   `json:"introspection_endpoint,omitempty"`
+	RevocationEndpoint                 string   `json:"revocation_endpoint,omitempty"`
+	PushedAuthorizationRequestEndpoint string   `json:"pushed_authorization_request_endpoint,omitempty"`
+	EndSessionEndpoint                 string   `json:"end_session_endpoint,omitempty"`
+	ScopesSupported                    []string `json:"scopes_supported,omitempty"`
+	GrantTypesSupported                []string `json:"grant_types_supported,omitempty"`
+	TokenEndpointAuthMethodsSupported  []string `json:"token_endpoint_auth_methods_supported,omitempty"`
+	CodeChallengeMethodsSupported      []string `json:"code_challenge_methods_supported,omitempty"`
+
+	AuthorizationResponseIssParameterSupported bool `json:"authorization_response_iss_parameter_supported,omitempty"`
+}
+
+// Config returns a Config with the provider endpoints.
+// The mode is InParamsMode if the provider supports `client_secret_post` but not `client_secret_basic`,
+// otherwise AutoDetectMode. Client fields must be set by the caller.
+func (m *ProviderMetadata) Config() Config {
+	config := Config{
+		Issuer:           m.Issuer,
+		AuthURL:          m.AuthorizationEndpoint,
+		PushedAuthURL:    m.PushedAuthorizationRequestEndpoint,
+		TokenURL:         m.TokenEndpoint,
+		DeviceURL:        m.DeviceAuthorizationEndpoint,
+		IntrospectionURL: m.IntrospectionEndpoint,
+		RevocationURL:    m.RevocationEndpoint,
+		UserInfoURL:      m.UserInfoEndpoint,
+
+		RequireResponseIssuer: m.AuthorizationResponseIssParameterSupported,
+	}
+	if !hasString(m.TokenEndpointAuthMethodsSupported, "client_secret_basic") &&
+		hasString(m.TokenEndpointAuthMethodsSupported, "client_secret_post") {
+		config.Mode = InParamsMode
+	}
+	return config
+}
+
+// Discover fetches metadata of the OpenID Provider, OIDC Discovery section 4.
+// The issuer in the metadata must be exactly the requested one.
+func Discover(ctx context.Context, client *http.Client, issuer string) (*ProviderMetadata, error) {
+	wellKnown := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+
+	var m ProviderMetadata
+	if err := getJSON(ctx, client, wellKnown, &m); err != nil {
+		return nil, fmt.Errorf("oauth2: cannot discover provider: %w", err)
+	}
+	if err := checkIssuer(m.Issuer, []string{issuer}); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// getJSON fetches the URL and decodes a successful JSON response into v.
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	body, err := readBody(resp)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %v: %s", resp.Status, body)
+	}
+	return json.Unmarshal(body, v)
+}
+
+func hasString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
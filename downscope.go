@@ -0,0 +1,122 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sync"
+)
+
+// TokenProvider returns valid tokens, like TokenSource, FederatedTokenSource and DownscopedTokenSource.
+type TokenProvider interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+var (
+	_ TokenProvider = &TokenSource{}
+	_ TokenProvider = &FederatedTokenSource{}
+	_ TokenProvider = &DownscopedTokenSource{}
+)
+
+// AccessBoundaryRule restricts a downscoped token to a resource, GCP Credential Access Boundaries.
+type AccessBoundaryRule struct {
+	AvailableResource    string   `json:"availableResource"`    // AvailableResource is a full resource name, like `//storage.googleapis.com/projects/_/buckets/b`.
+	AvailablePermissions []string `json:"availablePermissions"` // AvailablePermissions are allowed IAM roles, like `inRole:roles/storage.objectViewer`.
+
+	// AvailabilityCondition optionally narrows the rule down with a CEL expression.
+	AvailabilityCondition *AvailabilityCondition `json:"availabilityCondition,omitempty"`
+}
+
+// AvailabilityCondition is a CEL condition of AccessBoundaryRule.
+type AvailabilityCondition struct {
+	Expression  string `json:"expression"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// DownscopeConfig describes how DownscopedTokenSource restricts tokens.
+type DownscopeConfig struct {
+	Source   TokenProvider        // Source returns broad tokens to downscope, required.
+	Rules    []AccessBoundaryRule // Rules are sent as GCP access boundary `options`, optional.
+	Scopes   []string             // Scopes are requested narrower scopes, optional.
+	Audience string               // Audience is a target service of the token, optional.
+	Resource string               // Resource is a target URI of the token, optional.
+
+	// Params are additional parameters, like a security policy of other providers.
+	Params url.Values
+
+	_ struct{} // enforce explicit field names.
+}
+
+// DownscopedTokenSource exchanges broad tokens for restricted ones with the token exchange grant, RFC 8693,
+// to hand less-trusted components narrowly scoped credentials.
+// A downscoped token without `expires_in` expires together with the source token. It is safe for concurrent use.
+type DownscopedTokenSource struct {
+	client *Client
+	config DownscopeConfig
+	params url.Values
+
+	mu    sync.Mutex
+	token *Token
+}
+
+// NewDownscopedTokenSource instantiates a new downscoped token source with a given STS client and config.
+func NewDownscopedTokenSource(client *Client, config DownscopeConfig) (*DownscopedTokenSource, error) {
+	if config.Source == nil {
+		return nil, errors.New("oauth2: downscope source is not set")
+	}
+
+	params := cloneURLValues(config.Params)
+	if len(config.Rules) > 0 {
+		options, err := json.Marshal(map[string]interface{}{
+			"accessBoundary": map[string]interface{}{
+				"accessBoundaryRules": config.Rules,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		params.Set("options", string(options))
+	}
+
+	ts := &DownscopedTokenSource{
+		client: client,
+		config: config,
+		params: params,
+	}
+	return ts, nil
+}
+
+// Token returns a valid downscoped token, exchanging a source token if needed.
+func (ts *DownscopedTokenSource) Token(ctx context.Context) (*Token, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.token.validAt(timeNow()) {
+		return ts.token, nil
+	}
+
+	source, err := ts.config.Source.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := ts.client.ExchangeToken(ctx, TokenExchange{
+		SubjectToken:       source.AccessToken,
+		SubjectTokenType:   TokenTypeAccessToken,
+		RequestedTokenType: TokenTypeAccessToken,
+		Audience:           ts.config.Audience,
+		Resource:           ts.config.Resource,
+		Scopes:             ts.config.Scopes,
+		Params:             ts.params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if token.Expiry.IsZero() {
+		token.Expiry = source.Expiry
+	}
+	ts.token = token
+	return token, nil
+}
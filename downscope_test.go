@@ -0,0 +1,53 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDownscopedTokenSource(t *testing.T) {
+	var calls int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		r.ParseForm()
+		mustEqual(t, r.PostForm.Get("grant_type"), "urn:ietf:params:oauth:grant-type:token-exchange")
+		mustEqual(t, r.PostForm.Get("subject_token"), "BROAD_TOKEN")
+		mustEqual(t, r.PostForm.Get("subject_token_type"), TokenTypeAccessToken)
+		mustEqual(t, r.PostForm.Get("requested_token_type"), TokenTypeAccessToken)
+		mustEqual(t, r.PostForm.Get("options"), `{"accessBoundary":{"accessBoundaryRules":[{"availableResource":"//storage.googleapis.com/projects/_/buckets/reports","availablePermissions":["inRole:roles/storage.objectViewer"],"availabilityCondition":{"expression":"resource.name.startsWith('projects/_/buckets/reports/objects/2024')"}}]}}`)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "NARROW_TOKEN", "issued_token_type": "urn:ietf:params:oauth:token-type:access_token", "token_type": "Bearer"}`)
+	})
+	defer ts.Close()
+
+	sourceExpiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	source := NewTokenSource(newClient(ts.URL), &Token{AccessToken: "BROAD_TOKEN", Expiry: sourceExpiry}, TokenSourceConfig{})
+
+	sts := newClientWithConfig(Config{TokenURL: ts.URL + "/token", Mode: InParamsMode})
+	downscoped, err := NewDownscopedTokenSource(sts, DownscopeConfig{
+		Source: source,
+		Rules: []AccessBoundaryRule{{
+			AvailableResource:    "//storage.googleapis.com/projects/_/buckets/reports",
+			AvailablePermissions: []string{"inRole:roles/storage.objectViewer"},
+			AvailabilityCondition: &AvailabilityCondition{
+				Expression: "resource.name.startsWith('projects/_/buckets/reports/objects/2024')",
+			},
+		}},
+	})
+	mustOk(t, err)
+
+	for i := 0; i < 2; i++ {
+		token, err := downscoped.Token(context.Background())
+		mustOk(t, err)
+		mustEqual(t, token.AccessToken, "NARROW_TOKEN")
+		mustEqual(t, token.Expiry, sourceExpiry)
+	}
+	mustEqual(t, calls, 1)
+
+	_, err = NewDownscopedTokenSource(sts, DownscopeConfig{})
+	mustFail(t, err)
+}
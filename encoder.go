@@ -0,0 +1,56 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+)
+
+// BodyEncoder encodes parameters of requests to the token endpoint and other endpoints of the provider,
+// for providers requiring JSON, multipart or signed bodies instead of a form, see Config.BodyEncoder.
+// The parameters include client credentials of InParamsMode and PrivateKeyJWTMode.
+type BodyEncoder interface {
+	EncodeBody(ctx context.Context, params url.Values) (body []byte, contentType string, err error)
+}
+
+// BodyEncoderFunc is an adapter to use a function as BodyEncoder.
+type BodyEncoderFunc func(ctx context.Context, params url.Values) ([]byte, string, error)
+
+// EncodeBody implements the BodyEncoder interface.
+func (f BodyEncoderFunc) EncodeBody(ctx context.Context, params url.Values) ([]byte, string, error) {
+	return f(ctx, params)
+}
+
+// JSONBodyEncoder encodes parameters as a JSON object, parameters with several values become arrays.
+var JSONBodyEncoder BodyEncoder = BodyEncoderFunc(encodeJSONBody)
+
+func encodeJSONBody(ctx context.Context, params url.Values) ([]byte, string, error) {
+	obj := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		if len(v) == 1 {
+			obj[k] = v[0]
+		} else {
+			obj[k] = v
+		}
+	}
+
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, "application/json", nil
+}
+
+// withEncodedParams returns params with parameters of an encoded form set.
+func withEncodedParams(params url.Values, encoded string) url.Values {
+	if encoded == "" {
+		return params
+	}
+
+	res := cloneURLValues(params)
+	extra, _ := url.ParseQuery(encoded) // encoded by the client.
+	for k, v := range extra {
+		res[k] = v
+	}
+	return res
+}
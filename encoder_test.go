@@ -0,0 +1,52 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestBodyEncoder(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		mustEqual(t, r.Header.Get("Content-Type"), "application/json")
+
+		body, err := io.ReadAll(r.Body)
+		mustOk(t, err)
+		mustEqual(t, string(body), `{"client_id":"CLIENT_ID","client_secret":"CLIENT_SECRET","grant_type":"client_credentials","scope":"read"}`)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ProperToken"}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		ClientID:     "CLIENT_ID",
+		ClientSecret: "CLIENT_SECRET",
+		TokenURL:     ts.URL,
+		Mode:         InParamsMode,
+		Scopes:       []string{"read"},
+		BodyEncoder:  JSONBodyEncoder,
+	})
+
+	tok, err := client.ClientCredentialsToken(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "ProperToken")
+}
+
+func TestBodyEncoder_Error(t *testing.T) {
+	errEncode := errors.New("cannot sign")
+	client := newClientWithConfig(Config{
+		TokenURL: "https://example.com/token",
+		Mode:     InHeaderMode,
+		BodyEncoder: BodyEncoderFunc(func(ctx context.Context, params url.Values) ([]byte, string, error) {
+			return nil, "", errEncode
+		}),
+	})
+
+	_, err := client.ClientCredentialsToken(context.Background())
+	mustEqual(t, errors.Is(err, errEncode), true)
+}
@@ -0,0 +1,64 @@
+package oauth2
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Validate checks the endpoints of the config. Endpoints must be https,
+// except loopback hosts and configs with AllowInsecureEndpoints.
+//
+// NewClient doesn't validate the config, requests to invalid endpoints fail instead.
+// Call Validate at startup to catch misconfiguration early.
+func (c Config) Validate() error {
+	endpoints := []struct {
+		name, url string
+	}{
+		{"auth URL", c.AuthURL},
+		{"pushed auth URL", c.PushedAuthURL},
+		{"token URL", c.TokenURL},
+		{"device URL", c.DeviceURL},
+		{"introspection URL", c.IntrospectionURL},
+		{"revocation URL", c.RevocationURL},
+		{"user info URL", c.UserInfoURL},
+	}
+
+	for _, e := range endpoints {
+		if e.url == "" {
+			continue
+		}
+		if err := c.checkEndpoint(e.name, e.url); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkEndpoint reports an endpoint which isn't https, unless it's allowed.
+func (c Config) checkEndpoint(name, endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("oauth2: malformed %s: %w", name, err)
+	}
+
+	switch {
+	case strings.EqualFold(u.Scheme, "https"):
+		return nil
+	case c.AllowInsecureEndpoints:
+		return nil
+	case strings.EqualFold(u.Scheme, "http") && isLoopback(u.Hostname()):
+		return nil
+	default:
+		return fmt.Errorf("oauth2: %s must be https: %s", name, endpoint)
+	}
+}
+
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
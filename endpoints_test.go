@@ -0,0 +1,47 @@
+package oauth2
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	valid := []Config{
+		{},
+		{AuthURL: "https://idp.example.com/auth", TokenURL: "https://idp.example.com/token"},
+		{TokenURL: "http://localhost:8080/token"},
+		{TokenURL: "http://127.0.0.1:8080/token"},
+		{TokenURL: "http://[::1]:8080/token"},
+		{TokenURL: "http://idp.internal/token", AllowInsecureEndpoints: true},
+	}
+	for _, cfg := range valid {
+		mustOk(t, cfg.Validate())
+	}
+
+	invalid := []Config{
+		{AuthURL: "http://idp.example.com/auth"},
+		{TokenURL: "http://idp.example.com/token"},
+		{UserInfoURL: "http://idp.example.com/userinfo"},
+		{TokenURL: "ftp://localhost/token"},
+		{TokenURL: "https://exa mple.com/token"},
+	}
+	for _, cfg := range invalid {
+		mustFail(t, cfg.Validate())
+	}
+
+	err := Config{TokenURL: "http://idp.example.com/token"}.Validate()
+	mustEqual(t, err.Error(), "oauth2: token URL must be https: http://idp.example.com/token")
+}
+
+func TestInsecureEndpointRejected(t *testing.T) {
+	client := newClientWithConfig(Config{
+		AuthURL:  "http://idp.example.com/auth",
+		TokenURL: "http://idp.example.com/token",
+	})
+
+	_, err := client.ClientCredentialsToken(context.Background())
+	mustFail(t, err)
+
+	_, err = client.BuildAuthCodeURL("state", nil)
+	mustFail(t, err)
+}
@@ -0,0 +1,157 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RetrieveError is returned when the token endpoint responds with a non-2xx status.
+type RetrieveError struct {
+	StatusCode  int         // StatusCode is the HTTP status code of the response.
+	Header      http.Header // Header contains Retry-After, WWW-Authenticate and request ID response headers.
+	Body        []byte      // Body is the response body, up to Config.MaxErrorBodySize.
+	ContentType string      // ContentType is the media type of the response, like `text/html`.
+	RequestID   string      // RequestID is the provider's request ID from the response headers, if any.
+
+	// Error fields from RFC 6749 section 5.2, parsed from a JSON or form-encoded body.
+	ErrorCode        string // ErrorCode is `error`, like `invalid_grant`.
+	ErrorDescription string // ErrorDescription is `error_description`.
+	ErrorURI         string // ErrorURI is `error_uri`.
+
+	// Category is a normalized category of the error, like CategoryReauthRequired,
+	// found in the catalog of standard and provider-specific error codes, see Config.ErrorCategories.
+	Category ErrorCategory
+}
+
+func (e *RetrieveError) Error() string {
+	body := e.Body
+	if len(body) > errorSnippetSize {
+		body = append(body[:errorSnippetSize:errorSnippetSize], "..."...)
+	}
+
+	// an error page of a proxy or a CDN rather than a response of the provider.
+	label := "Response"
+	if !isOAuthErrorType(e.ContentType) {
+		label += " (" + e.ContentType + ")"
+	}
+
+	msg := fmt.Sprintf("oauth2: cannot fetch token: %v %v\n%s: %s",
+		e.StatusCode, http.StatusText(e.StatusCode), label, string(body))
+
+	if e.RequestID != "" {
+		msg += "\nRequest ID: " + e.RequestID
+	}
+	return msg
+}
+
+// ScopeDowngradeError is returned when Config.RejectScopeDowngrade is set
+// and the provider granted fewer scopes than requested.
+type ScopeDowngradeError struct {
+	Token   *Token   // Token is the retrieved token with fewer scopes.
+	Missing []string // Missing are requested scopes which were not granted.
+}
+
+func (e *ScopeDowngradeError) Error() string {
+	return fmt.Sprintf("oauth2: scopes were not granted: %s", strings.Join(e.Missing, " "))
+}
+
+// errorHeaders are response headers kept in RetrieveError.
+var errorHeaders = []string{
+	"Retry-After",
+	"Www-Authenticate",
+	"X-Request-Id",
+}
+
+const (
+	defaultMaxErrorBodySize = 4 << 10 // defaultMaxErrorBodySize is a default of Config.MaxErrorBodySize.
+	errorSnippetSize        = 512     // errorSnippetSize is how much of the body RetrieveError.Error shows.
+)
+
+// isOAuthErrorType reports whether the content type is used for OAuth error responses.
+func isOAuthErrorType(contentType string) bool {
+	switch contentType {
+	case "", "application/json", "text/plain", "application/x-www-form-urlencoded":
+		return true
+	default:
+		return strings.HasSuffix(contentType, "+json")
+	}
+}
+
+func (c *Client) newRetrieveError(resp *http.Response, body []byte) *RetrieveError {
+	maxBody := c.config.MaxErrorBodySize
+	if maxBody <= 0 {
+		maxBody = defaultMaxErrorBodySize
+	}
+	if len(body) > maxBody {
+		body = body[:maxBody:maxBody]
+	}
+	requestIDHeader := c.config.CorrelationHeader
+
+	e := &RetrieveError{
+		StatusCode:  resp.StatusCode,
+		Header:      http.Header{},
+		Body:        body,
+		ContentType: responseContentType(resp),
+		RequestID:   responseRequestID(resp, requestIDHeader),
+	}
+
+	for _, h := range errorHeaders {
+		if v := resp.Header.Values(h); len(v) > 0 {
+			e.Header[h] = v
+		}
+	}
+	if h := requestIDHeader; h != "" {
+		if v := resp.Header.Values(h); len(v) > 0 {
+			e.Header[http.CanonicalHeaderKey(h)] = v
+		}
+	}
+
+	switch e.ContentType {
+	case "text/plain", "application/x-www-form-urlencoded":
+		vals, err := url.ParseQuery(string(body))
+		if err == nil {
+			e.ErrorCode = vals.Get("error")
+			e.ErrorDescription = vals.Get("error_description")
+			e.ErrorURI = vals.Get("error_uri")
+		}
+	default:
+		var ej struct {
+			Code        string `json:"error"`
+			Description string `json:"error_description"`
+			URI         string `json:"error_uri"`
+		}
+		if json.Unmarshal(body, &ej) == nil {
+			e.ErrorCode = ej.Code
+			e.ErrorDescription = ej.Description
+			e.ErrorURI = ej.URI
+		}
+	}
+	e.Category = c.errorCategory(e)
+	return e
+}
+
+// requestIDHeaders are response headers commonly used by providers for request IDs.
+var requestIDHeaders = []string{
+	"X-Request-Id",
+	"X-Correlation-Id",
+	"Request-Id",
+	"X-Ms-Request-Id",
+}
+
+// responseRequestID returns the first found request ID header, extra header is checked first.
+func responseRequestID(resp *http.Response, extra string) string {
+	if extra != "" {
+		if id := resp.Header.Get(extra); id != "" {
+			return id
+		}
+	}
+	for _, h := range requestIDHeaders {
+		if id := resp.Header.Get(h); id != "" {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package oauth2
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRetrieveError(t *testing.T) {
+	err := &RetrieveError{
+		StatusCode: http.StatusBadRequest,
+		Body:       []byte(`{"error": "invalid_grant"}`),
+		RequestID:  "req-123",
+	}
+	mustEqual(t, err.Error(), "oauth2: cannot fetch token: 400 Bad Request\nResponse: {\"error\": \"invalid_grant\"}\nRequest ID: req-123")
+}
+
+func TestResponseRequestID(t *testing.T) {
+	testCases := []struct {
+		header http.Header
+		extra  string
+		want   string
+	}{
+		{http.Header{}, "", ""},
+		{http.Header{"X-Request-Id": {"abc"}}, "", "abc"},
+		{http.Header{"X-Ms-Request-Id": {"abc"}}, "", "abc"},
+		{http.Header{"X-Request-Id": {"abc"}, "X-Trace": {"def"}}, "X-Trace", "def"},
+	}
+
+	for _, tc := range testCases {
+		resp := &http.Response{Header: tc.header}
+		mustEqual(t, responseRequestID(resp, tc.extra), tc.want)
+	}
+}
+
+func TestNewRetrieveError(t *testing.T) {
+	testCases := []struct {
+		contentType string
+		body        string
+	}{
+		{"application/json", `{"error": "invalid_grant", "error_description": "expired", "error_uri": "https://docs"}`},
+		{"application/x-www-form-urlencoded", `error=invalid_grant&error_description=expired&error_uri=https%3A%2F%2Fdocs`},
+	}
+
+	for _, tc := range testCases {
+		resp := &http.Response{
+			StatusCode: http.StatusBadRequest,
+			Header: http.Header{
+				"Content-Type":     {tc.contentType},
+				"Retry-After":      {"30"},
+				"Www-Authenticate": {`Bearer error="invalid_token"`},
+				"Set-Cookie":       {"secret"},
+			},
+		}
+
+		err := newClient("").newRetrieveError(resp, []byte(tc.body))
+		mustEqual(t, err.StatusCode, http.StatusBadRequest)
+		mustEqual(t, err.ErrorCode, "invalid_grant")
+		mustEqual(t, err.ErrorDescription, "expired")
+		mustEqual(t, err.ErrorURI, "https://docs")
+		mustEqual(t, err.Header, http.Header{
+			"Retry-After":      {"30"},
+			"Www-Authenticate": {`Bearer error="invalid_token"`},
+		})
+	}
+}
+
+func TestNewRetrieveError_HTML(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusBadGateway,
+		Header:     http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+	}
+	page := "<html><body>" + strings.Repeat("<p>upstream is down</p>", 1000) + "</body></html>"
+
+	err := newClientWithConfig(Config{MaxErrorBodySize: 1000}).newRetrieveError(resp, []byte(page))
+	mustEqual(t, err.ContentType, "text/html")
+	mustEqual(t, len(err.Body), 1000)
+	mustEqual(t, err.ErrorCode, "")
+
+	msg := err.Error()
+	mustEqual(t, strings.HasPrefix(msg, "oauth2: cannot fetch token: 502 Bad Gateway\nResponse (text/html): <html><body><p>upstream"), true)
+	mustEqual(t, strings.HasSuffix(msg, "..."), true)
+	mustEqual(t, len(msg) < 700, true)
+
+	err = newClient("").newRetrieveError(resp, []byte(page))
+	mustEqual(t, len(err.Body), 4096)
+}
@@ -0,0 +1,124 @@
+package oauth2
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenEventType is a type of TokenEvent.
+type TokenEventType int
+
+const (
+	// TokenObtained is a token issued by any grant except refresh.
+	TokenObtained TokenEventType = 1
+
+	// TokenRefreshed is a token issued by the refresh token grant.
+	TokenRefreshed TokenEventType = 2
+
+	// TokenRefreshFailed is a failed refresh, Err is set.
+	TokenRefreshFailed TokenEventType = 3
+
+	// TokenRevoked is a token revoked with RevokeToken or RevokeAll.
+	TokenRevoked TokenEventType = 4
+)
+
+func (t TokenEventType) String() string {
+	switch t {
+	case TokenObtained:
+		return "obtained"
+	case TokenRefreshed:
+		return "refreshed"
+	case TokenRefreshFailed:
+		return "refresh_failed"
+	case TokenRevoked:
+		return "revoked"
+	default:
+		return "unknown"
+	}
+}
+
+// TokenEvent describes a change of a token lifecycle, see Client.Subscribe. It never contains tokens.
+type TokenEvent struct {
+	Type      TokenEventType // Type is the kind of the event.
+	Time      time.Time      // Time is when the event happened.
+	GrantType string         // GrantType is a grant type of the token request, like `refresh_token`.
+	Key       string         // Key is TokenSourceConfig.Key for events of TokenSource.
+	Hint      string         // Hint is `token_type_hint` of TokenRevoked events.
+	Err       error          // Err is the error of TokenRefreshFailed events.
+}
+
+// subscribers are callbacks of token events.
+type subscribers struct {
+	mu     sync.Mutex
+	nextID int
+	fns    map[int]func(TokenEvent)
+}
+
+// Subscribe registers a callback of token events, like to show a "reconnect your account" banner
+// when a refresh fails. Callbacks are called synchronously, they should not block for long;
+// send events to a buffered channel to handle them asynchronously.
+// The returned function unregisters the callback.
+func (c *Client) Subscribe(fn func(event TokenEvent)) (unsubscribe func()) {
+	s := &c.subscribers
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fns == nil {
+		s.fns = map[int]func(TokenEvent){}
+	}
+	id := s.nextID
+	s.nextID++
+	s.fns[id] = fn
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.fns, id)
+	}
+}
+
+// emit sends the event to all subscribers, the key of a TokenSource is taken from the context.
+func (c *Client) emit(ctx context.Context, event TokenEvent) {
+	s := &c.subscribers
+
+	s.mu.Lock()
+	fns := make([]func(TokenEvent), 0, len(s.fns))
+	for _, fn := range s.fns {
+		fns = append(fns, fn)
+	}
+	s.mu.Unlock()
+
+	if len(fns) == 0 {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if key, ok := ctx.Value(eventKeyKey{}).(string); ok && event.Key == "" {
+		event.Key = key
+	}
+	for _, fn := range fns {
+		fn(event)
+	}
+}
+
+// eventKeyKey is a context key of TokenSourceConfig.Key for token events.
+type eventKeyKey struct{}
+
+// emitTokenRequest emits the event of a token request.
+func (c *Client) emitTokenRequest(ctx context.Context, grantType string, err error) {
+	event := TokenEvent{GrantType: grantType, Err: err}
+	switch {
+	case grantType == GrantTypeRefreshToken && err != nil:
+		event.Type = TokenRefreshFailed
+	case grantType == GrantTypeRefreshToken:
+		event.Type = TokenRefreshed
+	case err == nil:
+		event.Type = TokenObtained
+	default:
+		return
+	}
+	c.emit(ctx, event)
+}
@@ -0,0 +1,70 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.URL.Path == "/revoke":
+		case r.PostForm.Get("refresh_token") == "BAD_REFRESH_TOKEN":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "invalid_grant"}`)
+		default:
+			fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN", "expires_in": 3600}`)
+		}
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		ClientID:      "CLIENT_ID",
+		TokenURL:      ts.URL + "/token",
+		RevocationURL: ts.URL + "/revoke",
+		Mode:          InHeaderMode,
+	})
+
+	var events []TokenEvent
+	unsubscribe := client.Subscribe(func(event TokenEvent) {
+		events = append(events, event)
+	})
+	ctx := context.Background()
+
+	_, err := client.ClientCredentialsToken(ctx)
+	mustOk(t, err)
+
+	expired := time.Now().Add(-time.Hour)
+	src := NewTokenSource(client, &Token{RefreshToken: "REFRESH_TOKEN", Expiry: expired}, TokenSourceConfig{Key: "user-1"})
+	_, err = src.Token(ctx)
+	mustOk(t, err)
+
+	bad := NewTokenSource(client, &Token{RefreshToken: "BAD_REFRESH_TOKEN", Expiry: expired}, TokenSourceConfig{Key: "user-2"})
+	_, err = bad.Token(ctx)
+	mustFail(t, err)
+
+	mustOk(t, client.RevokeToken(ctx, "REFRESH_TOKEN", "refresh_token"))
+
+	mustEqual(t, len(events), 4)
+	mustEqual(t, events[0].Type, TokenObtained)
+	mustEqual(t, events[0].GrantType, GrantTypeClientCredentials)
+	mustEqual(t, events[1].Type, TokenRefreshed)
+	mustEqual(t, events[1].Key, "user-1")
+	mustEqual(t, events[2].Type, TokenRefreshFailed)
+	mustEqual(t, events[2].Key, "user-2")
+	mustFail(t, events[2].Err)
+	mustEqual(t, events[3].Type, TokenRevoked)
+	mustEqual(t, events[3].Hint, "refresh_token")
+	mustEqual(t, events[3].Time.IsZero(), false)
+
+	unsubscribe()
+	_, err = client.ClientCredentialsToken(ctx)
+	mustOk(t, err)
+	mustEqual(t, len(events), 4)
+}
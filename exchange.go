@@ -0,0 +1,90 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"net/url"
+)
+
+// Token types of RFC 8693 section 3.
+const (
+	TokenTypeAccessToken  = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeRefreshToken = "urn:ietf:params:oauth:token-type:refresh_token"
+	TokenTypeIDToken      = "urn:ietf:params:oauth:token-type:id_token"
+	TokenTypeJWT          = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// TokenExchange is a token exchange request, RFC 8693 section 2.1.
+type TokenExchange struct {
+	SubjectToken       string   // SubjectToken represents the identity of the party on behalf of whom the request is made, required.
+	SubjectTokenType   string   // SubjectTokenType is a type of SubjectToken, required.
+	ActorToken         string   // ActorToken represents the identity of the acting party, optional.
+	ActorTokenType     string   // ActorTokenType is a type of ActorToken, required with ActorToken.
+	RequestedTokenType string   // RequestedTokenType is a type of the requested token, optional.
+	Audience           string   // Audience is a target service of the token, optional.
+	Resource           string   // Resource is a target URI of the token, optional.
+	Scopes             []string // Scopes are requested scopes, optional.
+
+	// Params are additional parameters of the request, like GCP `options` with an access boundary.
+	Params url.Values
+
+	_ struct{} // enforce explicit field names.
+}
+
+// ExchangeToken exchanges a token for another one with the token exchange grant, RFC 8693.
+// The type of the issued token is available as Token.Extra("issued_token_type").
+//
+// With an actor token the issued token is delegated: the actor acts for the subject,
+// which is recorded in the `act` claim of the token, see Actor.
+func (c *Client) ExchangeToken(ctx context.Context, te TokenExchange) (*Token, error) {
+	switch {
+	case te.SubjectToken == "":
+		return nil, errors.New("oauth2: subject token is not set")
+	case te.SubjectTokenType == "":
+		return nil, errors.New("oauth2: subject token type is not set")
+	case te.ActorToken != "" && te.ActorTokenType == "":
+		return nil, errors.New("oauth2: actor token type is not set")
+	}
+
+	params := cloneURLValues(te.Params)
+	params.Set("grant_type", GrantTypeTokenExchange)
+	params.Set("subject_token", te.SubjectToken)
+	params.Set("subject_token_type", te.SubjectTokenType)
+	if te.ActorToken != "" {
+		params.Set("actor_token", te.ActorToken)
+		params.Set("actor_token_type", te.ActorTokenType)
+	}
+	if te.RequestedTokenType != "" {
+		params.Set("requested_token_type", te.RequestedTokenType)
+	}
+	if te.Audience != "" {
+		params.Set("audience", te.Audience)
+	}
+	if te.Resource != "" {
+		params.Set("resource", te.Resource)
+	}
+	if len(te.Scopes) > 0 {
+		params.Set("scope", c.joinScopes(te.Scopes))
+	}
+	return c.retrieveToken(ctx, params)
+}
+
+// Actor is an `act` claim, RFC 8693 section 4.1. It identifies the party acting on behalf of the subject
+// of a token obtained with an actor token, prior actors of a delegation chain are nested in Actor.
+//
+// The claim is available in Introspection.Actor, or can be decoded from JWT claims of a verified token.
+type Actor struct {
+	Subject  string `json:"sub"`                 // Subject identifies the acting party.
+	Issuer   string `json:"iss,omitempty"`       // Issuer is an optional issuer of the subject.
+	ClientID string `json:"client_id,omitempty"` // ClientID is an optional client of the acting party.
+	Actor    *Actor `json:"act,omitempty"`       // Actor is the previous actor of the chain, if any.
+}
+
+// Chain returns the delegation chain starting with the current actor, the last one acted first.
+func (a *Actor) Chain() []*Actor {
+	var chain []*Actor
+	for ; a != nil; a = a.Actor {
+		chain = append(chain, a)
+	}
+	return chain
+}
@@ -0,0 +1,86 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestExchangeToken(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		mustEqual(t, r.FormValue("grant_type"), "urn:ietf:params:oauth:grant-type:token-exchange")
+		mustEqual(t, r.FormValue("subject_token"), "SUBJECT_TOKEN")
+		mustEqual(t, r.FormValue("subject_token_type"), TokenTypeJWT)
+		mustEqual(t, r.FormValue("actor_token"), "ACTOR_TOKEN")
+		mustEqual(t, r.FormValue("actor_token_type"), TokenTypeAccessToken)
+		mustEqual(t, r.FormValue("requested_token_type"), TokenTypeAccessToken)
+		mustEqual(t, r.FormValue("audience"), "api-1")
+		mustEqual(t, r.FormValue("scope"), "read write")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN", "issued_token_type": "urn:ietf:params:oauth:token-type:access_token", "token_type": "Bearer"}`)
+	})
+	defer ts.Close()
+
+	client := newClient(ts.URL)
+	tok, err := client.ExchangeToken(context.Background(), TokenExchange{
+		SubjectToken:       "SUBJECT_TOKEN",
+		SubjectTokenType:   TokenTypeJWT,
+		ActorToken:         "ACTOR_TOKEN",
+		ActorTokenType:     TokenTypeAccessToken,
+		RequestedTokenType: TokenTypeAccessToken,
+		Audience:           "api-1",
+		Scopes:             []string{"read", "write"},
+	})
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "ACCESS_TOKEN")
+	mustEqual(t, tok.Extra("issued_token_type"), TokenTypeAccessToken)
+}
+
+func TestExchangeToken_Invalid(t *testing.T) {
+	client := newClient("http://localhost")
+
+	testCases := []TokenExchange{
+		{SubjectTokenType: TokenTypeJWT},
+		{SubjectToken: "SUBJECT_TOKEN"},
+		{SubjectToken: "SUBJECT_TOKEN", SubjectTokenType: TokenTypeJWT, ActorToken: "ACTOR_TOKEN"},
+	}
+
+	for _, te := range testCases {
+		_, err := client.ExchangeToken(context.Background(), te)
+		mustFail(t, err)
+	}
+}
+
+func TestActorChain(t *testing.T) {
+	in, err := parseIntrospection([]byte(`{
+		"active": true,
+		"sub": "user@example.com",
+		"act": {
+			"sub": "https://service-b.example.com",
+			"act": {"sub": "https://service-a.example.com", "client_id": "service-a"}
+		}
+	}`))
+	mustOk(t, err)
+
+	chain := in.Actor.Chain()
+	mustEqual(t, len(chain), 2)
+	mustEqual(t, chain[0].Subject, "https://service-b.example.com")
+	mustEqual(t, chain[1].Subject, "https://service-a.example.com")
+	mustEqual(t, chain[1].ClientID, "service-a")
+
+	var claims struct {
+		Subject string `json:"sub"`
+		Actor   *Actor `json:"act"`
+	}
+	idToken := makeJWT(t, jwtHeader{Algorithm: "RS256"}, map[string]any{
+		"sub": "user@example.com",
+		"act": map[string]any{"sub": "service-a"},
+	})
+	mustOk(t, IDTokenClaims(idToken, &claims))
+	mustEqual(t, claims.Actor.Chain(), []*Actor{{Subject: "service-a"}})
+
+	var none *Actor
+	mustEqual(t, len(none.Chain()), 0)
+}
@@ -0,0 +1,87 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ExpiresInCompat is a set of accepted non-standard forms of `expires_in` in token responses,
+// RFC 6749 section 5.1 defines it as a number of seconds. See Config.ExpiresInCompat.
+type ExpiresInCompat uint
+
+const (
+	// ExpiresInString accepts numeric strings like "3600", returned at least by PayPal.
+	ExpiresInString ExpiresInCompat = 1 << iota
+
+	// ExpiresInFraction accepts fractional numbers like 3600.5, the fraction is dropped.
+	ExpiresInFraction
+
+	// ExpiresAlias takes `expires` when `expires_in` is missing, returned by older Facebook APIs.
+	ExpiresAlias
+
+	// ExpiresInIgnoreMalformed ignores malformed values instead of failing the token request,
+	// the token has no expiry then, see Config.AssumeExpiryIfMissing.
+	ExpiresInIgnoreMalformed
+)
+
+// DefaultExpiresInCompat is used when Config.ExpiresInCompat is zero.
+const DefaultExpiresInCompat = ExpiresInString
+
+// expiresInCompat returns the compatibility set of the client, zero means strict parsing.
+func (c *Client) expiresInCompat() ExpiresInCompat {
+	switch {
+	case c.config.StrictExpiresIn:
+		return 0
+	case c.config.ExpiresInCompat == 0:
+		return DefaultExpiresInCompat
+	default:
+		return c.config.ExpiresInCompat
+	}
+}
+
+// parseJSON parses a JSON `expires_in` value, zero means no expiry.
+func (compat ExpiresInCompat) parseJSON(name string, raw json.RawMessage) (time.Duration, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return 0, nil
+	}
+
+	s := string(raw)
+	if raw[0] == '"' {
+		if compat&ExpiresInString == 0 {
+			return compat.malformed(name, raw)
+		}
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return compat.malformed(name, raw)
+		}
+	}
+	return compat.parse(name, s)
+}
+
+// parse parses a numeric `expires_in` value, zero means no expiry.
+func (compat ExpiresInCompat) parse(name, s string) (time.Duration, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil && compat&ExpiresInFraction != 0 {
+		var f float64
+		if f, err = strconv.ParseFloat(s, 64); err == nil {
+			n = int64(math.Min(math.Max(f, math.MinInt64), math.MaxInt64))
+		}
+	}
+	if err != nil {
+		return compat.malformed(name, s)
+	}
+
+	if n > math.MaxInt32 {
+		n = math.MaxInt32
+	}
+	return time.Duration(n) * time.Second, nil
+}
+
+func (compat ExpiresInCompat) malformed(name string, v interface{}) (time.Duration, error) {
+	if compat&ExpiresInIgnoreMalformed != 0 {
+		return 0, nil
+	}
+	return 0, fmt.Errorf("oauth2: malformed %s: %s", name, v)
+}
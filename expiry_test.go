@@ -0,0 +1,72 @@
+package oauth2
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestExpiresInCompat(t *testing.T) {
+	testCases := []struct {
+		compat      ExpiresInCompat
+		strict      bool
+		contentType string
+		body        string
+		want        time.Duration
+		wantErr     bool
+	}{
+		{0, false, "application/json", `{"access_token": "T", "expires_in": 3600}`, time.Hour, false},
+		{0, false, "application/json", `{"access_token": "T", "expires_in": "3600"}`, time.Hour, false},
+		{0, false, "application/json", `{"access_token": "T", "expires_in": 3600.5}`, 0, true},
+		{0, false, "application/json", `{"access_token": "T", "expires": 3600}`, 0, false},
+		{0, false, "text/plain", `access_token=T&expires_in=zzz`, 0, false},
+
+		{ExpiresInFraction, false, "application/json", `{"access_token": "T", "expires_in": 3600.5}`, time.Hour, false},
+		{ExpiresInFraction, false, "application/json", `{"access_token": "T", "expires_in": "3600"}`, 0, true},
+		{ExpiresInString | ExpiresInFraction, false, "application/json", `{"access_token": "T", "expires_in": "3600.5"}`, time.Hour, false},
+		{ExpiresAlias, false, "application/json", `{"access_token": "T", "expires": 3600}`, time.Hour, false},
+		{ExpiresAlias, false, "text/plain", `access_token=T&expires=3600`, time.Hour, false},
+		{ExpiresInIgnoreMalformed, false, "application/json", `{"access_token": "T", "expires_in": "zzz"}`, 0, false},
+		{ExpiresInIgnoreMalformed, false, "application/json", `{"access_token": "T", "expires_in": {}}`, 0, false},
+
+		{0, true, "application/json", `{"access_token": "T", "expires_in": 3600}`, time.Hour, false},
+		{0, true, "application/json", `{"access_token": "T", "expires_in": "3600"}`, 0, true},
+		{ExpiresInIgnoreMalformed, true, "application/json", `{"access_token": "T", "expires_in": "zzz"}`, 0, true},
+		{0, true, "text/plain", `access_token=T&expires_in=3600`, time.Hour, false},
+		{0, true, "text/plain", `access_token=T&expires_in=zzz`, 0, true},
+		{0, true, "application/json", `{"access_token": "T", "refresh_expires_in": 1.5}`, 0, true},
+	}
+
+	for _, tc := range testCases {
+		ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", tc.contentType)
+			w.Write([]byte(tc.body))
+		})
+
+		client := newClientWithConfig(Config{
+			TokenURL:        ts.URL,
+			Mode:            InHeaderMode,
+			ExpiresInCompat: tc.compat,
+			StrictExpiresIn: tc.strict,
+		})
+		tok, err := client.Exchange(context.Background(), "code")
+		ts.Close()
+
+		if tc.wantErr {
+			if err == nil {
+				t.Fatalf("want error for %s", tc.body)
+			}
+			continue
+		}
+		mustOk(t, err)
+
+		if tc.want == 0 {
+			mustEqual(t, tok.Expiry.IsZero(), true)
+			continue
+		}
+		if d := time.Until(tok.Expiry); d > tc.want || d < tc.want-time.Minute {
+			t.Fatalf("unexpected expiry for %s: %v", tc.body, d)
+		}
+	}
+}
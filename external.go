@@ -0,0 +1,165 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ExternalAccount is an "external account" credential configuration: a source of a subject token
+// and an STS token URL to exchange it at. The format is used by GCP workload identity federation.
+type ExternalAccount struct {
+	Type             string                `json:"type"`                    // Type must be `external_account`.
+	Audience         string                `json:"audience"`                // Audience is a target of the token exchange.
+	SubjectTokenType string                `json:"subject_token_type"`      // SubjectTokenType is a type of the subject token.
+	TokenURL         string                `json:"token_url"`               // TokenURL is an STS token endpoint.
+	Scopes           []string              `json:"scopes,omitempty"`        // Scopes are requested scopes, optional.
+	CredentialSource ExternalAccountSource `json:"credential_source"`       // CredentialSource is where the subject token comes from.
+	ClientID         string                `json:"client_id,omitempty"`     // ClientID authenticates to the STS, optional.
+	ClientSecret     string                `json:"client_secret,omitempty"` // ClientSecret authenticates to the STS, optional.
+}
+
+// ExternalAccountSource describes where a subject token comes from, exactly one of
+// File, URL and Environment must be set.
+type ExternalAccountSource struct {
+	File        string            `json:"file,omitempty"`        // File is a path of the token file.
+	URL         string            `json:"url,omitempty"`         // URL is fetched with a GET request for the token.
+	Headers     map[string]string `json:"headers,omitempty"`     // Headers are sent with the URL request.
+	Environment string            `json:"environment,omitempty"` // Environment is a name of an environment variable with the token.
+
+	// Format is a format of the file or the URL response: `text` (default) or `json`
+	// with the token in the SubjectTokenFieldName field.
+	Format struct {
+		Type                  string `json:"type,omitempty"`
+		SubjectTokenFieldName string `json:"subject_token_field_name,omitempty"`
+	} `json:"format,omitempty"`
+}
+
+// ParseExternalAccount parses and validates an external account credential configuration.
+func ParseExternalAccount(data []byte) (*ExternalAccount, error) {
+	var ea ExternalAccount
+	if err := json.Unmarshal(data, &ea); err != nil {
+		return nil, fmt.Errorf("oauth2: malformed external account: %w", err)
+	}
+
+	switch {
+	case ea.Type != "external_account":
+		return nil, fmt.Errorf("oauth2: unsupported credential type %q", ea.Type)
+	case ea.TokenURL == "":
+		return nil, errors.New("oauth2: external account token_url is not set")
+	case ea.SubjectTokenType == "":
+		return nil, errors.New("oauth2: external account subject_token_type is not set")
+	}
+
+	src := ea.CredentialSource
+	var sources int
+	for _, s := range []string{src.File, src.URL, src.Environment} {
+		if s != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return nil, errors.New("oauth2: external account must have exactly one of file, url and environment credential sources")
+	}
+
+	switch src.Format.Type {
+	case "", "text":
+	case "json":
+		if src.Format.SubjectTokenFieldName == "" {
+			return nil, errors.New("oauth2: external account subject_token_field_name is not set")
+		}
+	default:
+		return nil, fmt.Errorf("oauth2: unsupported credential format %q", src.Format.Type)
+	}
+	return &ea, nil
+}
+
+// NewExternalAccountTokenSource returns a token source for the external account configuration.
+// The HTTP client is used for the STS and credential URL requests.
+func NewExternalAccountTokenSource(client *http.Client, data []byte) (*FederatedTokenSource, error) {
+	ea, err := ParseExternalAccount(data)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := InParamsMode
+	if ea.ClientID != "" && ea.ClientSecret != "" {
+		mode = InHeaderMode
+	}
+	c := NewClient(client, Config{
+		ClientID:     ea.ClientID,
+		ClientSecret: ea.ClientSecret,
+		TokenURL:     ea.TokenURL,
+		Mode:         mode,
+	})
+
+	return NewFederatedTokenSource(c, FederationConfig{
+		SubjectToken:       ea.subjectToken(client),
+		SubjectTokenType:   ea.SubjectTokenType,
+		RequestedTokenType: TokenTypeAccessToken,
+		Audience:           ea.Audience,
+		Scopes:             ea.Scopes,
+	})
+}
+
+func (ea *ExternalAccount) subjectToken(client *http.Client) SubjectTokenFunc {
+	src := ea.CredentialSource
+
+	return func(ctx context.Context) (string, error) {
+		var raw []byte
+		var err error
+
+		switch {
+		case src.File != "":
+			raw, err = os.ReadFile(src.File)
+		case src.URL != "":
+			raw, err = fetchSubjectToken(ctx, client, src.URL, src.Headers)
+		default:
+			raw = []byte(os.Getenv(src.Environment))
+		}
+		if err != nil {
+			return "", fmt.Errorf("oauth2: cannot read subject token: %w", err)
+		}
+
+		token := strings.TrimSpace(string(raw))
+		if src.Format.Type == "json" {
+			var fields map[string]interface{}
+			if err := json.Unmarshal(raw, &fields); err != nil {
+				return "", fmt.Errorf("oauth2: malformed subject token: %w", err)
+			}
+			token, _ = fields[src.Format.SubjectTokenFieldName].(string)
+		}
+		if token == "" {
+			return "", errors.New("oauth2: subject token is empty")
+		}
+		return token, nil
+	}
+}
+
+func fetchSubjectToken(ctx context.Context, client *http.Client, url string, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status %v: %s", resp.Status, body)
+	}
+	return body, nil
+}
@@ -0,0 +1,82 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExternalAccountTokenSource(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/metadata":
+			mustEqual(t, r.Header.Get("Metadata"), "True")
+			fmt.Fprint(w, `{"access_token": "URL_TOKEN"}`)
+
+		case "/sts":
+			mustEqual(t, r.FormValue("audience"), "//iam.example.com/pool")
+			mustEqual(t, r.FormValue("subject_token_type"), TokenTypeJWT)
+			mustEqual(t, r.FormValue("requested_token_type"), TokenTypeAccessToken)
+			mustEqual(t, r.FormValue("scope"), "cloud")
+
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token": "STS_%s", "expires_in": 3600}`, r.FormValue("subject_token"))
+		}
+	})
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "token")
+	mustOk(t, os.WriteFile(path, []byte("FILE_TOKEN"), 0o600))
+	t.Setenv("TEST_SUBJECT_TOKEN", "ENV_TOKEN")
+
+	testCases := []struct {
+		source string
+		want   string
+	}{
+		{fmt.Sprintf(`{"file": %q}`, path), "STS_FILE_TOKEN"},
+		{`{"environment": "TEST_SUBJECT_TOKEN"}`, "STS_ENV_TOKEN"},
+		{
+			fmt.Sprintf(`{"url": %q, "headers": {"Metadata": "True"}, "format": {"type": "json", "subject_token_field_name": "access_token"}}`, ts.URL+"/metadata"),
+			"STS_URL_TOKEN",
+		},
+	}
+
+	for _, tc := range testCases {
+		config := fmt.Sprintf(`{
+			"type": "external_account",
+			"audience": "//iam.example.com/pool",
+			"subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+			"token_url": %q,
+			"scopes": ["cloud"],
+			"credential_source": %s
+		}`, ts.URL+"/sts", tc.source)
+
+		src, err := NewExternalAccountTokenSource(http.DefaultClient, []byte(config))
+		mustOk(t, err)
+
+		tok, err := src.Token(context.Background())
+		mustOk(t, err)
+		mustEqual(t, tok.AccessToken, tc.want)
+	}
+}
+
+func TestParseExternalAccount_Invalid(t *testing.T) {
+	testCases := []string{
+		`not json`,
+		`{"type": "service_account"}`,
+		`{"type": "external_account", "subject_token_type": "jwt", "credential_source": {"file": "f"}}`,
+		`{"type": "external_account", "token_url": "u", "credential_source": {"file": "f"}}`,
+		`{"type": "external_account", "token_url": "u", "subject_token_type": "jwt", "credential_source": {}}`,
+		`{"type": "external_account", "token_url": "u", "subject_token_type": "jwt", "credential_source": {"file": "f", "url": "u"}}`,
+		`{"type": "external_account", "token_url": "u", "subject_token_type": "jwt", "credential_source": {"file": "f", "format": {"type": "json"}}}`,
+		`{"type": "external_account", "token_url": "u", "subject_token_type": "jwt", "credential_source": {"file": "f", "format": {"type": "xml"}}}`,
+	}
+
+	for _, data := range testCases {
+		_, err := ParseExternalAccount([]byte(data))
+		mustFail(t, err)
+	}
+}
@@ -0,0 +1,69 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Google endpoints, used when client_secret.json doesn't set them.
+const (
+	googleIssuer      = "https://accounts.google.com"
+	googleAuthURL     = "https://accounts.google.com/o/oauth2/auth"
+	googleTokenURL    = "https://oauth2.googleapis.com/token"
+	googleDeviceURL   = "https://oauth2.googleapis.com/device/code"
+	googleUserInfoURL = "https://openidconnect.googleapis.com/v1/userinfo"
+)
+
+// GoogleConfigFromJSON returns a Config from Google's `client_secret.json`
+// downloaded from the Cloud Console, both `web` and `installed` application types are supported.
+// The redirect URL is the first of the registered ones, it can be changed by the caller.
+func GoogleConfigFromJSON(data []byte, scopes ...string) (Config, error) {
+	type credentials struct {
+		ClientID     string   `json:"client_id"`
+		ClientSecret string   `json:"client_secret"`
+		AuthURI      string   `json:"auth_uri"`
+		TokenURI     string   `json:"token_uri"`
+		RedirectURIs []string `json:"redirect_uris"`
+	}
+	var file struct {
+		Web       *credentials `json:"web"`
+		Installed *credentials `json:"installed"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return Config{}, fmt.Errorf("oauth2: malformed client_secret.json: %w", err)
+	}
+
+	c := file.Web
+	if c == nil {
+		c = file.Installed
+	}
+	switch {
+	case c == nil:
+		return Config{}, errors.New("oauth2: client_secret.json has neither web nor installed credentials")
+	case c.ClientID == "":
+		return Config{}, errors.New("oauth2: client_secret.json has no client_id")
+	}
+
+	config := Config{
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		Issuer:       googleIssuer,
+		AuthURL:      c.AuthURI,
+		TokenURL:     c.TokenURI,
+		DeviceURL:    googleDeviceURL,
+		UserInfoURL:  googleUserInfoURL,
+		Mode:         InParamsMode,
+		Scopes:       scopes,
+	}
+	if config.AuthURL == "" {
+		config.AuthURL = googleAuthURL
+	}
+	if config.TokenURL == "" {
+		config.TokenURL = googleTokenURL
+	}
+	if len(c.RedirectURIs) > 0 {
+		config.RedirectURL = c.RedirectURIs[0]
+	}
+	return config, nil
+}
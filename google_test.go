@@ -0,0 +1,50 @@
+package oauth2
+
+import (
+	"testing"
+)
+
+func TestGoogleConfigFromJSON(t *testing.T) {
+	config, err := GoogleConfigFromJSON([]byte(`{
+		"web": {
+			"client_id": "123.apps.googleusercontent.com",
+			"project_id": "project",
+			"auth_uri": "https://accounts.google.com/o/oauth2/auth",
+			"token_uri": "https://oauth2.googleapis.com/token",
+			"auth_provider_x509_cert_url": "https://www.googleapis.com/oauth2/v1/certs",
+			"client_secret": "CLIENT_SECRET",
+			"redirect_uris": ["https://example.com/callback", "https://example.com/other"]
+		}
+	}`), "openid", "email")
+	mustOk(t, err)
+	mustEqual(t, config.ClientID, "123.apps.googleusercontent.com")
+	mustEqual(t, config.ClientSecret, "CLIENT_SECRET")
+	mustEqual(t, config.Issuer, "https://accounts.google.com")
+	mustEqual(t, config.AuthURL, "https://accounts.google.com/o/oauth2/auth")
+	mustEqual(t, config.TokenURL, "https://oauth2.googleapis.com/token")
+	mustEqual(t, config.RedirectURL, "https://example.com/callback")
+	mustEqual(t, config.Mode, InParamsMode)
+	mustEqual(t, config.Scopes, []string{"openid", "email"})
+
+	config, err = GoogleConfigFromJSON([]byte(`{
+		"installed": {
+			"client_id": "456.apps.googleusercontent.com",
+			"client_secret": "CLIENT_SECRET",
+			"redirect_uris": ["http://localhost"]
+		}
+	}`))
+	mustOk(t, err)
+	mustEqual(t, config.ClientID, "456.apps.googleusercontent.com")
+	mustEqual(t, config.TokenURL, "https://oauth2.googleapis.com/token")
+	mustEqual(t, config.DeviceURL, "https://oauth2.googleapis.com/device/code")
+	mustEqual(t, config.RedirectURL, "http://localhost")
+
+	_, err = GoogleConfigFromJSON([]byte(`{"type": "service_account"}`))
+	mustFail(t, err)
+
+	_, err = GoogleConfigFromJSON([]byte(`{"web": {"client_secret": "CLIENT_SECRET"}}`))
+	mustFail(t, err)
+
+	_, err = GoogleConfigFromJSON([]byte(`not json`))
+	mustFail(t, err)
+}
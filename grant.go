@@ -0,0 +1,75 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/url"
+)
+
+// Grant types of token requests, the `grant_type` parameter.
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypePassword          = "password"
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"    // RFC 8628.
+	GrantTypeTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange" // RFC 8693.
+	GrantTypeJWTBearer         = "urn:ietf:params:oauth:grant-type:jwt-bearer"     // RFC 7523.
+	GrantTypeSAML2Bearer       = "urn:ietf:params:oauth:grant-type:saml2-bearer"   // RFC 7522.
+	GrantTypeCIBA              = "urn:openid:params:grant-type:ciba"               // OIDC CIBA.
+)
+
+// Grant is a token request of any grant type, including extension grants of RFC 6749 section 4.5.
+// See the builders like JWTBearerGrant for common ones.
+type Grant struct {
+	Type   string     // Type is the `grant_type`, required.
+	Params url.Values // Params are parameters of the grant, optional.
+
+	_ struct{} // enforce explicit field names.
+}
+
+// JWTBearerGrant returns a grant exchanging a signed JWT for a token, RFC 7523 section 2.1.
+func JWTBearerGrant(assertion string) Grant {
+	return Grant{
+		Type:   GrantTypeJWTBearer,
+		Params: url.Values{"assertion": []string{assertion}},
+	}
+}
+
+// SAML2BearerGrant returns a grant exchanging a SAML 2.0 assertion for a token, RFC 7522 section 2.1.
+// The assertion is an XML document, it's base64url-encoded by the grant.
+func SAML2BearerGrant(assertion []byte) Grant {
+	return Grant{
+		Type:   GrantTypeSAML2Bearer,
+		Params: url.Values{"assertion": []string{base64.RawURLEncoding.EncodeToString(assertion)}},
+	}
+}
+
+// CIBAGrant returns a grant polling for a token of an authentication request, OIDC CIBA section 10.1.
+func CIBAGrant(authReqID string) Grant {
+	return Grant{
+		Type:   GrantTypeCIBA,
+		Params: url.Values{"auth_req_id": []string{authReqID}},
+	}
+}
+
+// DeviceCodeGrant returns a grant polling for a token of a device authorization, RFC 8628 section 3.4.
+// Client.DeviceAccessToken also handles polling intervals and pending authorizations.
+func DeviceCodeGrant(deviceCode string) Grant {
+	return Grant{
+		Type:   GrantTypeDeviceCode,
+		Params: url.Values{"device_code": []string{deviceCode}},
+	}
+}
+
+// GrantToken retrieves a token with the grant.
+func (c *Client) GrantToken(ctx context.Context, g Grant) (*Token, error) {
+	if g.Type == "" {
+		return nil, errors.New("oauth2: grant type is not set")
+	}
+
+	params := cloneURLValues(g.Params)
+	params.Set("grant_type", g.Type)
+	return c.retrieveToken(ctx, params)
+}
@@ -0,0 +1,44 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGrantToken(t *testing.T) {
+	var form string
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		mustOk(t, r.ParseForm())
+		form = r.PostForm.Encode()
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ProperToken"}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{TokenURL: ts.URL, Mode: InHeaderMode})
+
+	testCases := []struct {
+		grant Grant
+		want  string
+	}{
+		{JWTBearerGrant("JWT"), "assertion=JWT&grant_type=urn%3Aietf%3Aparams%3Aoauth%3Agrant-type%3Ajwt-bearer"},
+		{SAML2BearerGrant([]byte("<saml/>")), "assertion=PHNhbWwvPg&grant_type=urn%3Aietf%3Aparams%3Aoauth%3Agrant-type%3Asaml2-bearer"},
+		{CIBAGrant("REQ_ID"), "auth_req_id=REQ_ID&grant_type=urn%3Aopenid%3Aparams%3Agrant-type%3Aciba"},
+		{DeviceCodeGrant("CODE"), "device_code=CODE&grant_type=urn%3Aietf%3Aparams%3Aoauth%3Agrant-type%3Adevice_code"},
+		{Grant{Type: "custom"}, "grant_type=custom"},
+	}
+
+	for _, tc := range testCases {
+		tok, err := client.GrantToken(context.Background(), tc.grant)
+		mustOk(t, err)
+		mustEqual(t, tok.AccessToken, "ProperToken")
+		mustEqual(t, tok.GrantType, tc.grant.Type)
+		mustEqual(t, form, tc.want)
+	}
+
+	_, err := client.GrantToken(context.Background(), Grant{})
+	mustFail(t, err)
+}
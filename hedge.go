@@ -0,0 +1,91 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+// errHedgeLost is returned by a hedged request cancelled because the other one has won.
+var errHedgeLost = errors.New("oauth2: hedged request lost")
+
+// hedgeKey is a context key of *atomic.Bool, set when hedged requests are cancelled by the winner.
+type hedgeKey struct{}
+
+// hedgeLost reports whether the request of the context was cancelled by a winning hedged request.
+func hedgeLost(ctx context.Context) bool {
+	lost, ok := ctx.Value(hedgeKey{}).(*atomic.Bool)
+	return ok && lost.Load()
+}
+
+// doRequestHedged sends a token request and, if there is no response within Config.HedgeDelay,
+// a second one, the first successful response wins and the other request is cancelled.
+func (c *Client) doRequestHedged(ctx context.Context, mode Mode, params url.Values) (*Token, error) {
+	if c.config.HedgeDelay <= 0 || !isHedgeable(params) {
+		return c.doRequest(ctx, mode, params)
+	}
+
+	var lost atomic.Bool
+	hctx, cancel := context.WithCancel(context.WithValue(ctx, hedgeKey{}, &lost))
+	defer func() {
+		lost.Store(true)
+		cancel()
+	}()
+
+	type result struct {
+		token *Token
+		resp  *http.Response
+		err   error
+	}
+	results := make(chan result, 2)
+	send := func() {
+		var resp *http.Response
+		token, err := c.doRequest(context.WithValue(hctx, responseKey{}, &resp), mode, params)
+		results <- result{token: token, resp: resp, err: err}
+	}
+
+	go send()
+	inflight := 1
+
+	timer := time.NewTimer(c.config.HedgeDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			c.counters.hedges.Add(1)
+			inflight++
+			go send()
+
+		case r := <-results:
+			inflight--
+			if r.err != nil && inflight > 0 {
+				continue
+			}
+			if p, ok := ctx.Value(responseKey{}).(**http.Response); ok {
+				*p = r.resp
+			}
+			return r.token, r.err
+		}
+	}
+}
+
+// idempotentGrants are grants which can be sent twice without side effects, the same request
+// just gets another token. Codes of authorization_code, device_code and CIBA are single-use, and
+// a reused refresh token may revoke the whole token family, so a second request fails or even
+// revokes the tokens. Extension grants are unknown and not considered idempotent.
+var idempotentGrants = map[string]bool{
+	GrantTypeClientCredentials: true,
+	GrantTypePassword:          true,
+	GrantTypeJWTBearer:         true,
+	GrantTypeSAML2Bearer:       true,
+	GrantTypeTokenExchange:     true,
+}
+
+// isHedgeable reports whether a token request can be sent twice, see idempotentGrants.
+func isHedgeable(params url.Values) bool {
+	return idempotentGrants[params.Get("grant_type")]
+}
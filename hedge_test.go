@@ -0,0 +1,78 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedge(t *testing.T) {
+	var calls atomic.Int32
+	stuck := make(chan struct{})
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			<-stuck
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN"}`)
+	})
+	defer ts.Close()
+	defer close(stuck)
+
+	client := newClientWithConfig(Config{
+		ClientID:         "CLIENT_ID",
+		TokenURL:         ts.URL,
+		Mode:             InHeaderMode,
+		HedgeDelay:       10 * time.Millisecond,
+		BreakerThreshold: 1,
+	})
+
+	tok, err := client.ClientCredentialsToken(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "ACCESS_TOKEN")
+	mustEqual(t, calls.Load(), int32(2))
+	mustEqual(t, client.Stats().Hedges, uint64(1))
+	mustEqual(t, client.BreakerState(), BreakerClosed)
+}
+
+func TestHedge_SingleUseGrant(t *testing.T) {
+	var calls atomic.Int32
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		time.Sleep(30 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN"}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		ClientID:   "CLIENT_ID",
+		TokenURL:   ts.URL,
+		Mode:       InHeaderMode,
+		HedgeDelay: time.Millisecond,
+	})
+
+	_, err := client.Exchange(context.Background(), "CODE")
+	mustOk(t, err)
+	mustEqual(t, calls.Load(), int32(1))
+	mustEqual(t, client.Stats().Hedges, uint64(0))
+}
+
+func TestIsHedgeable(t *testing.T) {
+	for grant, want := range map[string]bool{
+		GrantTypeClientCredentials: true,
+		GrantTypeTokenExchange:     true,
+		GrantTypeRefreshToken:      false,
+		GrantTypeAuthorizationCode: false,
+		GrantTypeDeviceCode:        false,
+		GrantTypeCIBA:              false,
+		"urn:example:custom-grant": false,
+	} {
+		mustEqual(t, isHedgeable(url.Values{"grant_type": {grant}}), want)
+	}
+}
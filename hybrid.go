@@ -0,0 +1,93 @@
+package oauth2
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// HybridAuthURL returns a URL to the consent page for the OIDC hybrid flow
+// with `response_type=code id_token`.
+//
+// The provider sends the response in the URL fragment, pass `response_mode=form_post`
+// in params to receive it as a POST form on the server.
+func (c *Client) HybridAuthURL(state, nonce string, params url.Values) (string, error) {
+	if nonce == "" {
+		return "", errors.New("oauth2: nonce is required for hybrid flow")
+	}
+
+	v := cloneURLValues(params)
+	v.Set("response_type", "code id_token")
+	v.Set("nonce", nonce)
+	return c.BuildAuthCodeURL(state, v)
+}
+
+// HybridResponse is an authorization response of the OIDC hybrid flow.
+type HybridResponse struct {
+	Code    string // Code is an authorization code.
+	IDToken string // IDToken is an ID token, it must be verified by the caller.
+	State   string // State is a state passed to HybridAuthURL.
+	Issuer  string // Issuer is an optional `iss` of the response, see Client.ValidateResponseIssuer.
+}
+
+// ParseHybridResponse parses an authorization response of the hybrid flow
+// from the URL fragment parameters or form_post values.
+//
+// The code is validated against `c_hash` of the ID token.
+// Signature and other claims of the ID token must be verified by the caller.
+func ParseHybridResponse(values url.Values) (*HybridResponse, error) {
+	if code := values.Get("error"); code != "" {
+		return nil, fmt.Errorf("oauth2: authorization failed: %s: %s", code, values.Get("error_description"))
+	}
+
+	resp := &HybridResponse{
+		Code:    values.Get("code"),
+		IDToken: values.Get("id_token"),
+		State:   values.Get("state"),
+		Issuer:  values.Get("iss"),
+	}
+	switch {
+	case resp.Code == "":
+		return nil, errors.New("oauth2: response missing code")
+	case resp.IDToken == "":
+		return nil, errors.New("oauth2: response missing id_token")
+	}
+
+	if err := ValidateCodeHash(resp.IDToken, resp.Code); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ParseHybridRequest same as ParseHybridResponse but reads a form_post request.
+func ParseHybridRequest(r *http.Request) (*HybridResponse, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	return ParseHybridResponse(r.Form)
+}
+
+// ValidateCodeHash validates the code against `c_hash` claim of the ID token,
+// OIDC Core section 3.3.2.11. The ID token signature is not verified.
+func ValidateCodeHash(idToken, code string) error {
+	var claims struct {
+		CodeHash string `json:"c_hash"`
+	}
+	header, err := decodeJWT(idToken, &claims)
+	if err != nil {
+		return err
+	}
+	if claims.CodeHash == "" {
+		return errors.New("oauth2: id_token missing c_hash")
+	}
+
+	want, err := leftHalfHash(header.Algorithm, code)
+	if err != nil {
+		return err
+	}
+	if want != claims.CodeHash {
+		return errors.New("oauth2: c_hash mismatch")
+	}
+	return nil
+}
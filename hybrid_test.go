@@ -0,0 +1,66 @@
+package oauth2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const (
+	testCode     = "Qcb0Orv1zh30vL1MPRsbm-diHiMwcLyZvn1arpZv-Jxf_11jnpEX3Tgfvk"
+	testCodeHash = "LDktKdoQak3Pk0cnXxCltA"
+)
+
+func TestHybridAuthURL(t *testing.T) {
+	client := NewClient(http.DefaultClient, Config{
+		ClientID: "CLIENT_ID",
+		AuthURL:  "https://example.com/auth",
+	})
+
+	u, err := client.HybridAuthURL("state", "nonce", url.Values{"response_mode": {"form_post"}})
+	mustOk(t, err)
+	mustEqual(t, u, "https://example.com/auth?client_id=CLIENT_ID&nonce=nonce&response_mode=form_post&response_type=code+id_token&state=state")
+
+	_, err = client.HybridAuthURL("state", "", nil)
+	mustFail(t, err)
+}
+
+func TestParseHybridResponse(t *testing.T) {
+	idToken := makeJWT(t, jwtHeader{Algorithm: "RS256"}, map[string]any{"c_hash": testCodeHash})
+
+	resp, err := ParseHybridResponse(url.Values{
+		"code":     {testCode},
+		"id_token": {idToken},
+		"state":    {"state"},
+	})
+	mustOk(t, err)
+	mustEqual(t, resp.Code, testCode)
+	mustEqual(t, resp.IDToken, idToken)
+	mustEqual(t, resp.State, "state")
+
+	testCases := []url.Values{
+		{"error": {"access_denied"}},
+		{"id_token": {idToken}},
+		{"code": {testCode}},
+		{"code": {"other-code"}, "id_token": {idToken}},
+		{"code": {testCode}, "id_token": {makeJWT(t, jwtHeader{Algorithm: "RS256"}, map[string]any{})}},
+	}
+	for _, values := range testCases {
+		_, err := ParseHybridResponse(values)
+		mustFail(t, err)
+	}
+}
+
+func TestParseHybridRequest(t *testing.T) {
+	idToken := makeJWT(t, jwtHeader{Algorithm: "RS256"}, map[string]any{"c_hash": testCodeHash})
+	form := url.Values{"code": {testCode}, "id_token": {idToken}}
+
+	r := httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := ParseHybridRequest(r)
+	mustOk(t, err)
+	mustEqual(t, resp.Code, testCode)
+}
@@ -0,0 +1,67 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// IDToken is a verified OpenID Connect ID token, OIDC Core section 2.
+type IDToken struct {
+	Issuer          string    // Issuer is `iss`.
+	Subject         string    // Subject is `sub`.
+	Audience        []string  // Audience is `aud`.
+	AuthorizedParty string    // AuthorizedParty is `azp`.
+	Nonce           string    // Nonce is `nonce`.
+	Expiry          time.Time // Expiry is `exp`.
+	IssuedAt        time.Time // IssuedAt is `iat`.
+
+	// Raw are all claims of the token.
+	Raw UnverifiedClaims
+}
+
+// VerifyIDToken verifies the signature of an ID token with the key set and its claims, OIDC Core section 3.1.3.7:
+// the issuer must be Config.Issuer or one of Config.ExpectedIssuers, the audience must contain Config.ClientID,
+// `azp` must be Config.ClientID when present, and `exp` is required. Times are checked with a minute of leeway.
+// The nonce is checked when non-empty, it must be the one sent in the authorization request.
+func (c *Client) VerifyIDToken(ctx context.Context, ks *KeySet, raw, nonce string) (*IDToken, error) {
+	issuers := c.expectedIssuers()
+	if len(issuers) == 0 {
+		return nil, errors.New("oauth2: expected issuers are not set")
+	}
+
+	claims, err := ks.verifyJWT(ctx, raw, "")
+	if err != nil {
+		return nil, err
+	}
+
+	t := &IDToken{
+		Issuer:          claims.Issuer(),
+		Subject:         claims.Subject(),
+		Audience:        claims.Audience(),
+		AuthorizedParty: claims.string("azp"),
+		Nonce:           claims.string("nonce"),
+		Expiry:          claims.Expiry(),
+		IssuedAt:        claims.time("iat"),
+		Raw:             claims,
+	}
+	if err := checkIssuer(t.Issuer, issuers); err != nil {
+		return nil, err
+	}
+	if err := checkAudience(t.Audience, []string{c.config.ClientID}); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case t.AuthorizedParty != "" && t.AuthorizedParty != c.config.ClientID:
+		return nil, errors.New("oauth2: ID token azp is not the client")
+	case len(t.Audience) > 1 && t.AuthorizedParty == "":
+		return nil, errors.New("oauth2: ID token with several audiences has no azp")
+	case nonce != "" && !secretEqual(t.Nonce, nonce):
+		return nil, errors.New("oauth2: ID token nonce mismatch")
+	}
+	if err := checkJWTTimes(claims, c.now(), jwtLeeway); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
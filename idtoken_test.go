@@ -0,0 +1,110 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestExpectedIssuersAndAudiences(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	mustOk(t, err)
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"keys": [{"kty": "RSA", "kid": "k1", "n": %q, "e": %q}]}`,
+			b64(key.N.Bytes()), b64(big.NewInt(int64(key.E)).Bytes()))
+	})
+	defer ts.Close()
+
+	ctx := context.Background()
+	ks := NewKeySet(http.DefaultClient, ts.URL)
+	signer, err := NewSigner(key, "RS256", "k1")
+	mustOk(t, err)
+	sign := func(claims map[string]any) string {
+		raw, err := signJWT(ctx, signer, claims)
+		mustOk(t, err)
+		return raw
+	}
+	signAT := func(claims map[string]any) string {
+		raw, err := signTypedJWT(ctx, signer, "at+jwt", claims)
+		mustOk(t, err)
+		return raw
+	}
+
+	client := newClientWithConfig(Config{
+		ClientID:          "CLIENT_ID",
+		Issuer:            "https://login.example.com/common",
+		ExpectedIssuers:   []string{"https://login.example.com/tenant-1"},
+		ExpectedAudiences: []string{"api-1", "api-2"},
+	})
+	exp := time.Now().Add(time.Hour).Unix()
+
+	var issuerErr *IssuerMismatchError
+	var audienceErr *AudienceMismatchError
+
+	// access tokens.
+	c, err := client.VerifyAccessToken(ctx, ks, signAT(map[string]any{"iss": "https://login.example.com/tenant-1", "aud": "api-2", "exp": exp}))
+	mustOk(t, err)
+	mustEqual(t, c.Issuer, "https://login.example.com/tenant-1")
+
+	_, err = client.VerifyAccessToken(ctx, ks, signAT(map[string]any{"iss": "https://login.example.com/tenant-2", "aud": "api-1", "exp": exp}))
+	mustEqual(t, errors.As(err, &issuerErr), true)
+	mustEqual(t, issuerErr.Issuer, "https://login.example.com/tenant-2")
+	mustEqual(t, errors.Is(err, ErrResponseIssuerMismatch), false)
+
+	_, err = client.VerifyAccessToken(ctx, ks, signAT(map[string]any{"iss": "https://login.example.com/common", "aud": "api-3", "exp": exp}))
+	mustEqual(t, errors.As(err, &audienceErr), true)
+	mustEqual(t, audienceErr.Expected, []string{"api-1", "api-2"})
+
+	// an ID token signed with the same key isn't an access token.
+	_, err = client.VerifyAccessToken(ctx, ks, sign(map[string]any{"iss": "https://login.example.com/common", "aud": "api-1", "exp": exp}))
+	mustFail(t, err)
+
+	// ID tokens.
+	idClaims := map[string]any{"iss": "https://login.example.com/common", "sub": "user", "aud": "CLIENT_ID", "nonce": "NONCE", "exp": exp}
+	idToken, err := client.VerifyIDToken(ctx, ks, sign(idClaims), "NONCE")
+	mustOk(t, err)
+	mustEqual(t, idToken.Subject, "user")
+
+	_, err = client.VerifyIDToken(ctx, ks, sign(idClaims), "OTHER")
+	mustFail(t, err)
+
+	idClaims["aud"] = "api-1"
+	_, err = client.VerifyIDToken(ctx, ks, sign(idClaims), "NONCE")
+	mustEqual(t, errors.As(err, &audienceErr), true)
+
+	idClaims["aud"] = []string{"CLIENT_ID", "api-1"}
+	_, err = client.VerifyIDToken(ctx, ks, sign(idClaims), "NONCE")
+	mustFail(t, err)
+	idClaims["azp"] = "CLIENT_ID"
+	_, err = client.VerifyIDToken(ctx, ks, sign(idClaims), "NONCE")
+	mustOk(t, err)
+
+	idClaims["iss"] = "https://evil.example.com"
+	_, err = client.VerifyIDToken(ctx, ks, sign(idClaims), "NONCE")
+	mustEqual(t, errors.As(err, &issuerErr), true)
+	idClaims["iss"] = "https://login.example.com/common"
+
+	// clocks of the provider and the client may differ a bit.
+	idClaims["iat"] = time.Now().Add(30 * time.Second).Unix()
+	idClaims["exp"] = time.Now().Add(-30 * time.Second).Unix()
+	_, err = client.VerifyIDToken(ctx, ks, sign(idClaims), "NONCE")
+	mustOk(t, err)
+	idClaims["exp"] = time.Now().Add(-2 * time.Minute).Unix()
+	_, err = client.VerifyIDToken(ctx, ks, sign(idClaims), "NONCE")
+	mustFail(t, err)
+
+	// authorization responses.
+	mustOk(t, client.ValidateResponseIssuer(map[string][]string{"iss": {"https://login.example.com/tenant-1"}}))
+	err = client.ValidateResponseIssuer(map[string][]string{"iss": {"https://evil.example.com"}})
+	mustEqual(t, errors.Is(err, ErrResponseIssuerMismatch), true)
+	mustEqual(t, errors.As(err, &issuerErr), true)
+}
@@ -0,0 +1,151 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Introspection represents a token introspection response, RFC 7662 section 2.2.
+type Introspection struct {
+	Active    bool      `json:"active"`               // Active reports whether the token is currently active.
+	Scope     string    `json:"scope,omitempty"`      // Scope is a space-separated list of scopes of the token.
+	ClientID  string    `json:"client_id,omitempty"`  // ClientID is the client the token was issued to.
+	Username  string    `json:"username,omitempty"`   // Username is a human-readable identifier of the resource owner.
+	TokenType string    `json:"token_type,omitempty"` // TokenType is the type of the token.
+	Subject   string    `json:"sub,omitempty"`        // Subject is the resource owner of the token.
+	Audience  []string  `json:"aud,omitempty"`        // Audience are intended audiences of the token.
+	Issuer    string    `json:"iss,omitempty"`        // Issuer is the issuer of the token.
+	JTI       string    `json:"jti,omitempty"`        // JTI is an identifier of the token.
+	Expiry    time.Time `json:"expiry,omitempty"`     // Expiry is when the token expires, zero if unknown.
+	IssuedAt  time.Time `json:"issued_at,omitempty"`  // IssuedAt is when the token was issued, zero if unknown.
+	NotBefore time.Time `json:"not_before,omitempty"` // NotBefore is when the token becomes valid, zero if unknown.
+	Actor     *Actor    `json:"act,omitempty"`        // Actor is the acting party of a delegated token, RFC 8693.
+
+	Raw map[string]interface{} `json:"-"` // Raw contains all fields of the response.
+}
+
+// Scopes returns the scopes of the token.
+func (in *Introspection) Scopes() []string {
+	return ParseScopes(in.Scope)
+}
+
+// IntrospectToken asks the introspection endpoint about the state of a token, RFC 7662.
+// The hint is an optional `token_type_hint`, like "access_token" or "refresh_token".
+//
+// An inactive token is not an error, check Introspection.Active.
+func (c *Client) IntrospectToken(ctx context.Context, token, hint string) (*Introspection, error) {
+	if c.config.IntrospectionURL == "" {
+		return nil, errors.New("oauth2: introspection URL is not set")
+	}
+
+	params := url.Values{
+		"token": []string{token},
+	}
+	if hint != "" {
+		params.Set("token_type_hint", hint)
+	}
+
+	var body []byte
+	var err error
+	if c.config.IntrospectionToken != nil {
+		body, err = c.introspectWithToken(ctx, params)
+	} else {
+		mode, ok := ctx.Value(modeKey{}).(Mode)
+		if !ok {
+			mode = c.config.IntrospectionMode
+		}
+		if mode == AutoDetectMode {
+			// resource servers usually authenticate with Basic auth, RFC 7662 section 2.1.
+			mode = c.requestMode(ctx, InHeaderMode)
+		}
+		body, err = c.postForm(ctx, c.config.IntrospectionURL, mode, params)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: cannot introspect token: %w", err)
+	}
+	return parseIntrospection(body)
+}
+
+// introspectWithToken authenticates the introspection request with a bearer token from Config.IntrospectionToken.
+func (c *Client) introspectWithToken(ctx context.Context, params url.Values) ([]byte, error) {
+	token, err := c.config.IntrospectionToken.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get introspection token: %w", err)
+	}
+
+	// AutoDetectMode adds no client credentials.
+	req, err := c.newTokenRequest(ctx, c.config.IntrospectionURL, AutoDetectMode, params)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", token.Type()+" "+token.AccessToken)
+	return c.sendForm(req)
+}
+
+func parseIntrospection(body []byte) (*Introspection, error) {
+	var ij struct {
+		Active    bool        `json:"active"`
+		Scope     string      `json:"scope"`
+		ClientID  string      `json:"client_id"`
+		Username  string      `json:"username"`
+		TokenType string      `json:"token_type"`
+		Subject   string      `json:"sub"`
+		Audience  audience    `json:"aud"`
+		Issuer    string      `json:"iss"`
+		JTI       string      `json:"jti"`
+		Expiry    json.Number `json:"exp"`
+		IssuedAt  json.Number `json:"iat"`
+		NotBefore json.Number `json:"nbf"`
+		Actor     *Actor      `json:"act"`
+	}
+	if err := json.Unmarshal(body, &ij); err != nil {
+		return nil, err
+	}
+
+	in := &Introspection{
+		Active:    ij.Active,
+		Scope:     ij.Scope,
+		ClientID:  ij.ClientID,
+		Username:  ij.Username,
+		TokenType: ij.TokenType,
+		Subject:   ij.Subject,
+		Audience:  ij.Audience,
+		Issuer:    ij.Issuer,
+		JTI:       ij.JTI,
+		Expiry:    unixTime(ij.Expiry),
+		IssuedAt:  unixTime(ij.IssuedAt),
+		NotBefore: unixTime(ij.NotBefore),
+		Actor:     ij.Actor,
+	}
+
+	_ = json.Unmarshal(body, &in.Raw) // no error checks for optional fields
+
+	return in, nil
+}
+
+// unixTime converts a NumericDate to time, zero if it's missing or malformed.
+func unixTime(n json.Number) time.Time {
+	sec, err := n.Int64()
+	if err != nil || sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// audience is an `aud` claim, a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		if s != "" {
+			*a = audience{s}
+		}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(a))
+}
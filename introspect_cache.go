@@ -0,0 +1,191 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// IntrospectionCache caches introspection results, keys are hashes of tokens.
+// Implementations must be safe for concurrent use.
+type IntrospectionCache interface {
+	// Get returns a cached result for the key, if it's present and not expired.
+	Get(key string) (*Introspection, bool)
+
+	// Set caches a result for the key for the given duration.
+	Set(key string, in *Introspection, ttl time.Duration)
+}
+
+// IntrospectorConfig describes how Introspector caches results.
+type IntrospectorConfig struct {
+	// Cache keeps introspection results, defaults to an in-memory cache.
+	Cache IntrospectionCache
+
+	// TTL limits how long an active result is cached, it's never cached past the token `exp`.
+	// Zero means until `exp`, active results without `exp` are not cached then.
+	TTL time.Duration
+
+	// NegativeTTL is how long an inactive result is cached. Zero means it's not cached.
+	NegativeTTL time.Duration
+
+	// MaxEntries limits the number of results in the default in-memory cache, the least recently used
+	// ones are evicted when expired ones aren't enough. Defaults to 10000, so random tokens
+	// of unauthenticated callers can't grow the cache without limit.
+	MaxEntries int
+
+	_ struct{} // enforce explicit field names.
+}
+
+// Introspector introspects tokens with Client.IntrospectToken and caches results,
+// so resource servers don't call the introspection endpoint per request.
+// It is safe for concurrent use.
+type Introspector struct {
+	client *Client
+	config IntrospectorConfig
+}
+
+// NewIntrospector instantiates a new introspector with a given client and config.
+func NewIntrospector(client *Client, config IntrospectorConfig) *Introspector {
+	if config.Cache == nil {
+		config.Cache = newMemIntrospectionCache(config.MaxEntries)
+	}
+
+	i := &Introspector{
+		client: client,
+		config: config,
+	}
+	return i
+}
+
+// Introspect returns a cached introspection result for the token or asks the introspection endpoint.
+func (i *Introspector) Introspect(ctx context.Context, token string) (*Introspection, error) {
+	key := introspectionKey(token)
+	if in, ok := i.config.Cache.Get(key); ok {
+		return in, nil
+	}
+
+	in, err := i.client.IntrospectToken(ctx, token, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl := i.ttl(in); ttl > 0 {
+		i.config.Cache.Set(key, in, ttl)
+	}
+	return in, nil
+}
+
+func (i *Introspector) ttl(in *Introspection) time.Duration {
+	if !in.Active {
+		return i.config.NegativeTTL
+	}
+
+	ttl := i.config.TTL
+	if !in.Expiry.IsZero() {
+		if untilExp := in.Expiry.Sub(timeNow()); ttl == 0 || untilExp < ttl {
+			ttl = untilExp
+		}
+	}
+	return ttl
+}
+
+// introspectionKey hashes the token, so caches don't keep tokens in plain text.
+func introspectionKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// memIntrospectionCache is an in-memory IntrospectionCache.
+type memIntrospectionCache struct {
+	maxEntries int
+
+	mu        sync.Mutex
+	entries   map[string]introspectionEntry
+	sweepSize int
+}
+
+type introspectionEntry struct {
+	in       *Introspection
+	expiry   time.Time
+	lastUsed time.Time
+}
+
+// minSweepSize is how many entries memIntrospectionCache keeps before removing expired ones.
+const minSweepSize = 1024
+
+// defaultIntrospectionMaxEntries is the default IntrospectorConfig.MaxEntries.
+const defaultIntrospectionMaxEntries = 10000
+
+func newMemIntrospectionCache(maxEntries int) *memIntrospectionCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultIntrospectionMaxEntries
+	}
+
+	c := &memIntrospectionCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]introspectionEntry),
+		sweepSize:  minSweepSize,
+	}
+	return c
+}
+
+func (c *memIntrospectionCache) Get(key string) (*Introspection, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	now := timeNow()
+	if !now.Before(e.expiry) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	e.lastUsed = now
+	c.entries[key] = e
+	return e.in, true
+}
+
+func (c *memIntrospectionCache) Set(key string, in *Introspection, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := timeNow()
+	if _, ok := c.entries[key]; !ok {
+		c.shrink(now)
+	}
+	c.entries[key] = introspectionEntry{in: in, expiry: now.Add(ttl), lastUsed: now}
+}
+
+// shrink makes room for a new entry: it removes expired results once there are enough entries,
+// then the least recently used ones above maxEntries. c.mu must be held.
+func (c *memIntrospectionCache) shrink(now time.Time) {
+	if len(c.entries) < c.sweepSize && len(c.entries) < c.maxEntries {
+		return
+	}
+
+	// tokens are rarely introspected again after they expire.
+	for k, e := range c.entries {
+		if !now.Before(e.expiry) {
+			delete(c.entries, k)
+		}
+	}
+	c.sweepSize = 2 * len(c.entries)
+	if c.sweepSize < minSweepSize {
+		c.sweepSize = minSweepSize
+	}
+
+	for len(c.entries) >= c.maxEntries {
+		var oldest string
+		var oldestUsed time.Time
+		for k, e := range c.entries {
+			if oldestUsed.IsZero() || e.lastUsed.Before(oldestUsed) {
+				oldest, oldestUsed = k, e.lastUsed
+			}
+		}
+		delete(c.entries, oldest)
+	}
+}
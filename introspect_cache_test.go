@@ -0,0 +1,111 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIntrospector(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	calls := map[string]int{}
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		token := r.FormValue("token")
+		calls[token]++
+
+		w.Header().Set("Content-Type", "application/json")
+		switch token {
+		case "ACTIVE":
+			fmt.Fprintf(w, `{"active": true, "exp": %d}`, now.Add(time.Minute).Unix())
+		case "NO_EXP":
+			fmt.Fprint(w, `{"active": true}`)
+		default:
+			fmt.Fprint(w, `{"active": false}`)
+		}
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{IntrospectionURL: ts.URL})
+	i := NewIntrospector(client, IntrospectorConfig{
+		TTL:         time.Hour,
+		NegativeTTL: 10 * time.Second,
+	})
+	ctx := context.Background()
+
+	for _, token := range []string{"ACTIVE", "NO_EXP", "INACTIVE"} {
+		for n := 0; n < 3; n++ {
+			_, err := i.Introspect(ctx, token)
+			mustOk(t, err)
+		}
+		mustEqual(t, calls[token], 1)
+	}
+
+	// negative results expire first.
+	now = now.Add(30 * time.Second)
+	_, err := i.Introspect(ctx, "INACTIVE")
+	mustOk(t, err)
+	mustEqual(t, calls["INACTIVE"], 2)
+
+	// active results are not cached past exp.
+	now = now.Add(time.Minute)
+	in, err := i.Introspect(ctx, "ACTIVE")
+	mustOk(t, err)
+	mustEqual(t, in.Active, true)
+	mustEqual(t, calls["ACTIVE"], 2)
+	mustEqual(t, calls["NO_EXP"], 1)
+}
+
+func TestIntrospector_NoTTL(t *testing.T) {
+	var calls int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"active": false}`)
+	})
+	defer ts.Close()
+
+	i := NewIntrospector(newClientWithConfig(Config{IntrospectionURL: ts.URL}), IntrospectorConfig{})
+
+	for n := 0; n < 3; n++ {
+		_, err := i.Introspect(context.Background(), "TOKEN")
+		mustOk(t, err)
+	}
+	mustEqual(t, calls, 3)
+}
+
+func TestMemIntrospectionCache_MaxEntries(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	c := newMemIntrospectionCache(2)
+	in := &Introspection{Active: true}
+
+	c.Set("a", in, time.Hour)
+	now = now.Add(time.Second)
+	c.Set("b", in, time.Hour)
+	now = now.Add(time.Second)
+	_, ok := c.Get("a")
+	mustEqual(t, ok, true)
+
+	// b was the least recently used.
+	now = now.Add(time.Second)
+	c.Set("c", in, time.Hour)
+	mustEqual(t, len(c.entries), 2)
+	_, ok = c.Get("b")
+	mustEqual(t, ok, false)
+	_, ok = c.Get("a")
+	mustEqual(t, ok, true)
+
+	// expired results go first.
+	now = now.Add(2 * time.Hour)
+	c.Set("d", in, time.Hour)
+	mustEqual(t, len(c.entries), 1)
+
+	mustEqual(t, newMemIntrospectionCache(0).maxEntries, defaultIntrospectionMaxEntries)
+}
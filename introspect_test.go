@@ -0,0 +1,108 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIntrospectToken(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		mustEqual(t, r.URL.String(), "/introspect")
+		mustEqual(t, r.FormValue("token"), "ACCESS_TOKEN")
+		mustEqual(t, r.FormValue("token_type_hint"), "access_token")
+
+		user, pass, ok := r.BasicAuth()
+		mustEqual(t, ok, true)
+		mustEqual(t, user, "CLIENT_ID")
+		mustEqual(t, pass, "CLIENT_SECRET")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{
+			"active": true,
+			"scope": "read write",
+			"client_id": "CLIENT_ID",
+			"sub": "user-1",
+			"aud": "api-1",
+			"exp": 2000000000,
+			"tenant": "acme"
+		}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		ClientID:         "CLIENT_ID",
+		ClientSecret:     "CLIENT_SECRET",
+		IntrospectionURL: ts.URL + "/introspect",
+	})
+
+	in, err := client.IntrospectToken(context.Background(), "ACCESS_TOKEN", "access_token")
+	mustOk(t, err)
+	mustEqual(t, in.Active, true)
+	mustEqual(t, in.Scopes(), []string{"read", "write"})
+	mustEqual(t, in.Subject, "user-1")
+	mustEqual(t, in.Audience, []string{"api-1"})
+	mustEqual(t, in.Expiry, time.Unix(2000000000, 0))
+	mustEqual(t, in.IssuedAt.IsZero(), true)
+	mustEqual(t, in.Raw["tenant"], "acme")
+}
+
+func TestIntrospectToken_Inactive(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"active": false}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{IntrospectionURL: ts.URL})
+
+	in, err := client.IntrospectToken(context.Background(), "ACCESS_TOKEN", "")
+	mustOk(t, err)
+	mustEqual(t, in.Active, false)
+}
+
+func TestIntrospectToken_NoURL(t *testing.T) {
+	client := newClientWithConfig(Config{})
+
+	_, err := client.IntrospectToken(context.Background(), "ACCESS_TOKEN", "")
+	mustFail(t, err)
+}
+
+func TestIntrospectToken_Auth(t *testing.T) {
+	var auth, clientSecret string
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		clientSecret = r.FormValue("client_secret")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"active": true}`)
+	})
+	defer ts.Close()
+
+	config := Config{
+		ClientID:          "CLIENT_ID",
+		ClientSecret:      "CLIENT_SECRET",
+		IntrospectionURL:  ts.URL,
+		Mode:              InHeaderMode,
+		IntrospectionMode: InParamsMode,
+	}
+	_, err := newClientWithConfig(config).IntrospectToken(context.Background(), "ACCESS_TOKEN", "")
+	mustOk(t, err)
+	mustEqual(t, auth, "")
+	mustEqual(t, clientSecret, "CLIENT_SECRET")
+
+	// the context overrides IntrospectionMode.
+	ctx := ContextWithMode(context.Background(), InHeaderMode)
+	_, err = newClientWithConfig(config).IntrospectToken(ctx, "ACCESS_TOKEN", "")
+	mustOk(t, err)
+	mustEqual(t, auth, "Basic Q0xJRU5UX0lEOkNMSUVOVF9TRUNSRVQ=")
+	mustEqual(t, clientSecret, "")
+
+	config.IntrospectionToken = NewTokenSource(newClient(ts.URL), &Token{AccessToken: "INTROSPECTION_TOKEN"}, TokenSourceConfig{})
+	_, err = newClientWithConfig(config).IntrospectToken(context.Background(), "ACCESS_TOKEN", "")
+	mustOk(t, err)
+	mustEqual(t, auth, "Bearer INTROSPECTION_TOKEN")
+	mustEqual(t, clientSecret, "")
+}
@@ -0,0 +1,92 @@
+package oauth2
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrResponseIssuerMismatch is returned by ValidateResponseIssuer when the authorization response
+// came from another provider than the configured one, probably a mix-up attack.
+var ErrResponseIssuerMismatch = errors.New("oauth2: authorization response issuer mismatch")
+
+// IssuerMismatchError is returned when an issuer of an authorization response, a token
+// or provider metadata is not an expected one, see Config.ExpectedIssuers.
+// For authorization responses it matches ErrResponseIssuerMismatch with errors.Is.
+type IssuerMismatchError struct {
+	Issuer   string   // Issuer is the received issuer.
+	Expected []string // Expected are the accepted issuers.
+
+	response bool
+}
+
+func (e *IssuerMismatchError) Error() string {
+	if e.response {
+		return fmt.Sprintf("%v: got %q, want one of %q", ErrResponseIssuerMismatch, e.Issuer, e.Expected)
+	}
+	return fmt.Sprintf("oauth2: issuer %q is not one of %q", e.Issuer, e.Expected)
+}
+
+func (e *IssuerMismatchError) Is(target error) bool {
+	return e.response && target == ErrResponseIssuerMismatch
+}
+
+// AudienceMismatchError is returned when a token is not issued for an expected audience,
+// see Config.ExpectedAudiences.
+type AudienceMismatchError struct {
+	Audience []string // Audience is the received `aud` claim.
+	Expected []string // Expected are the accepted audiences.
+}
+
+func (e *AudienceMismatchError) Error() string {
+	return fmt.Sprintf("oauth2: audience %q doesn't contain any of %q", e.Audience, e.Expected)
+}
+
+// expectedIssuers returns Config.Issuer and Config.ExpectedIssuers.
+func (c *Client) expectedIssuers() []string {
+	issuers := make([]string, 0, 1+len(c.config.ExpectedIssuers))
+	if c.config.Issuer != "" {
+		issuers = append(issuers, c.config.Issuer)
+	}
+	return append(issuers, c.config.ExpectedIssuers...)
+}
+
+// checkIssuer returns *IssuerMismatchError if the issuer is not one of the expected ones.
+func checkIssuer(issuer string, expected []string) error {
+	if !hasString(expected, issuer) {
+		return &IssuerMismatchError{Issuer: issuer, Expected: expected}
+	}
+	return nil
+}
+
+// checkAudience returns *AudienceMismatchError if the audience contains none of the expected ones.
+func checkAudience(audience, expected []string) error {
+	for _, aud := range expected {
+		if hasString(audience, aud) {
+			return nil
+		}
+	}
+	return &AudienceMismatchError{Audience: audience, Expected: expected}
+}
+
+// ValidateResponseIssuer validates the `iss` parameter of an authorization response against Config.Issuer
+// and Config.ExpectedIssuers, RFC 9207. It must be called for error responses too, before acting on them.
+//
+// A response without `iss` is accepted unless Config.RequireResponseIssuer is set.
+func (c *Client) ValidateResponseIssuer(values url.Values) error {
+	iss, ok := values["iss"]
+	expected := c.expectedIssuers()
+
+	switch {
+	case !ok && c.config.RequireResponseIssuer:
+		return errors.New("oauth2: authorization response missing iss")
+	case !ok:
+		return nil
+	case len(expected) == 0:
+		return errors.New("oauth2: authorization response has iss but issuer is not set")
+	case len(iss) != 1 || !hasString(expected, iss[0]):
+		return &IssuerMismatchError{Issuer: strings.Join(iss, " "), Expected: expected, response: true}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package oauth2
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestValidateResponseIssuer(t *testing.T) {
+	client := newClientWithConfig(Config{Issuer: "https://idp.example.com"})
+
+	mustOk(t, client.ValidateResponseIssuer(url.Values{"code": {"CODE"}}))
+	mustOk(t, client.ValidateResponseIssuer(url.Values{"iss": {"https://idp.example.com"}}))
+
+	err := client.ValidateResponseIssuer(url.Values{"iss": {"https://attacker.example.com"}})
+	mustEqual(t, errors.Is(err, ErrResponseIssuerMismatch), true)
+
+	err = client.ValidateResponseIssuer(url.Values{"iss": {"https://idp.example.com", "https://attacker.example.com"}})
+	mustEqual(t, errors.Is(err, ErrResponseIssuerMismatch), true)
+
+	// no issuer to compare with.
+	client = newClientWithConfig(Config{})
+	mustFail(t, client.ValidateResponseIssuer(url.Values{"iss": {"https://idp.example.com"}}))
+
+	client = newClientWithConfig(Config{Issuer: "https://idp.example.com", RequireResponseIssuer: true})
+	mustFail(t, client.ValidateResponseIssuer(url.Values{"code": {"CODE"}}))
+	mustOk(t, client.ValidateResponseIssuer(url.Values{"iss": {"https://idp.example.com"}}))
+}
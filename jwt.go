@@ -0,0 +1,112 @@
+package oauth2
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	_ "crypto/sha256" // register hash functions
+	_ "crypto/sha512"
+)
+
+var errMalformedJWT = errors.New("oauth2: malformed JWT")
+
+// jwtHeader is a header of a JWT.
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid,omitempty"`
+	Type      string `json:"typ,omitempty"`
+}
+
+// decodeJWT decodes a header and claims of a JWT without verifying it.
+func decodeJWT(raw string, claims interface{}) (*jwtHeader, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errMalformedJWT
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errMalformedJWT
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, errMalformedJWT
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errMalformedJWT
+	}
+	if err := json.Unmarshal(claimsJSON, claims); err != nil {
+		return nil, errMalformedJWT
+	}
+	return &header, nil
+}
+
+// algHash returns a hash function used by the JWS algorithm.
+func algHash(alg string) (crypto.Hash, bool) {
+	switch alg {
+	case "RS256", "ES256", "PS256", "HS256":
+		return crypto.SHA256, true
+	case "RS384", "ES384", "PS384", "HS384":
+		return crypto.SHA384, true
+	case "RS512", "ES512", "PS512", "HS512", "EdDSA":
+		return crypto.SHA512, true
+	default:
+		return 0, false
+	}
+}
+
+// leftHalfHash computes an OIDC hash claim (at_hash, c_hash): base64url of the left half of the hash.
+func leftHalfHash(alg, value string) (string, error) {
+	hash, ok := algHash(alg)
+	if !ok {
+		return "", errors.New("oauth2: unsupported JWT algorithm: " + alg)
+	}
+
+	h := hash.New()
+	h.Write([]byte(value))
+	sum := h.Sum(nil)
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2]), nil
+}
+
+// signJWT creates a JWT with the claims signed by the signer.
+func signJWT(ctx context.Context, signer Signer, claims interface{}) (string, error) {
+	return signTypedJWT(ctx, signer, "JWT", claims)
+}
+
+// signTypedJWT is signJWT with the `typ` header, like `at+jwt`.
+func signTypedJWT(ctx context.Context, signer Signer, typ string, claims interface{}) (string, error) {
+	// the active key might change between calls, header and signature must use the same one.
+	if as, ok := signer.(ActiveSigner); ok {
+		signer = as.Active()
+	}
+
+	header := jwtHeader{
+		Algorithm: signer.Algorithm(),
+		KeyID:     signer.KeyID(),
+		Type:      typ,
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	data := base64.RawURLEncoding.EncodeToString(headerJSON) + "." +
+		base64.RawURLEncoding.EncodeToString(claimsJSON)
+
+	sig, err := signer.Sign(ctx, []byte(data))
+	if err != nil {
+		return "", err
+	}
+	return data + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
@@ -0,0 +1,46 @@
+package oauth2
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+)
+
+func TestDecodeJWT(t *testing.T) {
+	raw := makeJWT(t, jwtHeader{Algorithm: "RS256", KeyID: "key-1"}, map[string]any{"sub": "user"})
+
+	var claims map[string]any
+	header, err := decodeJWT(raw, &claims)
+	mustOk(t, err)
+	mustEqual(t, header.Algorithm, "RS256")
+	mustEqual(t, header.KeyID, "key-1")
+	mustEqual(t, claims["sub"], "user")
+
+	for _, raw := range []string{"", "a.b", "a.b.c", "e30.!!!.c"} {
+		_, err := decodeJWT(raw, &claims)
+		mustEqual(t, err, errMalformedJWT)
+	}
+}
+
+func TestLeftHalfHash(t *testing.T) {
+	// example from OIDC Core, appendix A.4.
+	h, err := leftHalfHash("RS256", "Qcb0Orv1zh30vL1MPRsbm-diHiMwcLyZvn1arpZv-Jxf_11jnpEX3Tgfvk")
+	mustOk(t, err)
+	mustEqual(t, h, "LDktKdoQak3Pk0cnXxCltA")
+
+	_, err = leftHalfHash("none", "code")
+	mustFail(t, err)
+}
+
+// makeJWT returns a JWT with a fake signature.
+func makeJWT(tb testing.TB, header jwtHeader, claims any) string {
+	tb.Helper()
+
+	h, err := json.Marshal(header)
+	mustOk(tb, err)
+	c, err := json.Marshal(claims)
+	mustOk(tb, err)
+
+	return base64.RawURLEncoding.EncodeToString(h) + "." +
+		base64.RawURLEncoding.EncodeToString(c) + ".c2lnbmF0dXJl"
+}
@@ -0,0 +1,43 @@
+package oauth2
+
+import (
+	"strings"
+)
+
+// KeycloakConfig returns a Config with Keycloak endpoints of the realm.
+// Base URL is the Keycloak server URL, like `https://sso.example.com`
+// (older versions also need the `/auth` suffix).
+// Client fields must be set by the caller.
+func KeycloakConfig(baseURL, realm string) Config {
+	issuer := strings.TrimSuffix(baseURL, "/") + "/realms/" + realm
+	prefix := issuer + "/protocol/openid-connect"
+
+	return Config{
+		Issuer:           issuer,
+		AuthURL:          prefix + "/auth",
+		TokenURL:         prefix + "/token",
+		DeviceURL:        prefix + "/auth/device",
+		IntrospectionURL: prefix + "/token/introspect",
+		RevocationURL:    prefix + "/revoke",
+		UserInfoURL:      prefix + "/userinfo",
+	}
+}
+
+// KeycloakSessionState returns Keycloak's `session_state` of the token.
+func KeycloakSessionState(t *Token) string {
+	s, _ := t.Extra("session_state").(string)
+	return s
+}
+
+// KeycloakNotBeforePolicy returns Keycloak's `not-before-policy` of the token,
+// tokens issued before this Unix time are revoked by the realm.
+func KeycloakNotBeforePolicy(t *Token) int64 {
+	switch v := t.Extra("not-before-policy").(type) {
+	case float64:
+		return int64(v)
+	case int64:
+		return v
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,52 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestKeycloakConfig(t *testing.T) {
+	cfg := KeycloakConfig("https://sso.example.com/", "main")
+
+	mustEqual(t, cfg.Issuer, "https://sso.example.com/realms/main")
+	mustEqual(t, cfg.AuthURL, "https://sso.example.com/realms/main/protocol/openid-connect/auth")
+	mustEqual(t, cfg.TokenURL, "https://sso.example.com/realms/main/protocol/openid-connect/token")
+	mustEqual(t, cfg.DeviceURL, "https://sso.example.com/realms/main/protocol/openid-connect/auth/device")
+	mustEqual(t, cfg.IntrospectionURL, "https://sso.example.com/realms/main/protocol/openid-connect/token/introspect")
+	mustEqual(t, cfg.RevocationURL, "https://sso.example.com/realms/main/protocol/openid-connect/revoke")
+	mustEqual(t, cfg.UserInfoURL, "https://sso.example.com/realms/main/protocol/openid-connect/userinfo")
+	mustOk(t, cfg.Validate())
+}
+
+func TestKeycloakToken(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{
+			"access_token": "ACCESS_TOKEN",
+			"expires_in": 300,
+			"refresh_expires_in": 1800,
+			"refresh_token": "REFRESH_TOKEN",
+			"token_type": "Bearer",
+			"not-before-policy": 1700000000,
+			"session_state": "6b1d0e5e-6c8a-4b1e-9d4b-3f1a6b0c2d7e",
+			"scope": "profile email"
+		}`)
+	})
+	defer ts.Close()
+
+	cfg := KeycloakConfig(ts.URL, "main")
+	cfg.ClientID = "CLIENT_ID"
+	cfg.TokenURL = ts.URL
+
+	tok, err := newClientWithConfig(cfg).Exchange(context.Background(), "code")
+	mustOk(t, err)
+
+	mustEqual(t, KeycloakSessionState(tok), "6b1d0e5e-6c8a-4b1e-9d4b-3f1a6b0c2d7e")
+	mustEqual(t, KeycloakNotBeforePolicy(tok), int64(1700000000))
+
+	wantRefresh := time.Now().Add(30 * time.Minute)
+	mustEqual(t, tok.RefreshExpiry.Sub(wantRefresh).Abs() < time.Minute, true)
+}
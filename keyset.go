@@ -0,0 +1,408 @@
+package oauth2
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrJWTSignature is returned when a JWT signature cannot be verified with the keys of a KeySet.
+var ErrJWTSignature = errors.New("oauth2: invalid JWT signature")
+
+// keySetRefreshInterval limits how often KeySet refetches the JWKS for an unknown key ID
+// or after a failed fetch.
+const keySetRefreshInterval = time.Minute
+
+// accessTokenType is the `typ` header of JWT access tokens, RFC 9068 section 2.1.
+const accessTokenType = "at+jwt"
+
+// KeySet is a JSON Web Key Set of a provider fetched from its `jwks_uri`, RFC 7517 section 5.
+// Keys are fetched on the first use and refetched when a token is signed with an unknown key ID,
+// so key rotations are picked up. Fetches happen at most once a minute, also when they fail,
+// and concurrent verifications wait for the same fetch. It is safe for concurrent use.
+type KeySet struct {
+	client *http.Client
+	url    string
+
+	mu        sync.Mutex
+	keys      []jsonWebKey
+	fetchedAt time.Time     // fetchedAt is the time of the last fetch, successful or not.
+	fetchErr  error         // fetchErr is the error of the last fetch.
+	fetching  chan struct{} // fetching is closed when the fetch in flight is done, nil if there is none.
+}
+
+// NewKeySet returns a KeySet fetching keys from the JWKS URL.
+func NewKeySet(client *http.Client, jwksURL string) *KeySet {
+	ks := &KeySet{
+		client: client,
+		url:    jwksURL,
+	}
+	return ks
+}
+
+// KeySet returns a KeySet of the provider's `jwks_uri`.
+func (m *ProviderMetadata) KeySet(client *http.Client) *KeySet {
+	return NewKeySet(client, m.JWKSURI)
+}
+
+// ExpectedClaims are requirements of VerifyAccessToken.
+type ExpectedClaims struct {
+	Issuer   string        // Issuer is the required `iss`, required.
+	Audience string        // Audience must be one of `aud`, required.
+	Type     string        // Type is an optional required `typ` header, like `at+jwt` of RFC 9068.
+	Leeway   time.Duration // Leeway is an allowed clock skew for `exp`, `nbf` and `iat`.
+
+	_ struct{} // enforce explicit field names.
+}
+
+// AccessTokenClaims are verified claims of a JWT access token, RFC 9068 section 2.2.
+type AccessTokenClaims struct {
+	Issuer    string    // Issuer is `iss`.
+	Subject   string    // Subject is `sub`.
+	Audience  []string  // Audience is `aud`.
+	ClientID  string    // ClientID is `client_id`.
+	Scopes    []string  // Scopes are from `scope` or `scp`.
+	Expiry    time.Time // Expiry is `exp`.
+	IssuedAt  time.Time // IssuedAt is `iat`, zero if there is none.
+	NotBefore time.Time // NotBefore is `nbf`, zero if there is none.
+	JWTID     string    // JWTID is `jti`.
+
+	// Raw are all claims of the token.
+	Raw UnverifiedClaims
+}
+
+// VerifyAccessToken verifies the signature of a JWT access token with the key set and its claims:
+// the issuer and the audience must be the expected ones, `exp` is required. It's the single place
+// of access token verification for resource servers and client-side checks.
+func (ks *KeySet) VerifyAccessToken(ctx context.Context, raw string, expected ExpectedClaims) (*AccessTokenClaims, error) {
+	if expected.Issuer == "" || expected.Audience == "" {
+		return nil, errors.New("oauth2: expected issuer and audience are required")
+	}
+	return ks.verifyAccessToken(ctx, raw, []string{expected.Issuer}, []string{expected.Audience}, expected.Type, timeNow(), expected.Leeway)
+}
+
+// VerifyAccessToken verifies a JWT access token with the key set like KeySet.VerifyAccessToken,
+// accepting Config.Issuer and Config.ExpectedIssuers as issuers, and Config.ExpectedAudiences
+// or Config.Audience as audiences. The `typ` header must be Config.AccessTokenType, `at+jwt` by default,
+// so an ID token signed with the same key isn't accepted as an access token. Times are checked with a minute of leeway.
+func (c *Client) VerifyAccessToken(ctx context.Context, ks *KeySet, raw string) (*AccessTokenClaims, error) {
+	issuers := c.expectedIssuers()
+	audiences := c.config.ExpectedAudiences
+	if len(audiences) == 0 && c.config.Audience != "" {
+		audiences = []string{c.config.Audience}
+	}
+	if len(issuers) == 0 || len(audiences) == 0 {
+		return nil, errors.New("oauth2: expected issuers and audiences are not set")
+	}
+	typ := c.config.AccessTokenType
+	if typ == "" {
+		typ = accessTokenType
+	}
+	return ks.verifyAccessToken(ctx, raw, issuers, audiences, typ, c.now(), jwtLeeway)
+}
+
+func (ks *KeySet) verifyAccessToken(ctx context.Context, raw string, issuers, audiences []string, typ string, now time.Time, leeway time.Duration) (*AccessTokenClaims, error) {
+	claims, err := ks.verifyJWT(ctx, raw, typ)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &AccessTokenClaims{
+		Issuer:    claims.Issuer(),
+		Subject:   claims.Subject(),
+		Audience:  claims.Audience(),
+		ClientID:  claims.string("client_id"),
+		Scopes:    claims.Scopes(),
+		Expiry:    claims.Expiry(),
+		IssuedAt:  claims.time("iat"),
+		NotBefore: claims.time("nbf"),
+		JWTID:     claims.string("jti"),
+		Raw:       claims,
+	}
+	if err := checkIssuer(c.Issuer, issuers); err != nil {
+		return nil, err
+	}
+	if err := checkAudience(c.Audience, audiences); err != nil {
+		return nil, err
+	}
+	if err := checkJWTTimes(claims, now, leeway); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// verifyJWT decodes the JWT, checks the optional `typ` header and verifies the signature.
+func (ks *KeySet) verifyJWT(ctx context.Context, raw, typ string) (UnverifiedClaims, error) {
+	var claims UnverifiedClaims
+	header, err := decodeJWT(raw, &claims)
+	if err != nil {
+		return nil, err
+	}
+	if claims == nil {
+		return nil, errMalformedJWT
+	}
+	if typ != "" && !strings.EqualFold(strings.TrimPrefix(header.Type, "application/"), typ) {
+		return nil, fmt.Errorf("oauth2: JWT type %q is not %q", header.Type, typ)
+	}
+	if err := ks.verifySignature(ctx, raw, header); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// jwtLeeway is an allowed clock skew for `exp`, `nbf` and `iat` of tokens verified by Client,
+// CompensateClockSkew handles larger skews.
+const jwtLeeway = time.Minute
+
+// checkJWTTimes checks the required `exp` and the optional `nbf` and `iat` claims at the time in the issuer's clock.
+func checkJWTTimes(claims UnverifiedClaims, now time.Time, leeway time.Duration) error {
+	exp, nbf, iat := claims.Expiry(), claims.time("nbf"), claims.time("iat")
+
+	switch {
+	case exp.IsZero():
+		return errors.New("oauth2: JWT has no exp claim")
+	case !now.Before(exp.Add(leeway)):
+		return errors.New("oauth2: JWT has expired")
+	case !nbf.IsZero() && now.Add(leeway).Before(nbf):
+		return errors.New("oauth2: JWT is not valid yet")
+	case !iat.IsZero() && now.Add(leeway).Before(iat):
+		return errors.New("oauth2: JWT is issued in the future")
+	}
+	return nil
+}
+
+// verifySignature verifies the JWS signature with a key matching the header.
+func (ks *KeySet) verifySignature(ctx context.Context, raw string, header *jwtHeader) error {
+	i := strings.LastIndexByte(raw, '.')
+	data, sigPart := raw[:i], raw[i+1:]
+	sig, err := base64.RawURLEncoding.DecodeString(sigPart)
+	if err != nil {
+		return errMalformedJWT
+	}
+
+	keys, err := ks.keysFor(ctx, header)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if verifyJWS(header.Algorithm, key.public, []byte(data), sig) {
+			return nil
+		}
+	}
+	return ErrJWTSignature
+}
+
+// keysFor returns keys matching the key ID of the header, all keys if there is none.
+// The key set is refetched if no key matches.
+func (ks *KeySet) keysFor(ctx context.Context, header *jwtHeader) ([]jsonWebKey, error) {
+	ks.mu.Lock()
+	if len(ks.match(header.KeyID)) == 0 && timeNow().Sub(ks.fetchedAt) >= keySetRefreshInterval {
+		ks.mu.Unlock()
+		if err := ks.refetch(ctx); err != nil {
+			return nil, err
+		}
+		ks.mu.Lock()
+	}
+	keys, fetchErr := ks.match(header.KeyID), ks.fetchErr
+	ks.mu.Unlock()
+
+	switch {
+	case len(keys) > 0:
+		return keys, nil
+	case fetchErr != nil:
+		return nil, fetchErr
+	default:
+		return nil, fmt.Errorf("oauth2: no key %q in the key set", header.KeyID)
+	}
+}
+
+// refetch fetches the key set outside of the lock, concurrent callers wait for the same fetch.
+func (ks *KeySet) refetch(ctx context.Context) error {
+	ks.mu.Lock()
+	if done := ks.fetching; done != nil {
+		ks.mu.Unlock()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	done := make(chan struct{})
+	ks.fetching = done
+	ks.mu.Unlock()
+
+	keys, err := ks.fetch(ctx)
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if err == nil {
+		ks.keys = keys
+	}
+	// a fetch cancelled by the caller says nothing about the endpoint.
+	if ctx.Err() == nil {
+		ks.fetchedAt = timeNow()
+		ks.fetchErr = err
+	}
+	ks.fetching = nil
+	close(done)
+	return ctx.Err()
+}
+
+func (ks *KeySet) match(kid string) []jsonWebKey {
+	var keys []jsonWebKey
+	for _, key := range ks.keys {
+		if kid == "" || key.kid == kid {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (ks *KeySet) fetch(ctx context.Context) ([]jsonWebKey, error) {
+	var set struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			KeyID   string `json:"kid"`
+			Use     string `json:"use"`
+			Curve   string `json:"crv"`
+			N       string `json:"n"`
+			E       string `json:"e"`
+			X       string `json:"x"`
+			Y       string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := getJSON(ctx, ks.client, ks.url, &set); err != nil {
+		return nil, fmt.Errorf("oauth2: cannot fetch key set: %w", err)
+	}
+
+	keys := make([]jsonWebKey, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := parseJWK(k.KeyType, k.Curve, k.N, k.E, k.X, k.Y)
+		if err != nil {
+			continue // unsupported keys are skipped.
+		}
+		keys = append(keys, jsonWebKey{kid: k.KeyID, public: pub})
+	}
+	return keys, nil
+}
+
+// jsonWebKey is a parsed public key of a KeySet.
+type jsonWebKey struct {
+	kid    string
+	public crypto.PublicKey
+}
+
+// parseJWK parses RSA, EC and OKP (Ed25519) public keys, RFC 7518 section 6 and RFC 8037.
+func parseJWK(kty, crv, n, e, x, y string) (crypto.PublicKey, error) {
+	b64 := base64.RawURLEncoding
+
+	switch kty {
+	case "RSA":
+		nb, err := b64.DecodeString(n)
+		if err != nil {
+			return nil, err
+		}
+		eb, err := b64.DecodeString(e)
+		if err != nil || len(eb) > 4 {
+			return nil, errors.New("oauth2: malformed RSA exponent")
+		}
+		exp := new(big.Int).SetBytes(eb)
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(nb), E: int(exp.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("oauth2: unsupported curve %q", crv)
+		}
+		xb, err := b64.DecodeString(x)
+		if err != nil {
+			return nil, err
+		}
+		yb, err := b64.DecodeString(y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(xb), Y: new(big.Int).SetBytes(yb)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("oauth2: EC point is not on the curve")
+		}
+		return pub, nil
+
+	case "OKP":
+		xb, err := b64.DecodeString(x)
+		if err != nil || crv != "Ed25519" || len(xb) != ed25519.PublicKeySize {
+			return nil, errors.New("oauth2: malformed Ed25519 key")
+		}
+		return ed25519.PublicKey(xb), nil
+
+	default:
+		return nil, fmt.Errorf("oauth2: unsupported key type %q", kty)
+	}
+}
+
+// verifyJWS verifies a JWS signature, the key type and size must match the algorithm.
+func verifyJWS(alg string, key crypto.PublicKey, data, sig []byte) bool {
+	if alg == "EdDSA" {
+		pub, ok := key.(ed25519.PublicKey)
+		return ok && ed25519.Verify(pub, data, sig)
+	}
+
+	hash, ok := algHash(alg)
+	if !ok {
+		return false // including `none`.
+	}
+	h := hash.New()
+	h.Write(data)
+	digest := h.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		switch alg[0] {
+		case 'R':
+			return rsa.VerifyPKCS1v15(pub, hash, digest, sig) == nil
+		case 'P':
+			opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto, Hash: hash}
+			return rsa.VerifyPSS(pub, hash, digest, sig, opts) == nil
+		}
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if alg[0] != 'E' || len(sig) != 2*size || hash.Size()*8 != ecdsaHashBits(pub.Curve) {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(pub, digest, r, s)
+	}
+	return false
+}
+
+// ecdsaHashBits returns the hash size of the JWS algorithm for the curve: ES256 for P-256 and so on.
+func ecdsaHashBits(curve elliptic.Curve) int {
+	if bits := curve.Params().BitSize; bits != 521 {
+		return bits
+	}
+	return 512
+}
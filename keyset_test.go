@@ -0,0 +1,200 @@
+package oauth2
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeySet_VerifyAccessToken(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	mustOk(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	mustOk(t, err)
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	mustOk(t, err)
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	var fetches int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"keys": [
+			{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": %q, "e": %q},
+			{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": %q, "y": %q},
+			{"kty": "OKP", "kid": "ed-1", "crv": "Ed25519", "x": %q},
+			{"kty": "RSA", "kid": "enc-1", "use": "enc", "n": %q, "e": %q}
+		]}`,
+			b64(rsaKey.N.Bytes()), b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+			b64(ecKey.X.FillBytes(make([]byte, 32))), b64(ecKey.Y.FillBytes(make([]byte, 32))),
+			b64(edPub),
+			b64(rsaKey.N.Bytes()), b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+		)
+	})
+	defer ts.Close()
+
+	now := time.Now()
+	claims := map[string]any{
+		"iss":       "https://issuer.example.com",
+		"sub":       "user",
+		"aud":       []string{"api", "other"},
+		"client_id": "CLIENT_ID",
+		"scope":     "read write",
+		"exp":       now.Add(time.Hour).Unix(),
+		"iat":       now.Unix(),
+	}
+	expected := ExpectedClaims{Issuer: "https://issuer.example.com", Audience: "api"}
+
+	ks := NewKeySet(http.DefaultClient, ts.URL)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		key crypto.Signer
+		alg string
+		kid string
+	}{
+		{rsaKey, "RS256", "rsa-1"},
+		{rsaKey, "PS384", "rsa-1"},
+		{ecKey, "ES256", "ec-1"},
+		{edKey, "EdDSA", "ed-1"},
+	} {
+		signer, err := NewSigner(tc.key, tc.alg, tc.kid)
+		mustOk(t, err)
+		raw, err := signJWT(ctx, signer, claims)
+		mustOk(t, err)
+
+		c, err := ks.VerifyAccessToken(ctx, raw, expected)
+		mustOk(t, err)
+		mustEqual(t, c.Subject, "user")
+		mustEqual(t, c.ClientID, "CLIENT_ID")
+		mustEqual(t, c.Audience, []string{"api", "other"})
+		mustEqual(t, c.Scopes, []string{"read", "write"})
+		mustEqual(t, c.Expiry.Unix(), now.Add(time.Hour).Unix())
+	}
+	mustEqual(t, fetches, 1)
+
+	sign := func(kid string, claims map[string]any) string {
+		signer, err := NewSigner(rsaKey, "RS256", kid)
+		mustOk(t, err)
+		raw, err := signJWT(ctx, signer, claims)
+		mustOk(t, err)
+		return raw
+	}
+	with := func(key string, value any) map[string]any {
+		c := map[string]any{}
+		for k, v := range claims {
+			c[k] = v
+		}
+		c[key] = value
+		return c
+	}
+
+	_, err = ks.VerifyAccessToken(ctx, sign("rsa-1", with("iss", "https://evil.example.com")), expected)
+	mustFail(t, err)
+	_, err = ks.VerifyAccessToken(ctx, sign("rsa-1", with("aud", "web")), expected)
+	mustFail(t, err)
+	_, err = ks.VerifyAccessToken(ctx, sign("rsa-1", with("exp", now.Add(-time.Minute).Unix())), expected)
+	mustFail(t, err)
+	_, err = ks.VerifyAccessToken(ctx, sign("rsa-1", claims), ExpectedClaims{Issuer: expected.Issuer, Audience: "api", Type: "at+jwt"})
+	mustFail(t, err)
+
+	// the key for encryption is not used for signatures.
+	_, err = ks.VerifyAccessToken(ctx, sign("enc-1", claims), expected)
+	mustFail(t, err)
+
+	// signed by another key with a known key ID.
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	mustOk(t, err)
+	signer, err := NewSigner(otherKey, "RS256", "rsa-1")
+	mustOk(t, err)
+	forged, err := signJWT(ctx, signer, claims)
+	mustOk(t, err)
+	_, err = ks.VerifyAccessToken(ctx, forged, expected)
+	mustEqual(t, errors.Is(err, ErrJWTSignature), true)
+	mustEqual(t, fetches, 1)
+}
+
+func TestKeySet_Refetch(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	var fetches int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		fmt.Fprint(w, `{"keys": []}`)
+	})
+	defer ts.Close()
+
+	ks := NewKeySet(http.DefaultClient, ts.URL)
+	raw := makeJWT(t, jwtHeader{Algorithm: "RS256", KeyID: "new"}, map[string]any{"sub": "user"})
+	expected := ExpectedClaims{Issuer: "https://issuer.example.com", Audience: "api"}
+
+	_, err := ks.VerifyAccessToken(context.Background(), raw, expected)
+	mustFail(t, err)
+	_, err = ks.VerifyAccessToken(context.Background(), raw, expected)
+	mustFail(t, err)
+	mustEqual(t, fetches, 1)
+
+	now = now.Add(keySetRefreshInterval)
+	_, err = ks.VerifyAccessToken(context.Background(), raw, expected)
+	mustFail(t, err)
+	mustEqual(t, fetches, 2)
+}
+
+func TestKeySet_FailedFetch(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		<-release
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer ts.Close()
+
+	ks := NewKeySet(http.DefaultClient, ts.URL)
+	raw := makeJWT(t, jwtHeader{Algorithm: "RS256", KeyID: "k1"}, map[string]any{"sub": "user"})
+	expected := ExpectedClaims{Issuer: "https://issuer.example.com", Audience: "api"}
+
+	// concurrent verifications wait for the same fetch.
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := ks.VerifyAccessToken(context.Background(), raw, expected)
+			mustFail(t, err)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	mustEqual(t, fetches.Load(), int32(1))
+
+	// a failed fetch isn't repeated for every token.
+	_, err := ks.VerifyAccessToken(context.Background(), raw, expected)
+	mustFail(t, err)
+	mustEqual(t, fetches.Load(), int32(1))
+
+	now = now.Add(keySetRefreshInterval)
+	_, err = ks.VerifyAccessToken(context.Background(), raw, expected)
+	mustFail(t, err)
+	mustEqual(t, fetches.Load(), int32(2))
+}
@@ -0,0 +1,63 @@
+package oauth2
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// FrontChannelLogout is a front-channel logout request, OIDC Front-Channel Logout 1.0 section 2.
+type FrontChannelLogout struct {
+	Issuer    string // Issuer is the `iss` parameter, empty if the provider doesn't send it.
+	SessionID string // SessionID is the `sid` parameter, empty if the provider doesn't send it.
+}
+
+// ParseFrontChannelLogout parses and validates a front-channel logout GET request.
+// When the provider sends `iss` and `sid`, both must be present
+// and `iss` must match Config.Issuer, if it's set.
+func (c *Client) ParseFrontChannelLogout(r *http.Request) (*FrontChannelLogout, error) {
+	if r.Method != http.MethodGet {
+		return nil, fmt.Errorf("oauth2: logout request must be GET, got %s", r.Method)
+	}
+
+	q := r.URL.Query()
+	l := &FrontChannelLogout{
+		Issuer:    q.Get("iss"),
+		SessionID: q.Get("sid"),
+	}
+
+	switch {
+	case l.Issuer == "" && l.SessionID == "":
+		return l, nil
+	case l.Issuer == "":
+		return nil, errors.New("oauth2: logout request missing iss")
+	case l.SessionID == "":
+		return nil, errors.New("oauth2: logout request missing sid")
+	case c.config.Issuer != "" && l.Issuer != c.config.Issuer:
+		return nil, fmt.Errorf("oauth2: logout request issuer %q doesn't match %q", l.Issuer, c.config.Issuer)
+	}
+	return l, nil
+}
+
+// FrontChannelLogoutHandler returns a handler of front-channel logout requests
+// which calls logout for valid requests. Logout should end the local session of the user.
+//
+// The handler responds with 400 to invalid requests and with 500 if logout fails.
+func (c *Client) FrontChannelLogoutHandler(logout func(r *http.Request, l *FrontChannelLogout) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the response is rendered in an iframe and must not be cached, section 2.
+		w.Header().Set("Cache-Control", "no-cache, no-store")
+		w.Header().Set("Pragma", "no-cache")
+
+		l, err := c.ParseFrontChannelLogout(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := logout(r, l); err != nil {
+			http.Error(w, "logout failed", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
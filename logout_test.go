@@ -0,0 +1,66 @@
+package oauth2
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseFrontChannelLogout(t *testing.T) {
+	client := newClientWithConfig(Config{Issuer: "https://op.example.com"})
+
+	testCases := []struct {
+		method  string
+		target  string
+		want    *FrontChannelLogout
+		wantErr bool
+	}{
+		{http.MethodGet, "/logout", &FrontChannelLogout{}, false},
+		{http.MethodGet, "/logout?iss=https://op.example.com&sid=s1", &FrontChannelLogout{Issuer: "https://op.example.com", SessionID: "s1"}, false},
+		{http.MethodGet, "/logout?iss=https://op.example.com", nil, true},
+		{http.MethodGet, "/logout?sid=s1", nil, true},
+		{http.MethodGet, "/logout?iss=https://evil.example.com&sid=s1", nil, true},
+		{http.MethodPost, "/logout", nil, true},
+	}
+
+	for _, tc := range testCases {
+		l, err := client.ParseFrontChannelLogout(httptest.NewRequest(tc.method, tc.target, nil))
+		if tc.wantErr {
+			mustFail(t, err)
+			continue
+		}
+		mustOk(t, err)
+		mustEqual(t, l, tc.want)
+	}
+}
+
+func TestFrontChannelLogoutHandler(t *testing.T) {
+	client := newClientWithConfig(Config{Issuer: "https://op.example.com"})
+
+	var loggedOut []string
+	h := client.FrontChannelLogoutHandler(func(r *http.Request, l *FrontChannelLogout) error {
+		if l.SessionID == "broken" {
+			return errors.New("session store is down")
+		}
+		loggedOut = append(loggedOut, l.SessionID)
+		return nil
+	})
+
+	testCases := []struct {
+		target string
+		want   int
+	}{
+		{"/logout?iss=https://op.example.com&sid=s1", http.StatusOK},
+		{"/logout?iss=https://evil.example.com&sid=s2", http.StatusBadRequest},
+		{"/logout?iss=https://op.example.com&sid=broken", http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.target, nil))
+		mustEqual(t, w.Code, tc.want)
+		mustEqual(t, w.Header().Get("Cache-Control"), "no-cache, no-store")
+	}
+	mustEqual(t, loggedOut, []string{"s1"})
+}
@@ -0,0 +1,186 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// TokenManagerConfig describes how TokenManager keeps tokens.
+type TokenManagerConfig struct {
+	Store   TokenStore    // Store keeps tokens of all keys, required.
+	Locker  Locker        // Locker is an optional lock taken before refreshing a token.
+	MaxIdle time.Duration // MaxIdle evicts token sources not used for this duration, zero means never.
+
+	_ struct{} // enforce explicit field names.
+}
+
+// TokenManager maps arbitrary keys (like user IDs) to token sources backed by a TokenStore.
+// Refreshes are serialized per key. It is safe for concurrent use.
+type TokenManager struct {
+	client *Client
+	config TokenManagerConfig
+
+	mu      sync.Mutex
+	sources map[string]*managedSource
+}
+
+type managedSource struct {
+	ts       *TokenSource
+	lastUsed time.Time
+
+	// mu serializes store writes of the source with Save and Delete. A replaced source is an older
+	// generation of the key, its writes are dropped, so a refresh in flight doesn't bring back
+	// a token deleted or replaced since.
+	mu       sync.Mutex
+	replaced bool
+}
+
+// NewTokenManager instantiates a new token manager with a given client and config.
+func NewTokenManager(client *Client, config TokenManagerConfig) (*TokenManager, error) {
+	if config.Store == nil {
+		return nil, errors.New("oauth2: token store is not set")
+	}
+
+	m := &TokenManager{
+		client:  client,
+		config:  config,
+		sources: make(map[string]*managedSource),
+	}
+	return m, nil
+}
+
+// Token returns a valid token for the key, refreshing it if needed.
+func (m *TokenManager) Token(ctx context.Context, key string) (*Token, error) {
+	return m.source(key).Token(ctx)
+}
+
+// Save stores a token for the key, for example after an authorization code exchange.
+func (m *TokenManager) Save(ctx context.Context, key string, token *Token) error {
+	m.replace(key)
+	if err := m.config.Store.Save(ctx, key, token); err != nil {
+		return err
+	}
+	// a source created meanwhile might have loaded the previous token.
+	m.replace(key)
+	return nil
+}
+
+// Delete removes a token for the key from the store.
+func (m *TokenManager) Delete(ctx context.Context, key string) error {
+	m.replace(key)
+	if err := m.config.Store.Delete(ctx, key); err != nil {
+		return err
+	}
+	m.replace(key)
+	return nil
+}
+
+// Evict drops a cached token source for the key, the token stays in the store.
+// A refresh in flight for the key still saves its token, rotated refresh tokens aren't lost.
+func (m *TokenManager) Evict(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.evict(key)
+}
+
+// replace evicts the token source for the key and drops its further writes,
+// the token in the store is about to be replaced or deleted.
+func (m *TokenManager) replace(key string) {
+	m.mu.Lock()
+	s, ok := m.sources[key]
+	m.evict(key)
+	m.mu.Unlock()
+
+	if ok {
+		// wait for a write in progress, later ones are dropped.
+		s.mu.Lock()
+		s.replaced = true
+		s.mu.Unlock()
+	}
+}
+
+// evict drops the token source for the key, m.mu must be held.
+func (m *TokenManager) evict(key string) {
+	delete(m.sources, key)
+}
+
+func (m *TokenManager) source(key string) *TokenSource {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := timeNow()
+
+	s, ok := m.sources[key]
+	if !ok {
+		m.evictIdle(now)
+
+		s = &managedSource{}
+		s.ts = NewTokenSource(m.client, nil, TokenSourceConfig{
+			Key:    key,
+			Locker: m.config.Locker,
+			Store:  &sourceStore{store: m.config.Store, source: s},
+		})
+		m.sources[key] = s
+	}
+	s.lastUsed = now
+	return s.ts
+}
+
+func (m *TokenManager) evictIdle(now time.Time) {
+	if m.config.MaxIdle == 0 {
+		return
+	}
+	for key, s := range m.sources {
+		if now.Sub(s.lastUsed) > m.config.MaxIdle {
+			m.evict(key)
+		}
+	}
+}
+
+// sourceStore is the TokenStore of a managed source, it drops writes once the source is replaced.
+// Writes of an evicted source go through, refreshes use Rotate when the store supports it.
+type sourceStore struct {
+	store  TokenStore
+	source *managedSource
+}
+
+func (s *sourceStore) Load(ctx context.Context, key string) (*Token, error) {
+	return s.store.Load(ctx, key)
+}
+
+func (s *sourceStore) Save(ctx context.Context, key string, token *Token) error {
+	return s.write(func() error {
+		return s.store.Save(ctx, key, token)
+	})
+}
+
+func (s *sourceStore) Delete(ctx context.Context, key string) error {
+	return s.write(func() error {
+		return s.store.Delete(ctx, key)
+	})
+}
+
+// Rotate implements the TokenRotator interface, the store is used with Save
+// if it doesn't implement TokenRotator.
+func (s *sourceStore) Rotate(ctx context.Context, key, refreshToken string, token *Token) error {
+	return s.write(func() error {
+		rotator, ok := s.store.(TokenRotator)
+		if !ok {
+			return s.store.Save(ctx, key, token)
+		}
+		return rotator.Rotate(ctx, key, refreshToken, token)
+	})
+}
+
+func (s *sourceStore) write(fn func() error) error {
+	s.source.mu.Lock()
+	defer s.source.mu.Unlock()
+
+	if s.source.replaced {
+		return nil
+	}
+	return fn()
+}
@@ -0,0 +1,131 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTokenManager(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "NEW_%s", "expires_in": 3600}`, r.FormValue("refresh_token"))
+	})
+	defer ts.Close()
+
+	store := &memStore{tokens: map[string]*Token{}}
+	m, err := NewTokenManager(newClient(ts.URL), TokenManagerConfig{Store: store})
+	mustOk(t, err)
+
+	ctx := context.Background()
+	_, err = m.Token(ctx, "user-1")
+	mustFail(t, err)
+
+	mustOk(t, m.Save(ctx, "user-1", &Token{AccessToken: "A1", RefreshToken: "R1", Expiry: time.Now().Add(-time.Hour)}))
+	mustOk(t, m.Save(ctx, "user-2", &Token{AccessToken: "A2", RefreshToken: "R2", Expiry: time.Now().Add(time.Hour)}))
+
+	tok, err := m.Token(ctx, "user-1")
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "NEW_R1")
+	mustEqual(t, store.tokens["user-1"].AccessToken, "NEW_R1")
+
+	tok, err = m.Token(ctx, "user-2")
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "A2")
+
+	mustOk(t, m.Delete(ctx, "user-2"))
+	_, err = store.Load(ctx, "user-2")
+	mustEqual(t, errors.Is(err, ErrTokenNotFound), true)
+}
+
+func TestTokenManager_MaxIdle(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	store := &memStore{tokens: map[string]*Token{
+		"user-1": {AccessToken: "A1"},
+		"user-2": {AccessToken: "A2"},
+	}}
+	m, err := NewTokenManager(newClient("http://localhost"), TokenManagerConfig{
+		Store:   store,
+		MaxIdle: time.Minute,
+	})
+	mustOk(t, err)
+
+	_, err = m.Token(context.Background(), "user-1")
+	mustOk(t, err)
+
+	now = now.Add(2 * time.Minute)
+	_, err = m.Token(context.Background(), "user-2")
+	mustOk(t, err)
+
+	mustEqual(t, len(m.sources), 1)
+}
+
+func TestTokenManager_NoStore(t *testing.T) {
+	_, err := NewTokenManager(newClient("http://localhost"), TokenManagerConfig{})
+	mustFail(t, err)
+}
+
+func TestTokenManager_EvictDuringRefresh(t *testing.T) {
+	testCases := []struct {
+		name    string
+		evict   func(m *TokenManager, ctx context.Context)
+		refresh string // refresh token in the store after the refresh, empty if deleted.
+	}{
+		{
+			name:    "evict",
+			evict:   func(m *TokenManager, ctx context.Context) { m.Evict("user-1") },
+			refresh: "NEW_REFRESH_TOKEN",
+		},
+		{
+			name:    "delete",
+			evict:   func(m *TokenManager, ctx context.Context) { mustOk(t, m.Delete(ctx, "user-1")) },
+			refresh: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			started := make(chan struct{})
+			stuck := make(chan struct{})
+			ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				<-stuck
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"access_token": "NEW_ACCESS_TOKEN", "refresh_token": "NEW_REFRESH_TOKEN", "expires_in": 3600}`)
+			})
+			defer ts.Close()
+
+			store := &memStore{tokens: map[string]*Token{
+				"user-1": {AccessToken: "A1", RefreshToken: "R1", Expiry: time.Now().Add(-time.Hour)},
+			}}
+			m, err := NewTokenManager(newClient(ts.URL), TokenManagerConfig{Store: store})
+			mustOk(t, err)
+
+			ctx := context.Background()
+			done := make(chan error)
+			go func() {
+				_, err := m.Token(ctx, "user-1")
+				done <- err
+			}()
+
+			<-started
+			tc.evict(m, ctx)
+			close(stuck)
+			mustOk(t, <-done)
+
+			tok, err := store.Load(ctx, "user-1")
+			if tc.refresh == "" {
+				mustEqual(t, errors.Is(err, ErrTokenNotFound), true)
+				return
+			}
+			mustOk(t, err)
+			mustEqual(t, tok.RefreshToken, tc.refresh)
+		})
+	}
+}
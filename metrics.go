@@ -0,0 +1,40 @@
+package oauth2
+
+import (
+	"time"
+)
+
+// Metrics receives measurements of Client, TokenSource and TokenCache, see Config.Metrics.
+// Methods are called synchronously and concurrently, implementations must be fast and safe for concurrent use.
+// See oauth2prom for a Prometheus implementation.
+type Metrics interface {
+	// TokenRequest is called after every token request with its grant type, error and duration.
+	TokenRequest(grantType string, err error, duration time.Duration)
+
+	// CacheLookup is called on every TokenCache.Token call, hit reports whether a cached token was used.
+	CacheLookup(hit bool)
+
+	// RefreshFailure is called when TokenSource fails to refresh a token.
+	RefreshFailure(err error)
+}
+
+// ModeMetrics is an optional interface of Metrics, implementations are notified about mode detection.
+type ModeMetrics interface {
+	// ModeDetected is called when AutoDetectMode settles on a mode or the detected mode changes.
+	ModeDetected(mode Mode)
+}
+
+// UsageMetrics is an optional interface of Metrics, implementations are notified about token uses
+// of TokenSource with TokenSourceConfig.TrackUsage.
+type UsageMetrics interface {
+	// TokenUsed is called when TokenSource returns a token, cached reports whether it was served
+	// without a token request, to estimate the savings of caching.
+	TokenUsed(cached bool)
+}
+
+// BreakerMetrics is an optional interface of Metrics, implementations are notified about
+// state changes of the circuit breaker, see Config.BreakerThreshold.
+type BreakerMetrics interface {
+	// BreakerStateChanged is called when the circuit breaker moves to a new state.
+	BreakerStateChanged(state BreakerState)
+}
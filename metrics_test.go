@@ -0,0 +1,57 @@
+package oauth2
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	m := &metricsRecorder{}
+	client := newClientWithConfig(Config{ClientID: "CLIENT_ID", Metrics: m})
+
+	token := &Token{RefreshToken: "REFRESH_TOKEN", RefreshExpiry: time.Now().Add(-time.Minute)}
+	_, err := NewTokenSource(client, token, TokenSourceConfig{}).Token(context.Background())
+	mustEqual(t, err, ErrRefreshTokenExpired)
+	mustEqual(t, m.refreshFailures, 1)
+
+	// no token endpoint, the request fails.
+	_, err = client.ClientCredentialsToken(context.Background())
+	mustFail(t, err)
+	mustEqual(t, m.requests, []string{"client_credentials"})
+}
+
+type metricsRecorder struct {
+	requests        []string
+	cacheHits       int
+	refreshFailures int
+	detectedModes   []Mode
+	uses            []bool
+	breakerStates   []BreakerState
+}
+
+func (m *metricsRecorder) BreakerStateChanged(state BreakerState) {
+	m.breakerStates = append(m.breakerStates, state)
+}
+
+func (m *metricsRecorder) TokenUsed(cached bool) {
+	m.uses = append(m.uses, cached)
+}
+
+func (m *metricsRecorder) TokenRequest(grantType string, err error, duration time.Duration) {
+	m.requests = append(m.requests, grantType)
+}
+
+func (m *metricsRecorder) CacheLookup(hit bool) {
+	if hit {
+		m.cacheHits++
+	}
+}
+
+func (m *metricsRecorder) RefreshFailure(err error) {
+	m.refreshFailures++
+}
+
+func (m *metricsRecorder) ModeDetected(mode Mode) {
+	m.detectedModes = append(m.detectedModes, mode)
+}
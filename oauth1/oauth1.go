@@ -0,0 +1,321 @@
+// Package oauth1 implements OAuth 1.0a (RFC 5849) for legacy providers:
+// request signing with HMAC-SHA1 or RSA-SHA1 and the 3-legged token acquisition.
+//
+// The API mirrors the oauth2 package, Client obtains tokens and Wrap returns
+// an http.Client signing every request with the token.
+package oauth1
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config describes a 3-legged OAuth 1.0a flow.
+type Config struct {
+	ConsumerKey     string          // ConsumerKey is the application's key.
+	ConsumerSecret  string          // ConsumerSecret is the application's secret, used for HMAC-SHA1.
+	PrivateKey      *rsa.PrivateKey // PrivateKey switches signing to RSA-SHA1, optional.
+	RequestTokenURL string          // RequestTokenURL is a URL for temporary credentials.
+	AuthorizeURL    string          // AuthorizeURL is a URL for the user authorization.
+	AccessTokenURL  string          // AccessTokenURL is a URL for token credentials.
+	CallbackURL     string          // CallbackURL is the URL to redirect users to, `oob` if not set.
+
+	_ struct{} // enforce explicit field names.
+}
+
+// Token represents OAuth 1.0a credentials, temporary or token credentials.
+type Token struct {
+	Token  string // Token is `oauth_token`.
+	Secret string // Secret is `oauth_token_secret`.
+}
+
+// Client represents an OAuth 1.0a HTTP client.
+type Client struct {
+	client *http.Client
+	config Config
+}
+
+// NewClient instantiates a new client with a given config.
+func NewClient(client *http.Client, config Config) *Client {
+	c := &Client{
+		client: client,
+		config: config,
+	}
+	return c
+}
+
+// RequestToken obtains temporary credentials, RFC 5849 section 2.1.
+func (c *Client) RequestToken(ctx context.Context) (*Token, error) {
+	callback := c.config.CallbackURL
+	if callback == "" {
+		callback = "oob"
+	}
+
+	values, err := c.postToken(ctx, c.config.RequestTokenURL, nil, map[string]string{"oauth_callback": callback})
+	if err != nil {
+		return nil, err
+	}
+	if values.Get("oauth_callback_confirmed") != "true" {
+		return nil, errors.New("oauth1: callback was not confirmed")
+	}
+	return tokenFromValues(values)
+}
+
+// AuthorizationURL returns a URL to the provider's page to authorize the temporary credentials.
+func (c *Client) AuthorizationURL(requestToken *Token) string {
+	sep := "?"
+	if strings.Contains(c.config.AuthorizeURL, "?") {
+		sep = "&"
+	}
+	return c.config.AuthorizeURL + sep + "oauth_token=" + url.QueryEscape(requestToken.Token)
+}
+
+// AccessToken exchanges the authorized temporary credentials and the verifier
+// (`oauth_verifier` from the callback) for token credentials, RFC 5849 section 2.3.
+func (c *Client) AccessToken(ctx context.Context, requestToken *Token, verifier string) (*Token, error) {
+	values, err := c.postToken(ctx, c.config.AccessTokenURL, requestToken, map[string]string{"oauth_verifier": verifier})
+	if err != nil {
+		return nil, err
+	}
+	return tokenFromValues(values)
+}
+
+// Wrap returns an http.Client signing every request with the token.
+func (c *Client) Wrap(token *Token, client *http.Client) (*http.Client, error) {
+	if token == nil {
+		return nil, errors.New("oauth1: token is not set")
+	}
+
+	transport := http.DefaultTransport
+	if client.Transport != nil {
+		transport = client.Transport
+	}
+
+	wrapped := &http.Client{
+		Transport: &signingTransport{
+			client:    c,
+			token:     token,
+			transport: transport,
+		},
+	}
+	return wrapped, nil
+}
+
+func (c *Client) postToken(ctx context.Context, endpoint string, token *Token, extra map[string]string) (url.Values, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.sign(req, token, extra); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("oauth1: cannot fetch token: %v\nResponse: %s", resp.Status, body)
+	}
+	return url.ParseQuery(string(body))
+}
+
+func tokenFromValues(values url.Values) (*Token, error) {
+	t := &Token{
+		Token:  values.Get("oauth_token"),
+		Secret: values.Get("oauth_token_secret"),
+	}
+	if t.Token == "" {
+		return nil, errors.New("oauth1: response missing oauth_token")
+	}
+	return t, nil
+}
+
+type signingTransport struct {
+	client    *Client
+	token     *Token
+	transport http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req2 := req.Clone(req.Context())
+	if err := t.client.sign(req2, t.token, nil); err != nil {
+		return nil, err
+	}
+	return t.transport.RoundTrip(req2)
+}
+
+var (
+	timeNow     = time.Now
+	randomNonce = func() (string, error) {
+		b := make([]byte, 24)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		return base64.RawURLEncoding.EncodeToString(b), nil
+	}
+)
+
+// sign sets the Authorization header of the request, RFC 5849 section 3.
+// A form body is read for the signature and restored.
+func (c *Client) sign(req *http.Request, token *Token, extra map[string]string) error {
+	nonce, err := randomNonce()
+	if err != nil {
+		return err
+	}
+
+	oauthParams := map[string]string{
+		"oauth_consumer_key":     c.config.ConsumerKey,
+		"oauth_nonce":            nonce,
+		"oauth_signature_method": c.signatureMethod(),
+		"oauth_timestamp":        strconv.FormatInt(timeNow().Unix(), 10),
+		"oauth_version":          "1.0",
+	}
+	if token != nil {
+		oauthParams["oauth_token"] = token.Token
+	}
+	for k, v := range extra {
+		oauthParams[k] = v
+	}
+
+	params := req.URL.Query()
+	if req.Body != nil && req.Body != http.NoBody &&
+		strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return err
+		}
+		for k, vs := range form {
+			params[k] = append(params[k], vs...)
+		}
+	}
+	for k, v := range oauthParams {
+		params.Set(k, v)
+	}
+
+	var secret string
+	if token != nil {
+		secret = token.Secret
+	}
+	signature, err := c.signature(signatureBase(req.Method, req.URL, params), secret)
+	if err != nil {
+		return err
+	}
+	oauthParams["oauth_signature"] = signature
+
+	keys := make([]string, 0, len(oauthParams))
+	for k := range oauthParams {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("OAuth ")
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(percentEncode(k) + `="` + percentEncode(oauthParams[k]) + `"`)
+	}
+	req.Header.Set("Authorization", b.String())
+	return nil
+}
+
+func (c *Client) signatureMethod() string {
+	if c.config.PrivateKey != nil {
+		return "RSA-SHA1"
+	}
+	return "HMAC-SHA1"
+}
+
+func (c *Client) signature(base, tokenSecret string) (string, error) {
+	if c.config.PrivateKey != nil {
+		digest := sha1.Sum([]byte(base))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, c.config.PrivateKey, crypto.SHA1, digest[:])
+		if err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(sig), nil
+	}
+
+	mac := hmac.New(sha1.New, []byte(percentEncode(c.config.ConsumerSecret)+"&"+percentEncode(tokenSecret)))
+	mac.Write([]byte(base))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// signatureBase returns the signature base string, RFC 5849 section 3.4.1.
+func signatureBase(method string, u *url.URL, params url.Values) string {
+	type pair struct{ k, v string }
+	encoded := make([]pair, 0, len(params))
+	for k, vs := range params {
+		for _, v := range vs {
+			encoded = append(encoded, pair{percentEncode(k), percentEncode(v)})
+		}
+	}
+	sort.Slice(encoded, func(i, j int) bool {
+		if encoded[i].k != encoded[j].k {
+			return encoded[i].k < encoded[j].k
+		}
+		return encoded[i].v < encoded[j].v
+	})
+
+	pairs := make([]string, len(encoded))
+	for i, p := range encoded {
+		pairs[i] = p.k + "=" + p.v
+	}
+
+	baseURL := strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host) + u.EscapedPath()
+	switch {
+	case u.Scheme == "http" && strings.HasSuffix(u.Host, ":80"):
+		baseURL = strings.Replace(baseURL, ":80/", "/", 1)
+	case u.Scheme == "https" && strings.HasSuffix(u.Host, ":443"):
+		baseURL = strings.Replace(baseURL, ":443/", "/", 1)
+	}
+
+	return strings.ToUpper(method) + "&" + percentEncode(baseURL) + "&" + percentEncode(strings.Join(pairs, "&"))
+}
+
+// percentEncode encodes the string as RFC 5849 section 3.6 requires, only unreserved characters are kept.
+func percentEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,172 @@
+package oauth1
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSignature uses the example of Twitter API documentation, `Creating a signature`.
+func TestSignature(t *testing.T) {
+	withFixedNonce(t, "kYjzVBB8Y0ZFabxSWbWovY3uYSQ2pTgmZeNu2VS4cg", 1318622958)
+
+	c := NewClient(http.DefaultClient, Config{
+		ConsumerKey:    "xvz1evFS4wEEPTGEFPHBog",
+		ConsumerSecret: "kAcSOqF21Fu85e7zjz7ZN2U4ZRhfV3WpwPAoE3Z7kBw",
+	})
+	token := &Token{
+		Token:  "370773112-GmHxMAgYyLbNEtIKZeRNFsMKPR9EyMZeS9weJAEb",
+		Secret: "LswwdoUaIvS8ltyTt5jkRh4J50vUPVVHtR2YPi5kE",
+	}
+
+	body := "status=" + url.QueryEscape("Hello Ladies + Gentlemen, a signed OAuth request!")
+	req, err := http.NewRequest(http.MethodPost, "https://api.twitter.com/1.1/statuses/update.json?include_entities=true", strings.NewReader(body))
+	mustOk(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	mustOk(t, c.sign(req, token, nil))
+
+	auth := req.Header.Get("Authorization")
+	mustEqual(t, strings.Contains(auth, `oauth_signature="hCtSmYh%2BiHYCEqBWrE7C7hYmtUk%3D"`), true)
+	mustEqual(t, strings.HasPrefix(auth, `OAuth oauth_consumer_key="xvz1evFS4wEEPTGEFPHBog", oauth_nonce=`), true)
+
+	// the body is kept for the request.
+	b := make([]byte, len(body))
+	_, err = req.Body.Read(b)
+	mustOk(t, err)
+	mustEqual(t, string(b), body)
+}
+
+func TestSignatureBase(t *testing.T) {
+	u, err := url.Parse("HTTP://Example.com:80/r%20v/X?id=123")
+	mustOk(t, err)
+
+	params := url.Values{"a": {"2"}, "a1": {"1"}, "c": {"x y", "w"}}
+	mustEqual(t, signatureBase("get", u, params), "GET&http%3A%2F%2Fexample.com%2Fr%2520v%2FX&a%3D2%26a1%3D1%26c%3Dw%26c%3Dx%2520y")
+}
+
+func TestFlow(t *testing.T) {
+	withFixedNonce(t, "NONCE", 1700000000)
+
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := parseAuthorization(t, r.Header.Get("Authorization"))
+		mustEqual(t, params["oauth_consumer_key"], "CONSUMER_KEY")
+
+		switch r.URL.Path {
+		case "/request_token":
+			mustEqual(t, params["oauth_callback"], "https://app.example.com/callback")
+			fmt.Fprint(w, "oauth_token=REQUEST_TOKEN&oauth_token_secret=REQUEST_SECRET&oauth_callback_confirmed=true")
+		case "/access_token":
+			mustEqual(t, params["oauth_token"], "REQUEST_TOKEN")
+			mustEqual(t, params["oauth_verifier"], "VERIFIER")
+			fmt.Fprint(w, "oauth_token=ACCESS_TOKEN&oauth_token_secret=ACCESS_SECRET")
+		case "/api":
+			mustEqual(t, params["oauth_token"], "ACCESS_TOKEN")
+			fmt.Fprint(w, "OK")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	c := NewClient(http.DefaultClient, Config{
+		ConsumerKey:     "CONSUMER_KEY",
+		ConsumerSecret:  "CONSUMER_SECRET",
+		RequestTokenURL: ts.URL + "/request_token",
+		AuthorizeURL:    ts.URL + "/authorize",
+		AccessTokenURL:  ts.URL + "/access_token",
+		CallbackURL:     "https://app.example.com/callback",
+	})
+	ctx := context.Background()
+
+	requestToken, err := c.RequestToken(ctx)
+	mustOk(t, err)
+	mustEqual(t, requestToken, &Token{Token: "REQUEST_TOKEN", Secret: "REQUEST_SECRET"})
+	mustEqual(t, c.AuthorizationURL(requestToken), ts.URL+"/authorize?oauth_token=REQUEST_TOKEN")
+
+	accessToken, err := c.AccessToken(ctx, requestToken, "VERIFIER")
+	mustOk(t, err)
+	mustEqual(t, accessToken, &Token{Token: "ACCESS_TOKEN", Secret: "ACCESS_SECRET"})
+
+	client, err := c.Wrap(accessToken, http.DefaultClient)
+	mustOk(t, err)
+
+	resp, err := client.Get(ts.URL + "/api")
+	mustOk(t, err)
+	resp.Body.Close()
+	mustEqual(t, resp.StatusCode, http.StatusOK)
+}
+
+func TestRSASignature(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	mustOk(t, err)
+
+	c := NewClient(http.DefaultClient, Config{ConsumerKey: "CONSUMER_KEY", PrivateKey: key})
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/api?x=1", http.NoBody)
+	mustOk(t, err)
+	mustOk(t, c.sign(req, &Token{Token: "TOKEN"}, nil))
+
+	params := parseAuthorization(t, req.Header.Get("Authorization"))
+	mustEqual(t, params["oauth_signature_method"], "RSA-SHA1")
+
+	query := url.Values{"x": {"1"}}
+	for k, v := range params {
+		if k != "oauth_signature" {
+			query.Set(k, v)
+		}
+	}
+	digest := sha1.Sum([]byte(signatureBase(req.Method, req.URL, query)))
+	sig, err := base64.StdEncoding.DecodeString(params["oauth_signature"])
+	mustOk(t, err)
+	mustOk(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA1, digest[:], sig))
+}
+
+func withFixedNonce(t *testing.T, nonce string, unix int64) {
+	timeNow = func() time.Time { return time.Unix(unix, 0) }
+	randomNonce = func() (string, error) { return nonce, nil }
+	t.Cleanup(func() {
+		timeNow = time.Now
+		randomNonce = defaultRandomNonce
+	})
+}
+
+var defaultRandomNonce = randomNonce
+
+func parseAuthorization(t *testing.T, header string) map[string]string {
+	t.Helper()
+	params := map[string]string{}
+	for _, part := range strings.Split(strings.TrimPrefix(header, "OAuth "), ", ") {
+		k, v, _ := strings.Cut(part, "=")
+		v, err := url.PathUnescape(strings.Trim(v, `"`))
+		mustOk(t, err)
+		params[k] = v
+	}
+	return params
+}
+
+func mustOk(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func mustEqual[T any](tb testing.TB, have, want T) {
+	tb.Helper()
+	if !reflect.DeepEqual(have, want) {
+		tb.Fatalf("\nhave: %+v\nwant: %+v\n", have, want)
+	}
+}
@@ -1,14 +1,192 @@
 package oauth2
 
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // Config describes a 3-legged OAuth2 flow.
 type Config struct {
-	ClientID     string   // ClientID is the application's ID.
-	ClientSecret string   // ClientSecret is the application's secret.
-	AuthURL      string   // AuthURL is a URL for authentication.
-	TokenURL     string   // TokenURL is a URL for retrieving a token.
-	Mode         Mode     // Mode represents how tokens are represented in requests.
-	RedirectURL  string   // RedirectURL is the URL to redirect users going through the OAuth flow.
-	Scopes       []string // Scope specifies optional requested permissions.
+	ClientID         string         // ClientID is the application's ID.
+	ClientSecret     string         // ClientSecret is the application's secret.
+	SecretProvider   SecretProvider // SecretProvider optionally supplies ClientSecret per request, see SecretProvider.
+	Issuer           string         // Issuer is an optional OIDC issuer identifier of the provider.
+	AuthURL          string         // AuthURL is a URL for authentication.
+	PushedAuthURL    string         // PushedAuthURL is a URL for pushed authorization requests, RFC 9126.
+	TokenURL         string         // TokenURL is a URL for retrieving a token.
+	DeviceURL        string         // DeviceURL is a URL for device authorization, RFC 8628.
+	IntrospectionURL string         // IntrospectionURL is a URL for token introspection, RFC 7662.
+	RevocationURL    string         // RevocationURL is a URL for token revocation, RFC 7009.
+	UserInfoURL      string         // UserInfoURL is a URL of the OIDC UserInfo endpoint.
+	Mode             Mode           // Mode represents how tokens are represented in requests.
+	Signer           Signer         // Signer signs client assertions for PrivateKeyJWTMode.
+
+	// AllowInsecureEndpoints allows http endpoints on non-loopback hosts, for tests only. See Config.Validate.
+	AllowInsecureEndpoints bool
+
+	// RawBasicAuth sends the client ID and secret in the Basic Authorization header as is (RFC 7617),
+	// instead of form-encoding them first as RFC 6749 section 2.3.1 requires. Some providers expect it.
+	RawBasicAuth bool
+
+	// ExpectedIssuers are accepted issuers in addition to Issuer, like tenant-specific issuers
+	// of a multi-tenant provider. They're checked by ValidateResponseIssuer, VerifyIDToken and VerifyAccessToken.
+	ExpectedIssuers []string
+
+	// ExpectedAudiences are accepted audiences of access tokens verified by VerifyAccessToken,
+	// Audience is used if empty. ID tokens are always checked against ClientID.
+	ExpectedAudiences []string
+
+	// AccessTokenType is the required `typ` header of access tokens verified by VerifyAccessToken,
+	// `at+jwt` of RFC 9068 by default. Set it for providers issuing access tokens with another type, like `JWT`.
+	AccessTokenType string
+
+	// RequireResponseIssuer makes ValidateResponseIssuer reject authorization responses without `iss`,
+	// set it for providers advertising `authorization_response_iss_parameter_supported`, RFC 9207.
+	RequireResponseIssuer bool
+
+	// IntrospectionMode optionally overrides Mode for IntrospectToken,
+	// InHeaderMode is used when both are AutoDetectMode.
+	IntrospectionMode Mode
+
+	// IntrospectionToken optionally supplies a bearer token authenticating IntrospectToken instead of the client,
+	// for providers requiring a dedicated introspection credential, RFC 7662 section 2.1.
+	IntrospectionToken TokenProvider
+
+	RedirectURL string   // RedirectURL is the URL to redirect users going through the OAuth flow.
+	Scopes      []string // Scope specifies optional requested permissions.
+	Audience    string   // Audience is an optional target API of tokens, sent as `audience` in token requests.
+
+	// ReuseClientAssertion caches a client assertion for PrivateKeyJWTMode until it's close to expiry
+	// instead of signing a new one per request. Don't use it with providers that reject replayed `jti`.
+	ReuseClientAssertion bool
+
+	// ScopeSeparator joins scopes in requests instead of a space, like `,` for providers
+	// not following RFC 6749 section 3.3. Granted scopes are split by it for RejectScopeDowngrade.
+	ScopeSeparator string
+
+	// RejectScopeDowngrade makes token requests fail with *ScopeDowngradeError
+	// when the provider grants fewer scopes than requested.
+	RejectScopeDowngrade bool
+
+	// AssumeExpiryIfMissing is a lifetime given to tokens returned without `expires_in`,
+	// so they're refreshed periodically instead of being cached forever. Zero means no expiry.
+	AssumeExpiryIfMissing time.Duration
+
+	// ExpiresInCompat is a set of accepted non-standard forms of `expires_in`, DefaultExpiresInCompat if zero.
+	// StrictExpiresIn accepts only integer numbers instead and fails on anything else, for conformance testing.
+	ExpiresInCompat ExpiresInCompat
+	StrictExpiresIn bool
+
+	// MinExpiry and MaxExpiry clamp token lifetimes reported by the provider,
+	// to defend against absurd `expires_in` values. Zero means no limit.
+	MinExpiry time.Duration
+	MaxExpiry time.Duration
+
+	// CompensateClockSkew measures the skew between the local clock and the token endpoint
+	// from the `Date` response header, see Client.ClockSkew. The skew is applied to times compared
+	// with the provider's clock: `iat` and `exp` of client assertions and verified JWTs.
+	// Token expiry is always in the local clock, computed from `expires_in` on receipt.
+	// Useful for devices with drifting clocks.
+	CompensateClockSkew bool
+
+	// MaxAge is an optional maximum authentication age, sent as OIDC `max_age` in auth code URLs.
+	MaxAge time.Duration
+
+	// ACRValues are optional requested authentication context classes, sent as OIDC `acr_values` in auth code URLs.
+	ACRValues []string
+
+	// MaxAuthURLLength limits the length of auth code URLs, browsers and providers reject too long ones.
+	// Longer URLs fail with *AuthURLTooLongError, AuthCodeURLContext pushes them to PushedAuthURL instead.
+	// Zero means no limit.
+	MaxAuthURLLength int
+
+	// TLSConfig is an optional TLS configuration (custom CA bundles, client certificates) for token endpoint calls.
+	// When TLSConfig or Proxy is set, the client passed to NewClient is copied with an adjusted transport,
+	// this works only for clients with nil or *http.Transport transport.
+	TLSConfig *tls.Config
+
+	// Proxy is an optional proxy function for token endpoint calls, like http.ProxyURL.
+	Proxy func(*http.Request) (*url.URL, error)
+
+	// RequestTimeout limits every token endpoint call if the given context has no deadline.
+	// Zero means no limit.
+	RequestTimeout time.Duration
+
+	// MaxErrorBodySize limits how much of an error response is kept in RetrieveError.Body, 4 KiB by default.
+	// Token responses are limited separately.
+	MaxErrorBodySize int
+
+	// Methods optionally overrides the POST method of endpoints, keyed by URL (like TokenURL), for providers
+	// with GET endpoints. Parameters, including client credentials of InParamsMode, are sent in the query then.
+	// The UserInfo endpoint uses GET by default, as OIDC Core section 5.3.1 recommends.
+	Methods map[string]string
+
+	// UserInfoTokenPlacement is where the access token is sent to the UserInfo endpoint, in the header by default.
+	UserInfoTokenPlacement TokenPlacement
+
+	// ResponseError optionally detects errors in successful responses, for providers reporting errors
+	// in an envelope with a 200 status, see WeChatResponseError.
+	ResponseError func(resp *http.Response, body []byte) error
+
+	// ErrorCategories optionally maps error codes to categories of RetrieveError,
+	// overriding and extending the built-in catalog, see ErrorCategory.
+	ErrorCategories map[string]ErrorCategory
+
+	// BodyEncoder optionally encodes request bodies instead of a form, see BodyEncoder.
+	BodyEncoder BodyEncoder
+
+	// Header is optional extra headers of token endpoint requests,
+	// like `Accept` or `User-Agent` for providers requiring them.
+	Header http.Header
+
+	// CorrelationHeader is an optional header name (like `X-Request-Id`) set on token requests.
+	// The value is taken from ContextWithCorrelationID or from CorrelationID.
+	// The same header is looked up in error responses, see RetrieveError.
+	CorrelationHeader string
+
+	// CorrelationID generates a correlation ID when the context has none.
+	CorrelationID func(ctx context.Context) string
+
+	// BreakerThreshold is a number of consecutive token endpoint failures (network errors and 5xx)
+	// after which requests fail fast with ErrBreakerOpen. Zero disables the circuit breaker.
+	BreakerThreshold int
+
+	// BreakerCooldown is how long the circuit breaker stays open before a probe request is allowed.
+	BreakerCooldown time.Duration
+
+	// MaxRetries is how many times a token request failed with a network error, 5xx or 429 is retried.
+	// Zero disables retries.
+	MaxRetries int
+
+	// RetryBackoff is a delay before the first retry, doubled for every next one. Default is 100ms.
+	RetryBackoff time.Duration
+
+	// RetryBudget limits retries of all token requests of the Client, so an outage of the provider
+	// doesn't multiply the traffic by MaxRetries. It's a bucket of RetryBudget retries refilled
+	// by one every RetryBudgetInterval (default is 1s), when it's empty requests fail with RetryBudgetError.
+	// Zero means no limit.
+	RetryBudget int
+
+	// RetryBudgetInterval is how often a retry is added back to RetryBudget.
+	RetryBudgetInterval time.Duration
+
+	// HedgeDelay enables hedged token requests: when the token endpoint hasn't responded within HedgeDelay,
+	// a second request is sent and the first successful response is taken. Zero disables hedging.
+	// Single-use grants (authorization, device and CIBA codes) are never hedged, beware of providers
+	// invalidating a rotated refresh token when it's used twice.
+	HedgeDelay time.Duration
+
+	// AuditSink optionally receives an event for every token request, see AuditEvent.
+	AuditSink AuditSink
+
+	// Metrics optionally receives measurements of Client, TokenSource and TokenCache.
+	Metrics Metrics
 
 	_ struct{} // enforce explicit field names.
 }
@@ -27,4 +205,116 @@ const (
 	// InHeaderMode sends the `client_id` and `client_secret` using HTTP Basic Authorization.
 	// This is an optional style described in the OAuth2 RFC 6749 section 2.3.1.
 	InHeaderMode Mode = 2
+
+	// PrivateKeyJWTMode sends a `client_assertion` JWT signed by Config.Signer,
+	// see OIDC Core section 9 and RFC 7523.
+	PrivateKeyJWTMode Mode = 3
+)
+
+var modeNames = map[Mode]string{
+	AutoDetectMode:    "auto",
+	InParamsMode:      "client_secret_post",
+	InHeaderMode:      "client_secret_basic",
+	PrivateKeyJWTMode: "private_key_jwt",
+}
+
+// String returns a name of the mode, the same as the OIDC `token_endpoint_auth_method` if there is one.
+func (m Mode) String() string {
+	if name, ok := modeNames[m]; ok {
+		return name
+	}
+	return "Mode(" + strconv.Itoa(int(m)) + ")"
+}
+
+// ParseMode returns a mode by its name, see Mode.String.
+// The names `params` and `header` are accepted too, an empty name is AutoDetectMode.
+func ParseMode(s string) (Mode, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return AutoDetectMode, nil
+	case "params":
+		return InParamsMode, nil
+	case "header":
+		return InHeaderMode, nil
+	}
+	for m, name := range modeNames {
+		if strings.EqualFold(s, name) {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("oauth2: unknown mode %q", s)
+}
+
+// MarshalText implements encoding.TextMarshaler, the mode is encoded by its name.
+func (m Mode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, see ParseMode.
+func (m *Mode) UnmarshalText(text []byte) error {
+	mode, err := ParseMode(string(text))
+	if err != nil {
+		return err
+	}
+	*m = mode
+	return nil
+}
+
+// TokenPlacement is where an access token is sent to a resource, RFC 6750 section 2.
+type TokenPlacement int
+
+const (
+	// TokenInHeader sends the token in the Authorization header, RFC 6750 section 2.1.
+	TokenInHeader TokenPlacement = 0
+
+	// TokenInBody sends the token as a form-encoded `access_token` body parameter with POST, RFC 6750 section 2.2.
+	TokenInBody TokenPlacement = 1
+
+	// TokenInQuery sends the token as an `access_token` query parameter, RFC 6750 section 2.3.
+	// Avoid it if possible, URLs end up in logs.
+	TokenInQuery TokenPlacement = 2
 )
+
+type modeKey struct{}
+
+// ContextWithMode returns a context overriding Config.Mode for requests made with it,
+// for providers authenticating clients differently at different endpoints.
+// Unlike Config.Mode, a detected mode isn't remembered when the override is AutoDetectMode.
+func ContextWithMode(ctx context.Context, mode Mode) context.Context {
+	return context.WithValue(ctx, modeKey{}, mode)
+}
+
+type paramsKey struct{}
+
+// ContextWithParams returns a context with extra parameters of token requests made with it,
+// like tenant hints attached by a middleware. Parameters passed to the call take precedence,
+// parameters of nested contexts are merged with the inner ones taking precedence.
+func ContextWithParams(ctx context.Context, params url.Values) context.Context {
+	merged := cloneURLValues(params)
+	if outer, ok := ctx.Value(paramsKey{}).(url.Values); ok {
+		for k, v := range outer {
+			if _, ok := merged[k]; !ok {
+				merged[k] = v
+			}
+		}
+	}
+	return context.WithValue(ctx, paramsKey{}, merged)
+}
+
+// requestMode returns the mode of a request, def is used instead of AutoDetectMode
+// for endpoints without auto-detection.
+func (c *Client) requestMode(ctx context.Context, def Mode) Mode {
+	mode, ok := ctx.Value(modeKey{}).(Mode)
+	if !ok {
+		mode = c.mode()
+	}
+	if mode == AutoDetectMode {
+		return def
+	}
+	return mode
+}
+
+// SecretProvider returns a client secret, it's called for every token request instead of using Config.ClientSecret.
+// This allows keeping the secret in a secret manager (like Vault or AWS SSM) and rotating it without restarts,
+// implementations should cache the secret if fetching it is expensive. See oauth2vault for a Vault implementation.
+type SecretProvider func(ctx context.Context) (string, error)
@@ -61,6 +61,7 @@ func TestAuthCodeURL(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
+		tc.cfg.AllowInsecureEndpoints = true // scheme-less URLs for brevity.
 		client := NewClient(http.DefaultClient, tc.cfg)
 		url := client.AuthCodeURL(tc.state)
 		mustEqual(t, url, tc.want)
@@ -129,12 +130,46 @@ func TestAuthCodeURLWithParams(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
+		tc.cfg.AllowInsecureEndpoints = true // scheme-less URLs for brevity.
 		client := NewClient(http.DefaultClient, tc.cfg)
 		url := client.AuthCodeURLWithParams(tc.state, tc.params)
 		mustEqual(t, url, tc.want)
 	}
 }
 
+func TestBuildAuthCodeURL(t *testing.T) {
+	testCases := []struct {
+		authURL string
+		want    string
+	}{
+		{"https://example.com/auth?", "https://example.com/auth?client_id=CLIENT_ID&response_type=code&state=state"},
+		{"https://example.com/auth?a=1&b=2", "https://example.com/auth?a=1&b=2&client_id=CLIENT_ID&response_type=code&state=state"},
+	}
+
+	for _, tc := range testCases {
+		client := NewClient(http.DefaultClient, Config{ClientID: "CLIENT_ID", AuthURL: tc.authURL})
+		url, err := client.BuildAuthCodeURL("state", nil)
+		mustOk(t, err)
+		mustEqual(t, url, tc.want)
+	}
+}
+
+func TestBuildAuthCodeURL_Malformed(t *testing.T) {
+	testCases := []string{
+		"",
+		"https://example.com/auth#fragment",
+		"https://exa mple.com/auth",
+		"://example.com",
+	}
+
+	for _, authURL := range testCases {
+		client := NewClient(http.DefaultClient, Config{ClientID: "CLIENT_ID", AuthURL: authURL})
+		_, err := client.BuildAuthCodeURL("state", nil)
+		mustFail(t, err)
+		mustEqual(t, client.AuthCodeURL("state"), "")
+	}
+}
+
 func mustOk(tb testing.TB, err error) {
 	tb.Helper()
 	if err != nil {
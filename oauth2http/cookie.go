@@ -0,0 +1,178 @@
+// Package oauth2http implements web session helpers for the authorization code flow:
+// encrypted cookies and login and callback handlers.
+package oauth2http
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/cristalhq/oauth2"
+)
+
+// ErrInvalidCookie is returned when a cookie cannot be decrypted,
+// it was tampered with or encrypted with another key.
+var ErrInvalidCookie = errors.New("oauth2http: invalid cookie")
+
+// maxCookieSize is a cookie size supported by all browsers, RFC 6265 section 6.1.
+const maxCookieSize = 4096
+
+// CookieConfig describes cookies of CookieStore.
+// Cookies are always HttpOnly.
+type CookieConfig struct {
+	Name     string        // Name of the cookie, defaults to `oauth2_session`.
+	Path     string        // Path of the cookie, defaults to `/`.
+	Domain   string        // Domain of the cookie, defaults to the host of the request.
+	MaxAge   time.Duration // MaxAge of the cookie, zero means a session cookie.
+	SameSite http.SameSite // SameSite mode of the cookie, defaults to Lax.
+
+	// Insecure allows sending cookies over plain HTTP, for local development only.
+	Insecure bool
+
+	_ struct{} // enforce explicit field names.
+}
+
+// CookieStore keeps a token or a session reference in an encrypted cookie (AES-GCM).
+//
+// A token fits into a cookie only without Token.Raw, it's not stored.
+// For larger tokens store them on the server, for example with oauth2.TokenManager,
+// and keep only a session reference with SaveSession.
+type CookieStore struct {
+	aead   cipher.AEAD
+	config CookieConfig
+}
+
+// NewCookieStore instantiates a new cookie store, the key must be 16, 24 or 32 bytes
+// to select AES-128, AES-192 or AES-256.
+func NewCookieStore(key []byte, config CookieConfig) (*CookieStore, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Name == "" {
+		config.Name = "oauth2_session"
+	}
+	if config.Path == "" {
+		config.Path = "/"
+	}
+	if config.SameSite == 0 {
+		config.SameSite = http.SameSiteLaxMode
+	}
+
+	s := &CookieStore{
+		aead:   aead,
+		config: config,
+	}
+	return s, nil
+}
+
+// Save stores the token in the cookie.
+func (s *CookieStore) Save(w http.ResponseWriter, token *oauth2.Token) error {
+	t := *token
+	t.Raw = nil
+
+	data, err := json.Marshal(&t)
+	if err != nil {
+		return err
+	}
+	return s.set(w, s.config.Name, "token", data, s.config.MaxAge)
+}
+
+// Load returns the token from the cookie or oauth2.ErrTokenNotFound.
+func (s *CookieStore) Load(r *http.Request) (*oauth2.Token, error) {
+	data, err := s.get(r, s.config.Name, "token")
+	if err != nil {
+		return nil, err
+	}
+
+	var token oauth2.Token
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, ErrInvalidCookie
+	}
+	return &token, nil
+}
+
+// SaveSession stores a session reference in the cookie instead of a token.
+func (s *CookieStore) SaveSession(w http.ResponseWriter, sessionID string) error {
+	return s.set(w, s.config.Name, "session", []byte(sessionID), s.config.MaxAge)
+}
+
+// LoadSession returns the session reference from the cookie or oauth2.ErrTokenNotFound.
+func (s *CookieStore) LoadSession(r *http.Request) (string, error) {
+	data, err := s.get(r, s.config.Name, "session")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Delete removes the cookie.
+func (s *CookieStore) Delete(w http.ResponseWriter) {
+	s.delete(w, s.config.Name)
+}
+
+// set encrypts the value into the cookie, the name and the purpose are authenticated,
+// so a value cannot be moved to another cookie or used for another purpose.
+func (s *CookieStore) set(w http.ResponseWriter, name, purpose string, value []byte, maxAge time.Duration) error {
+	nonce := make([]byte, s.aead.NonceSize(), s.aead.NonceSize()+len(value)+s.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	sealed := s.aead.Seal(nonce, nonce, value, []byte(name+"|"+purpose))
+
+	c := s.cookie(name, base64.RawURLEncoding.EncodeToString(sealed), maxAge)
+	if len(c.String()) > maxCookieSize {
+		return errors.New("oauth2http: cookie is too large, store a session reference instead")
+	}
+	http.SetCookie(w, c)
+	return nil
+}
+
+func (s *CookieStore) get(r *http.Request, name, purpose string) ([]byte, error) {
+	c, err := r.Cookie(name)
+	if err != nil {
+		return nil, oauth2.ErrTokenNotFound
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(c.Value)
+	if err != nil || len(sealed) < s.aead.NonceSize() {
+		return nil, ErrInvalidCookie
+	}
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+
+	value, err := s.aead.Open(nil, nonce, ciphertext, []byte(name+"|"+purpose))
+	if err != nil {
+		return nil, ErrInvalidCookie
+	}
+	return value, nil
+}
+
+func (s *CookieStore) delete(w http.ResponseWriter, name string) {
+	c := s.cookie(name, "", 0)
+	c.MaxAge = -1
+	http.SetCookie(w, c)
+}
+
+func (s *CookieStore) cookie(name, value string, maxAge time.Duration) *http.Cookie {
+	c := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     s.config.Path,
+		Domain:   s.config.Domain,
+		MaxAge:   int(maxAge / time.Second),
+		Secure:   !s.config.Insecure,
+		HttpOnly: true,
+		SameSite: s.config.SameSite,
+	}
+	return c
+}
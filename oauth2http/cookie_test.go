@@ -0,0 +1,132 @@
+package oauth2http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cristalhq/oauth2"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestCookieStore(t *testing.T) {
+	store, err := NewCookieStore(testKey, CookieConfig{MaxAge: time.Hour})
+	mustOk(t, err)
+
+	_, err = store.Load(httptest.NewRequest(http.MethodGet, "/", nil))
+	mustEqual(t, errors.Is(err, oauth2.ErrTokenNotFound), true)
+
+	token := &oauth2.Token{
+		AccessToken:  "ACCESS_TOKEN",
+		RefreshToken: "REFRESH_TOKEN",
+		Expiry:       time.Now().Add(time.Hour).Round(0),
+		Raw:          map[string]interface{}{"id_token": "large"},
+	}
+	w := httptest.NewRecorder()
+	mustOk(t, store.Save(w, token))
+
+	c := w.Result().Cookies()[0]
+	mustEqual(t, c.Name, "oauth2_session")
+	mustEqual(t, c.Path, "/")
+	mustEqual(t, c.MaxAge, 3600)
+	mustEqual(t, c.Secure, true)
+	mustEqual(t, c.HttpOnly, true)
+	mustEqual(t, c.SameSite, http.SameSiteLaxMode)
+	mustEqual(t, strings.Contains(c.Value, "ACCESS_TOKEN"), false)
+
+	loaded, err := store.Load(requestWithCookies(w))
+	mustOk(t, err)
+	mustEqual(t, loaded.AccessToken, token.AccessToken)
+	mustEqual(t, loaded.RefreshToken, token.RefreshToken)
+	mustEqual(t, loaded.Expiry.Equal(token.Expiry), true)
+	mustEqual(t, loaded.Raw, nil)
+
+	// a session reference is not a token.
+	_, err = store.LoadSession(requestWithCookies(w))
+	mustEqual(t, err, ErrInvalidCookie)
+
+	w = httptest.NewRecorder()
+	store.Delete(w)
+	mustEqual(t, w.Result().Cookies()[0].MaxAge, -1)
+}
+
+func TestCookieStore_Session(t *testing.T) {
+	store, err := NewCookieStore(testKey, CookieConfig{Name: "sid", Insecure: true})
+	mustOk(t, err)
+
+	w := httptest.NewRecorder()
+	mustOk(t, store.SaveSession(w, "session-1"))
+	mustEqual(t, w.Result().Cookies()[0].Secure, false)
+
+	id, err := store.LoadSession(requestWithCookies(w))
+	mustOk(t, err)
+	mustEqual(t, id, "session-1")
+}
+
+func TestCookieStore_Tampered(t *testing.T) {
+	store, err := NewCookieStore(testKey, CookieConfig{})
+	mustOk(t, err)
+	other, err := NewCookieStore([]byte("another key 16by"), CookieConfig{})
+	mustOk(t, err)
+
+	w := httptest.NewRecorder()
+	mustOk(t, store.SaveSession(w, "session-1"))
+
+	_, err = other.LoadSession(requestWithCookies(w))
+	mustEqual(t, err, ErrInvalidCookie)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: "oauth2_session", Value: "garbage"})
+	_, err = store.LoadSession(r)
+	mustEqual(t, err, ErrInvalidCookie)
+}
+
+func TestCookieStore_TooLarge(t *testing.T) {
+	store, err := NewCookieStore(testKey, CookieConfig{})
+	mustOk(t, err)
+
+	token := &oauth2.Token{AccessToken: strings.Repeat("x", maxCookieSize)}
+	mustFail(t, store.Save(httptest.NewRecorder(), token))
+}
+
+func TestNewCookieStore_BadKey(t *testing.T) {
+	_, err := NewCookieStore([]byte("short"), CookieConfig{})
+	mustFail(t, err)
+}
+
+// requestWithCookies returns a request with cookies set by the response.
+func requestWithCookies(w *httptest.ResponseRecorder) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range w.Result().Cookies() {
+		if c.MaxAge >= 0 {
+			r.AddCookie(c)
+		}
+	}
+	return r
+}
+
+func mustOk(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func mustFail(tb testing.TB, err error) {
+	tb.Helper()
+	if err == nil {
+		tb.Fatal("want err, got nil")
+	}
+}
+
+func mustEqual[T any](tb testing.TB, have, want T) {
+	tb.Helper()
+	if !reflect.DeepEqual(have, want) {
+		tb.Fatalf("\nhave: %+v\nwant: %+v\n", have, want)
+	}
+}
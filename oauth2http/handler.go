@@ -0,0 +1,265 @@
+package oauth2http
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cristalhq/oauth2"
+)
+
+// stateTTL is how long a user has to complete the authorization.
+const stateTTL = 10 * time.Minute
+
+// HandlerConfig describes how Handler completes the authorization.
+type HandlerConfig struct {
+	// Cookies keeps the token unless OnToken is set, required.
+	Cookies *CookieStore
+
+	// States keeps pending authorizations between login and callback,
+	// defaults to a CookieStateStore on top of Cookies.
+	States StateStore
+
+	// OnToken is called with the token after a successful callback, for example
+	// to keep the token on the server and save a session reference with CookieStore.SaveSession.
+	// Defaults to saving the token with CookieStore.Save and redirecting to `/`.
+	OnToken func(w http.ResponseWriter, r *http.Request, token *oauth2.Token)
+
+	// DoubleSubmit additionally binds the state to a random CSRF cookie, which must be sent back
+	// with the callback (double-submit cookie). It protects apps behind caches and proxies
+	// that can replay callback query strings.
+	DoubleSubmit bool
+
+	// PKCE enables PKCE with the given policy, nil disables it.
+	PKCE *oauth2.PKCEPolicy
+
+	// Nonce sends an OIDC nonce with the authorization request
+	// and verifies it in the ID token of the token response.
+	Nonce bool
+
+	// Links keeps provider identities linked to users, required for LinkLogin.
+	Links LinkStore
+
+	// OnLink is called with the result of a callback started by LinkLogin instead of OnToken,
+	// required for LinkLogin.
+	OnLink func(w http.ResponseWriter, r *http.Request, result *LinkResult)
+
+	// OnError is called when the callback fails. Defaults to a plain text error response.
+	OnError func(w http.ResponseWriter, r *http.Request, err error)
+
+	_ struct{} // enforce explicit field names.
+}
+
+// Handler implements login and callback endpoints of the authorization code flow.
+type Handler struct {
+	client *oauth2.Client
+	config HandlerConfig
+}
+
+// NewHandler instantiates a new handler with a given client and config.
+func NewHandler(client *oauth2.Client, config HandlerConfig) (*Handler, error) {
+	if config.Cookies == nil {
+		return nil, errors.New("oauth2http: cookie store is not set")
+	}
+
+	if (config.Links == nil) != (config.OnLink == nil) {
+		return nil, errors.New("oauth2http: links and link callback must be set together")
+	}
+
+	if config.States == nil {
+		config.States = NewCookieStateStore(config.Cookies)
+	}
+
+	h := &Handler{
+		client: client,
+		config: config,
+	}
+	return h, nil
+}
+
+// Login redirects the user to the provider's consent page.
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	if err := h.login(w, r, &AuthState{}); err != nil {
+		h.fail(w, r, err)
+	}
+}
+
+func (h *Handler) login(w http.ResponseWriter, r *http.Request, data *AuthState) error {
+	state, err := randomString()
+	if err != nil {
+		return err
+	}
+	if h.config.DoubleSubmit {
+		csrf, err := randomString()
+		if err != nil {
+			return err
+		}
+		http.SetCookie(w, h.config.Cookies.cookie(h.csrfCookie(), csrf, stateTTL))
+		state += "." + csrf
+	}
+
+	params := url.Values{}
+
+	if h.config.PKCE != nil {
+		pkce, err := oauth2.NewPKCE(*h.config.PKCE)
+		if err != nil {
+			return err
+		}
+		data.CodeVerifier = pkce.Verifier
+		for k, v := range pkce.AuthParams() {
+			params[k] = v
+		}
+	}
+	if h.config.Nonce {
+		if data.Nonce, err = randomString(); err != nil {
+			return err
+		}
+		params.Set("nonce", data.Nonce)
+	}
+
+	authURL, err := h.client.BuildAuthCodeURL(state, params)
+	if err != nil {
+		return err
+	}
+	if err := h.config.States.Save(w, r, state, data, stateTTL); err != nil {
+		return err
+	}
+	http.Redirect(w, r, authURL, http.StatusFound)
+	return nil
+}
+
+// Callback verifies the state and exchanges the authorization code for a token.
+func (h *Handler) Callback(w http.ResponseWriter, r *http.Request) {
+	token, data, err := h.callback(w, r)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	if data.LinkTo != "" {
+		result, err := h.link(r, data.LinkTo, token)
+		if err != nil {
+			h.fail(w, r, err)
+			return
+		}
+		h.config.OnLink(w, r, result)
+		return
+	}
+
+	if h.config.OnToken != nil {
+		h.config.OnToken(w, r, token)
+		return
+	}
+	if err := h.config.Cookies.Save(w, token); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+func (h *Handler) callback(w http.ResponseWriter, r *http.Request) (*oauth2.Token, *AuthState, error) {
+	q := r.URL.Query()
+	if err := h.client.ValidateResponseIssuer(q); err != nil {
+		return nil, nil, err
+	}
+	if code := q.Get("error"); code != "" {
+		return nil, nil, fmt.Errorf("oauth2http: authorization failed: %s: %s", code, q.Get("error_description"))
+	}
+
+	state := q.Get("state")
+	if state == "" {
+		return nil, nil, errors.New("oauth2http: callback missing state")
+	}
+	data, err := h.config.States.Consume(w, r, state)
+	if err != nil {
+		return nil, nil, fmt.Errorf("oauth2http: state is unknown, expired or already used: %w", err)
+	}
+	if h.config.DoubleSubmit {
+		if err := h.checkCSRF(w, r, state); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	code := q.Get("code")
+	if code == "" {
+		return nil, nil, errors.New("oauth2http: callback missing code")
+	}
+
+	var params url.Values
+	if data.CodeVerifier != "" {
+		params = url.Values{"code_verifier": []string{data.CodeVerifier}}
+	}
+	token, err := h.client.ExchangeWithParams(r.Context(), code, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if data.Nonce != "" {
+		if err := checkNonce(token, data.Nonce); err != nil {
+			return nil, nil, err
+		}
+	}
+	return token, data, nil
+}
+
+// checkNonce verifies the `nonce` claim of the ID token.
+// The signature of the ID token is not verified, the token came directly from the token endpoint.
+func checkNonce(token *oauth2.Token, nonce string) error {
+	idToken, _ := token.Extra("id_token").(string)
+	if idToken == "" {
+		return errors.New("oauth2http: token response missing id_token")
+	}
+
+	var claims struct {
+		Nonce string `json:"nonce"`
+	}
+	if err := oauth2.IDTokenClaims(idToken, &claims); err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return errors.New("oauth2http: ID token nonce mismatch")
+	}
+	return nil
+}
+
+// checkCSRF verifies the CSRF cookie against the part of the state after the dot.
+func (h *Handler) checkCSRF(w http.ResponseWriter, r *http.Request, state string) error {
+	c, err := r.Cookie(h.csrfCookie())
+	h.config.Cookies.delete(w, h.csrfCookie())
+	if err != nil {
+		return errors.New("oauth2http: CSRF cookie is missing")
+	}
+
+	_, csrf, ok := strings.Cut(state, ".")
+	if !ok || subtle.ConstantTimeCompare([]byte(c.Value), []byte(csrf)) != 1 {
+		return errors.New("oauth2http: CSRF cookie mismatch")
+	}
+	return nil
+}
+
+func (h *Handler) csrfCookie() string {
+	return h.config.Cookies.config.Name + "_csrf"
+}
+
+func (h *Handler) fail(w http.ResponseWriter, r *http.Request, err error) {
+	if h.config.OnError != nil {
+		h.config.OnError(w, r, err)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+// randomString returns a random URL-safe string with 256 bits of entropy.
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,270 @@
+package oauth2http
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/cristalhq/oauth2"
+)
+
+func TestHandler(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mustEqual(t, r.FormValue("code"), "CODE")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN", "expires_in": 3600}`)
+	}))
+	defer ts.Close()
+
+	h := newTestHandler(t, ts.URL)
+
+	w := httptest.NewRecorder()
+	h.Login(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+	mustEqual(t, w.Code, http.StatusFound)
+
+	authURL, err := url.Parse(w.Header().Get("Location"))
+	mustOk(t, err)
+	state := authURL.Query().Get("state")
+	mustEqual(t, len(state), 43)
+
+	r := requestWithCookies(w)
+	r.URL.RawQuery = url.Values{"code": {"CODE"}, "state": {state}}.Encode()
+
+	w = httptest.NewRecorder()
+	h.Callback(w, r)
+	mustEqual(t, w.Code, http.StatusFound)
+	mustEqual(t, w.Header().Get("Location"), "/")
+
+	token, err := h.config.Cookies.Load(requestWithCookies(w))
+	mustOk(t, err)
+	mustEqual(t, token.AccessToken, "ACCESS_TOKEN")
+}
+
+func TestHandler_DoubleSubmit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN"}`)
+	}))
+	defer ts.Close()
+
+	h := newTestHandler(t, ts.URL)
+	h.config.DoubleSubmit = true
+
+	w := httptest.NewRecorder()
+	h.Login(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+
+	authURL, err := url.Parse(w.Header().Get("Location"))
+	mustOk(t, err)
+	query := url.Values{"code": {"CODE"}, "state": {authURL.Query().Get("state")}}.Encode()
+
+	// a replayed callback with the state cookie only.
+	r := httptest.NewRequest(http.MethodGet, "/callback?"+query, nil)
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "oauth2_session_state" {
+			r.AddCookie(c)
+		}
+	}
+	rw := httptest.NewRecorder()
+	h.Callback(rw, r)
+	mustEqual(t, rw.Code, http.StatusBadRequest)
+
+	r = requestWithCookies(w)
+	r.URL.RawQuery = query
+	rw = httptest.NewRecorder()
+	h.Callback(rw, r)
+	mustEqual(t, rw.Code, http.StatusFound)
+}
+
+func TestHandler_PKCE(t *testing.T) {
+	var challenge string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		mustEqual(t, base64.RawURLEncoding.EncodeToString(sum[:]), challenge)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN"}`)
+	}))
+	defer ts.Close()
+
+	h := newTestHandler(t, ts.URL)
+	h.config.PKCE = &oauth2.PKCEPolicy{}
+
+	w := httptest.NewRecorder()
+	h.Login(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+
+	authURL, err := url.Parse(w.Header().Get("Location"))
+	mustOk(t, err)
+	challenge = authURL.Query().Get("code_challenge")
+	mustEqual(t, authURL.Query().Get("code_challenge_method"), "S256")
+
+	r := requestWithCookies(w)
+	r.URL.RawQuery = url.Values{"code": {"CODE"}, "state": {authURL.Query().Get("state")}}.Encode()
+
+	rw := httptest.NewRecorder()
+	h.Callback(rw, r)
+	mustEqual(t, rw.Code, http.StatusFound)
+}
+
+func TestHandler_MemoryStatesAndNonce(t *testing.T) {
+	var nonce string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idToken := makeJWT(map[string]string{"nonce": nonce})
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "ACCESS_TOKEN", "id_token": %q}`, idToken)
+	}))
+	defer ts.Close()
+
+	h := newTestHandler(t, ts.URL)
+	h.config.States = NewMemoryStateStore(h.config.Cookies)
+	h.config.Nonce = true
+
+	w := httptest.NewRecorder()
+	h.Login(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+
+	authURL, err := url.Parse(w.Header().Get("Location"))
+	mustOk(t, err)
+	nonce = authURL.Query().Get("nonce")
+	r := requestWithCookies(w)
+	r.URL.RawQuery = url.Values{"code": {"CODE"}, "state": {authURL.Query().Get("state")}}.Encode()
+
+	// the callback in another browser, like a victim of login CSRF.
+	w = httptest.NewRecorder()
+	h.Callback(w, httptest.NewRequest(http.MethodGet, "/callback?"+r.URL.RawQuery, nil))
+	mustEqual(t, w.Code, http.StatusBadRequest)
+
+	w = httptest.NewRecorder()
+	h.Callback(w, r)
+	mustEqual(t, w.Code, http.StatusFound)
+
+	// a replay of the same callback.
+	w = httptest.NewRecorder()
+	h.Callback(w, r)
+	mustEqual(t, w.Code, http.StatusBadRequest)
+}
+
+func TestHandler_NonceMismatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idToken := makeJWT(map[string]string{"nonce": "forged"})
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "ACCESS_TOKEN", "id_token": %q}`, idToken)
+	}))
+	defer ts.Close()
+
+	h := newTestHandler(t, ts.URL)
+	h.config.States = NewMemoryStateStore(h.config.Cookies)
+	h.config.Nonce = true
+
+	w := httptest.NewRecorder()
+	h.Login(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+
+	authURL, err := url.Parse(w.Header().Get("Location"))
+	mustOk(t, err)
+	r := requestWithCookies(w)
+	r.URL.RawQuery = url.Values{"code": {"CODE"}, "state": {authURL.Query().Get("state")}}.Encode()
+
+	w = httptest.NewRecorder()
+	h.Callback(w, r)
+	mustEqual(t, w.Code, http.StatusBadRequest)
+}
+
+func TestHandler_BadState(t *testing.T) {
+	h := newTestHandler(t, "http://localhost")
+
+	w := httptest.NewRecorder()
+	h.Login(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+
+	r := requestWithCookies(w)
+	r.URL.RawQuery = url.Values{"code": {"CODE"}, "state": {"forged"}}.Encode()
+
+	w = httptest.NewRecorder()
+	h.Callback(w, r)
+	mustEqual(t, w.Code, http.StatusBadRequest)
+
+	// no state cookie at all.
+	w = httptest.NewRecorder()
+	h.Callback(w, httptest.NewRequest(http.MethodGet, "/callback?code=CODE&state=forged", nil))
+	mustEqual(t, w.Code, http.StatusBadRequest)
+}
+
+func TestHandler_ProviderError(t *testing.T) {
+	var got error
+	h := newTestHandler(t, "http://localhost")
+	h.config.OnError = func(w http.ResponseWriter, r *http.Request, err error) {
+		got = err
+		w.WriteHeader(http.StatusForbidden)
+	}
+
+	w := httptest.NewRecorder()
+	h.Callback(w, httptest.NewRequest(http.MethodGet, "/callback?error=access_denied", nil))
+	mustEqual(t, w.Code, http.StatusForbidden)
+	mustEqual(t, got.Error(), "oauth2http: authorization failed: access_denied: ")
+}
+
+func TestHandler_ResponseIssuerMismatch(t *testing.T) {
+	var got error
+	h := newTestHandler(t, "http://localhost")
+	h.client = oauth2.NewClient(http.DefaultClient, oauth2.Config{
+		Issuer:   "https://idp.example.com",
+		TokenURL: "http://localhost/token",
+	})
+	h.config.OnError = func(w http.ResponseWriter, r *http.Request, err error) {
+		got = err
+		w.WriteHeader(http.StatusForbidden)
+	}
+
+	w := httptest.NewRecorder()
+	h.Callback(w, httptest.NewRequest(http.MethodGet, "/callback?error=access_denied&iss=https%3A%2F%2Fattacker.example.com", nil))
+	mustEqual(t, w.Code, http.StatusForbidden)
+	mustEqual(t, errors.Is(got, oauth2.ErrResponseIssuerMismatch), true)
+}
+
+func TestHandler_InsecureAuthURL(t *testing.T) {
+	var got error
+	h := newTestHandler(t, "http://localhost")
+	h.client = oauth2.NewClient(http.DefaultClient, oauth2.Config{
+		ClientID: "CLIENT_ID",
+		AuthURL:  "http://idp.example.com/auth",
+		TokenURL: "https://idp.example.com/token",
+	})
+	h.config.OnError = func(w http.ResponseWriter, r *http.Request, err error) {
+		got = err
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	w := httptest.NewRecorder()
+	h.Login(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+	mustEqual(t, w.Code, http.StatusInternalServerError)
+	mustEqual(t, w.Header().Get("Location"), "")
+	mustEqual(t, len(w.Result().Cookies()), 0)
+	mustEqual(t, got != nil, true)
+}
+
+// makeJWT returns an unsigned JWT with the claims.
+func makeJWT(claims interface{}) string {
+	c, _ := json.Marshal(claims)
+	return "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString(c) + "."
+}
+
+func newTestHandler(t *testing.T, serverURL string) *Handler {
+	t.Helper()
+
+	cookies, err := NewCookieStore(testKey, CookieConfig{})
+	mustOk(t, err)
+
+	client := oauth2.NewClient(http.DefaultClient, oauth2.Config{
+		ClientID: "CLIENT_ID",
+		AuthURL:  serverURL + "/auth",
+		TokenURL: serverURL + "/token",
+		Mode:     oauth2.InParamsMode,
+	})
+
+	h, err := NewHandler(client, HandlerConfig{Cookies: cookies})
+	mustOk(t, err)
+	return h
+}
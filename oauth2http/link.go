@@ -0,0 +1,151 @@
+package oauth2http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/cristalhq/oauth2"
+)
+
+// Identity is a user at a provider, the `iss` and `sub` claims of the ID token.
+// A subject is unique only within its issuer, so both are compared.
+type Identity struct {
+	Issuer  string
+	Subject string
+}
+
+// LinkStatus is the outcome of linking a provider identity to a user.
+type LinkStatus int
+
+const (
+	// Linked means the identity was linked to the user.
+	Linked LinkStatus = iota + 1
+
+	// AlreadyLinked means the identity was linked to the user before.
+	AlreadyLinked
+
+	// LinkConflict means the identity is linked to another user, nothing was changed.
+	LinkConflict
+)
+
+func (s LinkStatus) String() string {
+	switch s {
+	case Linked:
+		return "linked"
+	case AlreadyLinked:
+		return "already-linked"
+	case LinkConflict:
+		return "conflict"
+	default:
+		return "unknown"
+	}
+}
+
+// LinkResult is the result of a callback started by Handler.LinkLogin.
+type LinkResult struct {
+	Status   LinkStatus    // Status is the outcome of linking.
+	UserID   string        // UserID is the user from the LinkLogin call.
+	Identity Identity      // Identity is the provider identity of the callback.
+	Owner    string        // Owner is the user the identity is linked to on LinkConflict.
+	Token    *oauth2.Token // Token is the token of the callback.
+}
+
+// LinkStore keeps provider identities linked to users.
+// Implementations must be safe for concurrent use.
+type LinkStore interface {
+	// LinkIfAbsent atomically links the identity to the user unless it's linked already.
+	// It returns the user the identity is linked to and whether it was linked by this call.
+	LinkIfAbsent(ctx context.Context, id Identity, userID string) (owner string, linked bool, err error)
+}
+
+var _ LinkStore = &MemoryLinkStore{}
+
+// MemoryLinkStore is an in-memory LinkStore, it's suitable for tests and a single instance only.
+type MemoryLinkStore struct {
+	mu    sync.Mutex
+	links map[Identity]string
+}
+
+// NewMemoryLinkStore instantiates a new in-memory link store.
+func NewMemoryLinkStore() *MemoryLinkStore {
+	s := &MemoryLinkStore{
+		links: make(map[Identity]string),
+	}
+	return s
+}
+
+// LinkIfAbsent implements the LinkStore interface.
+func (s *MemoryLinkStore) LinkIfAbsent(ctx context.Context, id Identity, userID string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if owner, ok := s.links[id]; ok {
+		return owner, false, nil
+	}
+	s.links[id] = userID
+	return userID, true, nil
+}
+
+// LinkLogin redirects the user to the provider's consent page to link the provider identity
+// to the user of an existing session, the userID must come from that session.
+// The callback calls HandlerConfig.OnLink with the result.
+func (h *Handler) LinkLogin(w http.ResponseWriter, r *http.Request, userID string) {
+	if err := h.linkLogin(w, r, userID); err != nil {
+		h.fail(w, r, err)
+	}
+}
+
+func (h *Handler) linkLogin(w http.ResponseWriter, r *http.Request, userID string) error {
+	switch {
+	case h.config.Links == nil:
+		return errors.New("oauth2http: link store is not set")
+	case userID == "":
+		return errors.New("oauth2http: user to link is empty")
+	}
+	return h.login(w, r, &AuthState{LinkTo: userID})
+}
+
+// link links the identity of the token's ID token to the user unless it's linked to another one.
+// The signature of the ID token is not verified, the token came directly from the token endpoint.
+func (h *Handler) link(r *http.Request, userID string, token *oauth2.Token) (*LinkResult, error) {
+	if h.config.Links == nil {
+		return nil, errors.New("oauth2http: link store is not set")
+	}
+
+	idToken, _ := token.Extra("id_token").(string)
+	if idToken == "" {
+		return nil, errors.New("oauth2http: token response missing id_token")
+	}
+	var claims struct {
+		Issuer  string `json:"iss"`
+		Subject string `json:"sub"`
+	}
+	if err := oauth2.IDTokenClaims(idToken, &claims); err != nil {
+		return nil, err
+	}
+	if claims.Issuer == "" || claims.Subject == "" {
+		return nil, errors.New("oauth2http: ID token missing iss or sub")
+	}
+
+	result := &LinkResult{
+		UserID:   userID,
+		Identity: Identity{Issuer: claims.Issuer, Subject: claims.Subject},
+		Token:    token,
+	}
+
+	owner, linked, err := h.config.Links.LinkIfAbsent(r.Context(), result.Identity, userID)
+	switch {
+	case err != nil:
+		return nil, err
+	case linked:
+		result.Status = Linked
+	case owner == userID:
+		result.Status = AlreadyLinked
+	default:
+		result.Status = LinkConflict
+		result.Owner = owner
+	}
+	return result, nil
+}
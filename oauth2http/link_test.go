@@ -0,0 +1,83 @@
+package oauth2http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHandler_LinkLogin(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idToken := makeJWT(map[string]string{"iss": "https://idp.example.com", "sub": "ext-1"})
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "ACCESS_TOKEN", "id_token": %q}`, idToken)
+	}))
+	defer ts.Close()
+
+	var result *LinkResult
+	h := newTestHandler(t, ts.URL)
+	h.config.States = NewMemoryStateStore(h.config.Cookies)
+	h.config.Links = NewMemoryLinkStore()
+	h.config.OnLink = func(w http.ResponseWriter, r *http.Request, res *LinkResult) {
+		result = res
+	}
+
+	link := func(userID string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.LinkLogin(w, httptest.NewRequest(http.MethodGet, "/link", nil), userID)
+		mustEqual(t, w.Code, http.StatusFound)
+
+		authURL, err := url.Parse(w.Header().Get("Location"))
+		mustOk(t, err)
+		r := requestWithCookies(w)
+		r.URL.RawQuery = url.Values{"code": {"CODE"}, "state": {authURL.Query().Get("state")}}.Encode()
+
+		result = nil
+		h.Callback(httptest.NewRecorder(), r)
+	}
+
+	link("user-1")
+	mustEqual(t, result.Status, Linked)
+	mustEqual(t, result.Identity, Identity{Issuer: "https://idp.example.com", Subject: "ext-1"})
+	mustEqual(t, result.Token.AccessToken, "ACCESS_TOKEN")
+
+	link("user-1")
+	mustEqual(t, result.Status, AlreadyLinked)
+
+	link("user-2")
+	mustEqual(t, result.Status, LinkConflict)
+	mustEqual(t, result.Owner, "user-1")
+	mustEqual(t, result.Status.String(), "conflict")
+
+	// the user to link is required.
+	w := httptest.NewRecorder()
+	h.LinkLogin(w, httptest.NewRequest(http.MethodGet, "/link", nil), "")
+	mustEqual(t, w.Code, http.StatusBadRequest)
+}
+
+func TestMemoryLinkStore_Concurrent(t *testing.T) {
+	s := NewMemoryLinkStore()
+	id := Identity{Issuer: "https://idp.example.com", Subject: "ext-1"}
+
+	var wg sync.WaitGroup
+	var linked atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, ok, err := s.LinkIfAbsent(context.Background(), id, fmt.Sprintf("user-%d", i))
+			mustOk(t, err)
+			if ok {
+				linked.Add(1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	mustEqual(t, linked.Load(), int32(1))
+}
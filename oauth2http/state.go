@@ -0,0 +1,163 @@
+package oauth2http
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrStateNotFound is returned by StateStore when the state is unknown, expired or already used.
+var ErrStateNotFound = errors.New("oauth2http: state not found")
+
+// AuthState is data of a pending authorization kept until the callback.
+type AuthState struct {
+	Nonce        string `json:"nonce,omitempty"`         // Nonce is an OIDC nonce sent with the authorization request.
+	CodeVerifier string `json:"code_verifier,omitempty"` // CodeVerifier is a PKCE code verifier.
+	LinkTo       string `json:"link_to,omitempty"`       // LinkTo is the user to link the provider identity to, see Handler.LinkLogin.
+}
+
+// StateStore keeps pending authorizations by state. A state can be consumed only once.
+// Implementations must be safe for concurrent use.
+type StateStore interface {
+	// Save keeps the data of the state for the ttl.
+	Save(w http.ResponseWriter, r *http.Request, state string, data *AuthState, ttl time.Duration) error
+
+	// Consume returns and removes the data of the state or returns ErrStateNotFound.
+	Consume(w http.ResponseWriter, r *http.Request, state string) (*AuthState, error)
+}
+
+var (
+	_ StateStore = &MemoryStateStore{}
+	_ StateStore = &CookieStateStore{}
+)
+
+// MemoryStateStore is an in-memory StateStore, it's suitable for a single instance only.
+// The data stays on the server, the browser gets only a cookie with a hash of the state,
+// which is required by Consume, so a state cannot be completed in another browser (login CSRF).
+// Unlike CookieStateStore, it rejects replays of a used state.
+type MemoryStateStore struct {
+	cookies *CookieStore
+
+	mu     sync.Mutex
+	states map[string]stateEntry
+}
+
+type stateEntry struct {
+	data   *AuthState
+	expiry time.Time
+}
+
+// NewMemoryStateStore instantiates a new in-memory state store binding states to browsers
+// with cookies of the cookie store.
+func NewMemoryStateStore(cookies *CookieStore) *MemoryStateStore {
+	s := &MemoryStateStore{
+		cookies: cookies,
+		states:  make(map[string]stateEntry),
+	}
+	return s
+}
+
+// Save implements the StateStore interface.
+func (s *MemoryStateStore) Save(w http.ResponseWriter, r *http.Request, state string, data *AuthState, ttl time.Duration) error {
+	name, hash := s.binding(state)
+	http.SetCookie(w, s.cookies.cookie(name, hash, ttl))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, e := range s.states {
+		if now.After(e.expiry) {
+			delete(s.states, k)
+		}
+	}
+	s.states[state] = stateEntry{data: data, expiry: now.Add(ttl)}
+	return nil
+}
+
+// Consume implements the StateStore interface.
+func (s *MemoryStateStore) Consume(w http.ResponseWriter, r *http.Request, state string) (*AuthState, error) {
+	name, hash := s.binding(state)
+	c, err := r.Cookie(name)
+	if err != nil || subtle.ConstantTimeCompare([]byte(c.Value), []byte(hash)) != 1 {
+		return nil, ErrStateNotFound
+	}
+	s.cookies.delete(w, name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.states[state]
+	if !ok {
+		return nil, ErrStateNotFound
+	}
+	delete(s.states, state)
+
+	if time.Now().After(e.expiry) {
+		return nil, ErrStateNotFound
+	}
+	return e.data, nil
+}
+
+// binding returns the name and the value of the cookie binding the state to the browser.
+// The name has a part of the hash, so several logins can be pending in one browser.
+func (s *MemoryStateStore) binding(state string) (name, hash string) {
+	sum := sha256.Sum256([]byte(state))
+	hash = base64.RawURLEncoding.EncodeToString(sum[:])
+	return s.cookies.config.Name + "_state_" + hash[:8], hash
+}
+
+// CookieStateStore is a StateStore keeping a pending authorization in an encrypted cookie.
+// It keeps one authorization per browser, a new login replaces the previous one.
+type CookieStateStore struct {
+	cookies *CookieStore
+}
+
+// NewCookieStateStore instantiates a new state store on top of the cookie store.
+func NewCookieStateStore(cookies *CookieStore) *CookieStateStore {
+	s := &CookieStateStore{
+		cookies: cookies,
+	}
+	return s
+}
+
+type cookieState struct {
+	State string `json:"state"`
+	AuthState
+}
+
+// Save implements the StateStore interface.
+func (s *CookieStateStore) Save(w http.ResponseWriter, r *http.Request, state string, data *AuthState, ttl time.Duration) error {
+	value, err := json.Marshal(cookieState{State: state, AuthState: *data})
+	if err != nil {
+		return err
+	}
+	return s.cookies.set(w, s.name(), "state", value, ttl)
+}
+
+// Consume implements the StateStore interface.
+func (s *CookieStateStore) Consume(w http.ResponseWriter, r *http.Request, state string) (*AuthState, error) {
+	value, err := s.cookies.get(r, s.name(), "state")
+	s.cookies.delete(w, s.name())
+	if err != nil {
+		return nil, ErrStateNotFound
+	}
+
+	var cs cookieState
+	if err := json.Unmarshal(value, &cs); err != nil {
+		return nil, ErrStateNotFound
+	}
+	if subtle.ConstantTimeCompare([]byte(cs.State), []byte(state)) != 1 {
+		return nil, ErrStateNotFound
+	}
+	return &cs.AuthState, nil
+}
+
+func (s *CookieStateStore) name() string {
+	return s.cookies.config.Name + "_state"
+}
@@ -0,0 +1,62 @@
+package oauth2http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMemoryStateStore(t *testing.T) {
+	cookies, err := NewCookieStore(testKey, CookieConfig{})
+	mustOk(t, err)
+	s := NewMemoryStateStore(cookies)
+	data := &AuthState{Nonce: "NONCE", CodeVerifier: "VERIFIER"}
+
+	w := httptest.NewRecorder()
+	mustOk(t, s.Save(w, nil, "state-1", data, time.Minute))
+	mustOk(t, s.Save(w, nil, "state-2", data, -time.Second))
+	r := requestWithCookies(w)
+
+	// another browser without the cookie, like a victim of login CSRF.
+	_, err = s.Consume(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "state-1")
+	mustEqual(t, errors.Is(err, ErrStateNotFound), true)
+
+	got, err := s.Consume(httptest.NewRecorder(), r, "state-1")
+	mustOk(t, err)
+	mustEqual(t, got, data)
+
+	// single use.
+	_, err = s.Consume(httptest.NewRecorder(), r, "state-1")
+	mustEqual(t, errors.Is(err, ErrStateNotFound), true)
+
+	// expired.
+	_, err = s.Consume(httptest.NewRecorder(), r, "state-2")
+	mustEqual(t, errors.Is(err, ErrStateNotFound), true)
+}
+
+func TestCookieStateStore(t *testing.T) {
+	cookies, err := NewCookieStore(testKey, CookieConfig{})
+	mustOk(t, err)
+	s := NewCookieStateStore(cookies)
+	data := &AuthState{Nonce: "NONCE", CodeVerifier: "VERIFIER"}
+
+	w := httptest.NewRecorder()
+	mustOk(t, s.Save(w, nil, "state-1", data, time.Minute))
+	r := requestWithCookies(w)
+
+	_, err = s.Consume(httptest.NewRecorder(), r, "state-2")
+	mustEqual(t, errors.Is(err, ErrStateNotFound), true)
+
+	w = httptest.NewRecorder()
+	got, err := s.Consume(w, r, "state-1")
+	mustOk(t, err)
+	mustEqual(t, got, data)
+
+	// the cookie is removed.
+	mustEqual(t, w.Result().Cookies()[0].MaxAge, -1)
+
+	_, err = s.Consume(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "state-1")
+	mustEqual(t, errors.Is(err, ErrStateNotFound), true)
+}
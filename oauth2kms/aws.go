@@ -0,0 +1,63 @@
+package oauth2kms
+
+import (
+	"context"
+
+	"github.com/cristalhq/oauth2"
+)
+
+// AWSClient is a subset of AWS KMS API used by this package.
+type AWSClient interface {
+	// Sign calls KMS Sign with the digest (MessageType DIGEST) and
+	// a signing algorithm like `RSASSA_PKCS1_V1_5_SHA_256`, returning the Signature field.
+	Sign(ctx context.Context, keyID string, digest []byte, signingAlgorithm string) ([]byte, error)
+}
+
+var awsAlgorithms = map[string]string{
+	"RS256": "RSASSA_PKCS1_V1_5_SHA_256",
+	"RS384": "RSASSA_PKCS1_V1_5_SHA_384",
+	"RS512": "RSASSA_PKCS1_V1_5_SHA_512",
+	"PS256": "RSASSA_PSS_SHA_256",
+	"PS384": "RSASSA_PSS_SHA_384",
+	"PS512": "RSASSA_PSS_SHA_512",
+	"ES256": "ECDSA_SHA_256",
+	"ES384": "ECDSA_SHA_384",
+	"ES512": "ECDSA_SHA_512",
+}
+
+// NewAWSSigner returns an oauth2.Signer using the AWS KMS key with the JWS algorithm.
+// The kid is an optional key ID for the JWT header.
+func NewAWSSigner(client AWSClient, keyID, alg, kid string) (oauth2.Signer, error) {
+	a, err := lookupAlgorithm(alg)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &awsSigner{
+		client: client,
+		keyID:  keyID,
+		alg:    alg,
+		kid:    kid,
+		algo:   a,
+	}
+	return s, nil
+}
+
+type awsSigner struct {
+	client AWSClient
+	keyID  string
+	alg    string
+	kid    string
+	algo   algorithm
+}
+
+func (s *awsSigner) Algorithm() string { return s.alg }
+func (s *awsSigner) KeyID() string     { return s.kid }
+
+func (s *awsSigner) Sign(ctx context.Context, data []byte) ([]byte, error) {
+	sig, err := s.client.Sign(ctx, s.keyID, s.algo.digest(data), awsAlgorithms[s.alg])
+	if err != nil {
+		return nil, err
+	}
+	return s.algo.signature(sig)
+}
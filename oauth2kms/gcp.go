@@ -0,0 +1,52 @@
+package oauth2kms
+
+import (
+	"context"
+	"crypto"
+
+	"github.com/cristalhq/oauth2"
+)
+
+// GCPClient is a subset of GCP Cloud KMS API used by this package.
+type GCPClient interface {
+	// AsymmetricSign calls Cloud KMS AsymmetricSign for the key version name
+	// with the digest of the given hash, returning the Signature field.
+	AsymmetricSign(ctx context.Context, name string, digest []byte, hash crypto.Hash) ([]byte, error)
+}
+
+// NewGCPSigner returns an oauth2.Signer using the Cloud KMS key version with the JWS algorithm,
+// the algorithm must match the key version. The kid is an optional key ID for the JWT header.
+func NewGCPSigner(client GCPClient, name, alg, kid string) (oauth2.Signer, error) {
+	a, err := lookupAlgorithm(alg)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &gcpSigner{
+		client: client,
+		name:   name,
+		alg:    alg,
+		kid:    kid,
+		algo:   a,
+	}
+	return s, nil
+}
+
+type gcpSigner struct {
+	client GCPClient
+	name   string
+	alg    string
+	kid    string
+	algo   algorithm
+}
+
+func (s *gcpSigner) Algorithm() string { return s.alg }
+func (s *gcpSigner) KeyID() string     { return s.kid }
+
+func (s *gcpSigner) Sign(ctx context.Context, data []byte) ([]byte, error) {
+	sig, err := s.client.AsymmetricSign(ctx, s.name, s.algo.digest(data), s.algo.hash)
+	if err != nil {
+		return nil, err
+	}
+	return s.algo.signature(sig)
+}
@@ -0,0 +1,68 @@
+// Package oauth2kms implements oauth2.Signer on top of cloud KMS services,
+// so private keys never leave the KMS.
+//
+// The package doesn't depend on cloud SDKs,
+// SDK clients can be adapted to AWSClient and GCPClient interfaces.
+package oauth2kms
+
+import (
+	"crypto"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+
+	_ "crypto/sha256" // register hash functions
+	_ "crypto/sha512"
+)
+
+// algorithm describes a JWS algorithm.
+type algorithm struct {
+	hash    crypto.Hash
+	ecdsa   bool
+	keySize int // size of R and S for ECDSA
+}
+
+var algorithms = map[string]algorithm{
+	"RS256": {hash: crypto.SHA256},
+	"RS384": {hash: crypto.SHA384},
+	"RS512": {hash: crypto.SHA512},
+	"PS256": {hash: crypto.SHA256},
+	"PS384": {hash: crypto.SHA384},
+	"PS512": {hash: crypto.SHA512},
+	"ES256": {hash: crypto.SHA256, ecdsa: true, keySize: 32},
+	"ES384": {hash: crypto.SHA384, ecdsa: true, keySize: 48},
+	"ES512": {hash: crypto.SHA512, ecdsa: true, keySize: 66},
+}
+
+func lookupAlgorithm(alg string) (algorithm, error) {
+	a, ok := algorithms[alg]
+	if !ok {
+		return algorithm{}, errors.New("oauth2kms: unsupported algorithm: " + alg)
+	}
+	return a, nil
+}
+
+func (a algorithm) digest(data []byte) []byte {
+	h := a.hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// signature converts a KMS signature into JWS format, ECDSA signatures are ASN.1 encoded by KMS.
+func (a algorithm) signature(sig []byte) ([]byte, error) {
+	if !a.ecdsa {
+		return sig, nil
+	}
+
+	var es struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(sig, &es); err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, 2*a.keySize)
+	es.R.FillBytes(raw[:a.keySize])
+	es.S.FillBytes(raw[a.keySize:])
+	return raw, nil
+}
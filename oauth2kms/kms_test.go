@@ -0,0 +1,97 @@
+package oauth2kms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestAWSSigner(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	mustOk(t, err)
+
+	client := awsClientFunc(func(ctx context.Context, keyID string, digest []byte, signingAlgorithm string) ([]byte, error) {
+		mustEqual(t, keyID, "alias/oauth2")
+		mustEqual(t, signingAlgorithm, "ECDSA_SHA_256")
+		return key.Sign(rand.Reader, digest, crypto.SHA256)
+	})
+
+	signer, err := NewAWSSigner(client, "alias/oauth2", "ES256", "kid-1")
+	mustOk(t, err)
+	mustEqual(t, signer.Algorithm(), "ES256")
+	mustEqual(t, signer.KeyID(), "kid-1")
+
+	data := []byte("header.payload")
+	sig, err := signer.Sign(context.Background(), data)
+	mustOk(t, err)
+	mustEqual(t, len(sig), 64)
+
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	mustEqual(t, ecdsa.Verify(&key.PublicKey, hashOf(crypto.SHA256, data), r, s), true)
+
+	_, err = NewAWSSigner(client, "alias/oauth2", "HS256", "")
+	mustFail(t, err)
+}
+
+func TestGCPSigner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	mustOk(t, err)
+
+	client := gcpClientFunc(func(ctx context.Context, name string, digest []byte, hash crypto.Hash) ([]byte, error) {
+		mustEqual(t, hash, crypto.SHA256)
+		return key.Sign(rand.Reader, digest, hash)
+	})
+
+	signer, err := NewGCPSigner(client, "projects/p/locations/l/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1", "RS256", "")
+	mustOk(t, err)
+
+	data := []byte("header.payload")
+	sig, err := signer.Sign(context.Background(), data)
+	mustOk(t, err)
+	mustOk(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashOf(crypto.SHA256, data), sig))
+}
+
+type awsClientFunc func(ctx context.Context, keyID string, digest []byte, signingAlgorithm string) ([]byte, error)
+
+func (f awsClientFunc) Sign(ctx context.Context, keyID string, digest []byte, signingAlgorithm string) ([]byte, error) {
+	return f(ctx, keyID, digest, signingAlgorithm)
+}
+
+type gcpClientFunc func(ctx context.Context, name string, digest []byte, hash crypto.Hash) ([]byte, error)
+
+func (f gcpClientFunc) AsymmetricSign(ctx context.Context, name string, digest []byte, hash crypto.Hash) ([]byte, error) {
+	return f(ctx, name, digest, hash)
+}
+
+func hashOf(hash crypto.Hash, data []byte) []byte {
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func mustOk(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func mustFail(tb testing.TB, err error) {
+	tb.Helper()
+	if err == nil {
+		tb.Fatal()
+	}
+}
+
+func mustEqual[T any](tb testing.TB, have, want T) {
+	tb.Helper()
+	if !reflect.DeepEqual(have, want) {
+		tb.Fatalf("\nhave: %+v\nwant: %+v\n", have, want)
+	}
+}
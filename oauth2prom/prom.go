@@ -0,0 +1,249 @@
+// Package oauth2prom implements oauth2.Metrics exposing Prometheus metrics:
+//
+//	oauth2_token_requests_total{grant,outcome}
+//	oauth2_token_request_duration_seconds{grant}
+//	oauth2_token_cache_hits_total
+//	oauth2_token_cache_misses_total
+//	oauth2_refresh_failures_total
+//	oauth2_mode_detections_total{mode}
+//	oauth2_token_uses_total{source}
+//	oauth2_breaker_state
+//
+// The package doesn't depend on the Prometheus client library, Collector serves
+// the Prometheus text format itself and can be mounted at `/metrics` or next to an existing registry.
+package oauth2prom
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cristalhq/oauth2"
+)
+
+var (
+	_ oauth2.Metrics        = &Collector{}
+	_ oauth2.ModeMetrics    = &Collector{}
+	_ oauth2.UsageMetrics   = &Collector{}
+	_ oauth2.BreakerMetrics = &Collector{}
+)
+
+// DefaultBuckets are histogram buckets of the token request duration in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Collector collects metrics of clients using it as oauth2.Config.Metrics.
+// It is safe for concurrent use.
+type Collector struct {
+	buckets []float64
+
+	mu         sync.Mutex
+	requests   map[requestLabels]uint64
+	durations  map[string]*histogram
+	detections map[string]uint64
+
+	cacheHits       uint64
+	cacheMisses     uint64
+	refreshFailures uint64
+	cachedUses      uint64
+	refreshedUses   uint64
+	breakerState    int64
+}
+
+type requestLabels struct {
+	grant   string
+	outcome string
+}
+
+type histogram struct {
+	counts []uint64 // counts per bucket, not cumulative.
+	sum    float64
+	count  uint64
+}
+
+// NewCollector instantiates a new collector with the duration histogram buckets,
+// DefaultBuckets are used if none are given.
+func NewCollector(buckets ...float64) *Collector {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	c := &Collector{
+		buckets:    buckets,
+		requests:   make(map[requestLabels]uint64),
+		durations:  make(map[string]*histogram),
+		detections: make(map[string]uint64),
+	}
+	return c
+}
+
+// TokenRequest implements the oauth2.Metrics interface.
+func (c *Collector) TokenRequest(grantType string, err error, duration time.Duration) {
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+	seconds := duration.Seconds()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests[requestLabels{grant: grantType, outcome: outcome}]++
+
+	h, ok := c.durations[grantType]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(c.buckets))}
+		c.durations[grantType] = h
+	}
+	for i, b := range c.buckets {
+		if seconds <= b {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// CacheLookup implements the oauth2.Metrics interface.
+func (c *Collector) CacheLookup(hit bool) {
+	if hit {
+		atomic.AddUint64(&c.cacheHits, 1)
+	} else {
+		atomic.AddUint64(&c.cacheMisses, 1)
+	}
+}
+
+// RefreshFailure implements the oauth2.Metrics interface.
+func (c *Collector) RefreshFailure(err error) {
+	atomic.AddUint64(&c.refreshFailures, 1)
+}
+
+// ModeDetected implements the oauth2.ModeMetrics interface.
+func (c *Collector) ModeDetected(mode oauth2.Mode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.detections[mode.String()]++
+}
+
+// TokenUsed implements the oauth2.UsageMetrics interface.
+func (c *Collector) TokenUsed(cached bool) {
+	if cached {
+		atomic.AddUint64(&c.cachedUses, 1)
+	} else {
+		atomic.AddUint64(&c.refreshedUses, 1)
+	}
+}
+
+// BreakerStateChanged implements the oauth2.BreakerMetrics interface.
+func (c *Collector) BreakerStateChanged(state oauth2.BreakerState) {
+	atomic.StoreInt64(&c.breakerState, int64(state))
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text format.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+
+	c.mu.Lock()
+	requests := make([]requestLabels, 0, len(c.requests))
+	for l := range c.requests {
+		requests = append(requests, l)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].grant != requests[j].grant {
+			return requests[i].grant < requests[j].grant
+		}
+		return requests[i].outcome < requests[j].outcome
+	})
+
+	fmt.Fprintln(cw, "# HELP oauth2_token_requests_total Token endpoint requests by grant type and outcome.")
+	fmt.Fprintln(cw, "# TYPE oauth2_token_requests_total counter")
+	for _, l := range requests {
+		fmt.Fprintf(cw, "oauth2_token_requests_total{grant=%q,outcome=%q} %d\n", l.grant, l.outcome, c.requests[l])
+	}
+
+	grants := make([]string, 0, len(c.durations))
+	for g := range c.durations {
+		grants = append(grants, g)
+	}
+	sort.Strings(grants)
+
+	fmt.Fprintln(cw, "# HELP oauth2_token_request_duration_seconds Token endpoint request duration by grant type.")
+	fmt.Fprintln(cw, "# TYPE oauth2_token_request_duration_seconds histogram")
+	for _, g := range grants {
+		h := c.durations[g]
+		var cumulative uint64
+		for i, b := range c.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(cw, "oauth2_token_request_duration_seconds_bucket{grant=%q,le=%q} %d\n", g, formatFloat(b), cumulative)
+		}
+		fmt.Fprintf(cw, "oauth2_token_request_duration_seconds_bucket{grant=%q,le=\"+Inf\"} %d\n", g, h.count)
+		fmt.Fprintf(cw, "oauth2_token_request_duration_seconds_sum{grant=%q} %s\n", g, formatFloat(h.sum))
+		fmt.Fprintf(cw, "oauth2_token_request_duration_seconds_count{grant=%q} %d\n", g, h.count)
+	}
+
+	modes := make([]string, 0, len(c.detections))
+	for m := range c.detections {
+		modes = append(modes, m)
+	}
+	sort.Strings(modes)
+
+	fmt.Fprintln(cw, "# HELP oauth2_mode_detections_total Authentication modes settled on by auto-detection.")
+	fmt.Fprintln(cw, "# TYPE oauth2_mode_detections_total counter")
+	for _, m := range modes {
+		fmt.Fprintf(cw, "oauth2_mode_detections_total{mode=%q} %d\n", m, c.detections[m])
+	}
+	c.mu.Unlock()
+
+	writeCounter(cw, "oauth2_token_cache_hits_total", "TokenCache lookups returning a cached token.", atomic.LoadUint64(&c.cacheHits))
+	writeCounter(cw, "oauth2_token_cache_misses_total", "TokenCache lookups retrieving a new token.", atomic.LoadUint64(&c.cacheMisses))
+	writeCounter(cw, "oauth2_refresh_failures_total", "Failed TokenSource refreshes.", atomic.LoadUint64(&c.refreshFailures))
+
+	fmt.Fprintln(cw, "# HELP oauth2_token_uses_total Tokens returned by TokenSource, cached or refreshed.")
+	fmt.Fprintln(cw, "# TYPE oauth2_token_uses_total counter")
+	fmt.Fprintf(cw, "oauth2_token_uses_total{source=\"cached\"} %d\n", atomic.LoadUint64(&c.cachedUses))
+	fmt.Fprintf(cw, "oauth2_token_uses_total{source=\"refreshed\"} %d\n", atomic.LoadUint64(&c.refreshedUses))
+
+	fmt.Fprintln(cw, "# HELP oauth2_breaker_state Circuit breaker state of the last changed client: 0 closed, 1 open, 2 half-open.")
+	fmt.Fprintln(cw, "# TYPE oauth2_breaker_state gauge")
+	fmt.Fprintf(cw, "oauth2_breaker_state %d\n", atomic.LoadInt64(&c.breakerState))
+	return cw.n, cw.err
+}
+
+func writeCounter(w io.Writer, name, help string, value uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, value)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// countingWriter keeps the number of written bytes and the first error.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
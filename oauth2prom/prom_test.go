@@ -0,0 +1,120 @@
+package oauth2prom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cristalhq/oauth2"
+)
+
+func TestCollector(t *testing.T) {
+	c := NewCollector(0.1, 1)
+
+	c.TokenRequest("client_credentials", nil, 50*time.Millisecond)
+	c.TokenRequest("client_credentials", nil, 500*time.Millisecond)
+	c.TokenRequest("client_credentials", errors.New("boom"), 2*time.Second)
+	c.TokenRequest("refresh_token", errors.New("boom"), 10*time.Millisecond)
+	c.CacheLookup(true)
+	c.CacheLookup(true)
+	c.CacheLookup(false)
+	c.RefreshFailure(errors.New("boom"))
+	c.ModeDetected(oauth2.InParamsMode)
+	c.TokenUsed(true)
+	c.TokenUsed(true)
+	c.TokenUsed(false)
+	c.BreakerStateChanged(oauth2.BreakerOpen)
+
+	var b strings.Builder
+	_, err := c.WriteTo(&b)
+	mustOk(t, err)
+
+	want := `# HELP oauth2_token_requests_total Token endpoint requests by grant type and outcome.
+# TYPE oauth2_token_requests_total counter
+oauth2_token_requests_total{grant="client_credentials",outcome="failure"} 1
+oauth2_token_requests_total{grant="client_credentials",outcome="success"} 2
+oauth2_token_requests_total{grant="refresh_token",outcome="failure"} 1
+# HELP oauth2_token_request_duration_seconds Token endpoint request duration by grant type.
+# TYPE oauth2_token_request_duration_seconds histogram
+oauth2_token_request_duration_seconds_bucket{grant="client_credentials",le="0.1"} 1
+oauth2_token_request_duration_seconds_bucket{grant="client_credentials",le="1"} 2
+oauth2_token_request_duration_seconds_bucket{grant="client_credentials",le="+Inf"} 3
+oauth2_token_request_duration_seconds_sum{grant="client_credentials"} 2.55
+oauth2_token_request_duration_seconds_count{grant="client_credentials"} 3
+oauth2_token_request_duration_seconds_bucket{grant="refresh_token",le="0.1"} 1
+oauth2_token_request_duration_seconds_bucket{grant="refresh_token",le="1"} 1
+oauth2_token_request_duration_seconds_bucket{grant="refresh_token",le="+Inf"} 1
+oauth2_token_request_duration_seconds_sum{grant="refresh_token"} 0.01
+oauth2_token_request_duration_seconds_count{grant="refresh_token"} 1
+# HELP oauth2_mode_detections_total Authentication modes settled on by auto-detection.
+# TYPE oauth2_mode_detections_total counter
+oauth2_mode_detections_total{mode="client_secret_post"} 1
+# HELP oauth2_token_cache_hits_total TokenCache lookups returning a cached token.
+# TYPE oauth2_token_cache_hits_total counter
+oauth2_token_cache_hits_total 2
+# HELP oauth2_token_cache_misses_total TokenCache lookups retrieving a new token.
+# TYPE oauth2_token_cache_misses_total counter
+oauth2_token_cache_misses_total 1
+# HELP oauth2_refresh_failures_total Failed TokenSource refreshes.
+# TYPE oauth2_refresh_failures_total counter
+oauth2_refresh_failures_total 1
+# HELP oauth2_token_uses_total Tokens returned by TokenSource, cached or refreshed.
+# TYPE oauth2_token_uses_total counter
+oauth2_token_uses_total{source="cached"} 2
+oauth2_token_uses_total{source="refreshed"} 1
+# HELP oauth2_breaker_state Circuit breaker state of the last changed client: 0 closed, 1 open, 2 half-open.
+# TYPE oauth2_breaker_state gauge
+oauth2_breaker_state 1
+`
+	mustEqual(t, b.String(), want)
+}
+
+func TestCollectorWithClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN", "expires_in": 3600}`)
+	}))
+	defer ts.Close()
+
+	c := NewCollector()
+	client := oauth2.NewClient(http.DefaultClient, oauth2.Config{
+		ClientID: "CLIENT_ID",
+		TokenURL: ts.URL,
+		Mode:     oauth2.InParamsMode,
+		Metrics:  c,
+	})
+	cache := oauth2.NewTokenCache(client, oauth2.TokenCacheConfig{})
+
+	for i := 0; i < 3; i++ {
+		_, err := cache.Token(context.Background(), oauth2.TokenKey{})
+		mustOk(t, err)
+	}
+
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+
+	mustEqual(t, strings.Contains(body, `oauth2_token_requests_total{grant="client_credentials",outcome="success"} 1`), true)
+	mustEqual(t, strings.Contains(body, "oauth2_token_cache_hits_total 2\n"), true)
+	mustEqual(t, strings.Contains(body, "oauth2_token_cache_misses_total 1\n"), true)
+}
+
+func mustOk(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func mustEqual[T any](tb testing.TB, have, want T) {
+	tb.Helper()
+	if !reflect.DeepEqual(have, want) {
+		tb.Fatalf("\nhave: %+v\nwant: %+v\n", have, want)
+	}
+}
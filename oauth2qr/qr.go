@@ -0,0 +1,565 @@
+// Package oauth2qr renders QR codes in a terminal, for example the verification URL
+// of the device authorization flow on TV and CLI login screens:
+//
+//	da, err := client.DeviceAuth(ctx)
+//	...
+//	oauth2qr.Print(os.Stdout, da.URL())
+//
+// The package has no dependencies, it implements a QR code encoder in byte mode, ISO/IEC 18004.
+package oauth2qr
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// Level is an error correction level of a QR code.
+type Level int
+
+const (
+	// LevelLow recovers about 7% of damaged codewords.
+	LevelLow Level = iota
+
+	// LevelMedium recovers about 15% of damaged codewords.
+	LevelMedium
+
+	// LevelQuartile recovers about 25% of damaged codewords.
+	LevelQuartile
+
+	// LevelHigh recovers about 30% of damaged codewords.
+	LevelHigh
+)
+
+// formatBits are the bits of a level in the format information.
+func (l Level) formatBits() int {
+	return [...]int{1, 0, 3, 2}[l]
+}
+
+// ErrTooLong is returned when the text doesn't fit into a QR code of version 40.
+var ErrTooLong = errors.New("oauth2qr: text is too long")
+
+const (
+	minVersion = 1
+	maxVersion = 40
+
+	// quietZone is a light border around the code, the standard asks for 4 modules
+	// but 2 are enough on a terminal which is already surrounded by a background.
+	quietZone = 2
+)
+
+// eccPerBlock is the number of error correction codewords per block by level and version.
+var eccPerBlock = [4][maxVersion + 1]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+// eccBlocks is the number of error correction blocks by level and version.
+var eccBlocks = [4][maxVersion + 1]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// Code is an encoded QR code.
+type Code struct {
+	size     int
+	modules  []bool // dark modules, row by row.
+	function []bool // modules of function patterns, used only while encoding.
+}
+
+// Print encodes the text with LevelMedium and writes it to w as a terminal QR code,
+// see Code.String.
+func Print(w io.Writer, text string) error {
+	code, err := Encode(text, LevelMedium)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, code.String())
+	return err
+}
+
+// Encode encodes the text in byte mode into a QR code of the smallest version that fits it.
+func Encode(text string, level Level) (*Code, error) {
+	if level < LevelLow || level > LevelHigh {
+		return nil, errors.New("oauth2qr: unknown error correction level")
+	}
+
+	for version := minVersion; version <= maxVersion; version++ {
+		capacity := dataCodewords(version, level) * 8
+		if segmentBits(version, len(text)) <= capacity {
+			data := encodeData(text, version, capacity)
+			return newCode(version, level, addECC(data, version, level)), nil
+		}
+	}
+	return nil, ErrTooLong
+}
+
+// Size returns the number of modules on a side of the code, without a quiet zone.
+func (c *Code) Size() int {
+	return c.size
+}
+
+// Dark reports whether the module at x and y is dark, (0, 0) is the top left corner.
+// Modules outside of the code are light.
+func (c *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.size || y >= c.size {
+		return false
+	}
+	return c.modules[y*c.size+x]
+}
+
+// String returns the code drawn with Unicode half blocks, two rows of modules per line.
+// Light modules are drawn and dark ones are left blank, so the code scans on a terminal
+// with a dark background.
+func (c *Code) String() string {
+	var b strings.Builder
+	for y := -quietZone; y < c.size+quietZone; y += 2 {
+		for x := -quietZone; x < c.size+quietZone; x++ {
+			top, bottom := !c.Dark(x, y), !c.Dark(x, y+1)
+			if y+1 >= c.size+quietZone {
+				bottom = false
+			}
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// segmentBits is the length of a byte mode segment with n bytes.
+func segmentBits(version, n int) int {
+	return 4 + countBits(version) + 8*n
+}
+
+// countBits is the length of the character count in byte mode.
+func countBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// rawModules is the number of modules for data and error correction of a version.
+func rawModules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		n -= (25*align-10)*align - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+// dataCodewords is the number of data codewords of a version and level.
+func dataCodewords(version int, level Level) int {
+	return rawModules(version)/8 - eccPerBlock[level][version]*eccBlocks[level][version]
+}
+
+// encodeData returns data codewords of the text: a byte mode segment, a terminator and padding.
+func encodeData(text string, version, capacity int) []byte {
+	bb := &bitBuffer{}
+	bb.append(0b0100, 4)
+	bb.append(len(text), countBits(version))
+	for i := 0; i < len(text); i++ {
+		bb.append(int(text[i]), 8)
+	}
+
+	terminator := capacity - bb.n
+	if terminator > 4 {
+		terminator = 4
+	}
+	bb.append(0, terminator)
+	bb.append(0, (8-bb.n%8)%8)
+	for pad := 0xEC; bb.n < capacity; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+	return bb.data
+}
+
+// addECC splits data into blocks, appends error correction codewords to each one
+// and interleaves the blocks.
+func addECC(data []byte, version int, level Level) []byte {
+	numBlocks := eccBlocks[level][version]
+	eccLen := eccPerBlock[level][version]
+	raw := rawModules(version) / 8
+	numShort := numBlocks - raw%numBlocks
+	shortLen := raw / numBlocks
+
+	divisor := rsDivisor(eccLen)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		block := append([]byte(nil), data[k:k+n]...)
+		k += n
+		block = append(block, rsRemainder(block, divisor)...)
+		if i < numShort {
+			// align codewords of short blocks with long ones, the gap is skipped below.
+			block = append(block[:n], append([]byte{0}, block[n:]...)...)
+		}
+		blocks[i] = block
+	}
+
+	result := make([]byte, 0, raw)
+	for i := 0; i <= shortLen; i++ {
+		for j, block := range blocks {
+			if i != shortLen-eccLen || j >= numShort {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// newCode draws function patterns and the codewords, choosing a mask with the lowest penalty.
+func newCode(version int, level Level, codewords []byte) *Code {
+	size := version*4 + 17
+	c := &Code{
+		size:     size,
+		modules:  make([]bool, size*size),
+		function: make([]bool, size*size),
+	}
+	c.drawFunctionPatterns(version)
+	c.drawFormat(level, 0) // reserve the format modules before placing data.
+	c.drawCodewords(codewords)
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormat(level, mask)
+		if p := c.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		c.applyMask(mask) // masks are XOR, applying one again reverts it.
+	}
+	c.applyMask(best)
+	c.drawFormat(level, best)
+	c.function = nil
+	return c
+}
+
+func (c *Code) set(x, y int, dark bool) {
+	c.modules[y*c.size+x] = dark
+}
+
+func (c *Code) setFunction(x, y int, dark bool) {
+	c.modules[y*c.size+x] = dark
+	c.function[y*c.size+x] = true
+}
+
+func (c *Code) drawFunctionPatterns(version int) {
+	for i := 0; i < c.size; i++ {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	c.drawFinder(3, 3)
+	c.drawFinder(c.size-4, 3)
+	c.drawFinder(3, c.size-4)
+
+	positions := alignmentPositions(version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// skip the ones overlapping finder patterns.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			c.drawAlignment(x, y)
+		}
+	}
+
+	if version >= 7 {
+		bits := version<<12 | bchRemainder(version, 12, 0x1F25)
+		for i := 0; i < 18; i++ {
+			dark := bits>>i&1 == 1
+			a, b := c.size-11+i%3, i/3
+			c.setFunction(a, b, dark)
+			c.setFunction(b, a, dark)
+		}
+	}
+}
+
+// drawFinder draws a finder pattern with its separator around the center.
+func (c *Code) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || y < 0 || x >= c.size || y >= c.size {
+				continue
+			}
+			d := chebyshev(dx, dy)
+			c.setFunction(x, y, d != 2 && d != 4)
+		}
+	}
+}
+
+func (c *Code) drawAlignment(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(cx+dx, cy+dy, chebyshev(dx, dy) != 1)
+		}
+	}
+}
+
+// drawFormat draws both copies of the format information and the dark module.
+func (c *Code) drawFormat(level Level, mask int) {
+	data := level.formatBits()<<3 | mask
+	bits := (data<<10 | bchRemainder(data, 10, 0x537)) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		c.setFunction(8, i, bit(i))
+	}
+	c.setFunction(8, 7, bit(6))
+	c.setFunction(8, 8, bit(7))
+	c.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.setFunction(c.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.size-15+i, bit(i))
+	}
+	c.setFunction(8, c.size-8, true)
+}
+
+// drawCodewords places the codewords in two-module columns zigzagging from the bottom right corner.
+func (c *Code) drawCodewords(codewords []byte) {
+	i := 0
+	for right := c.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern.
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.size; vert++ {
+			y := vert
+			if upward {
+				y = c.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if c.function[y*c.size+x] || i >= len(codewords)*8 {
+					continue
+				}
+				c.set(x, y, codewords[i>>3]>>(7-i&7)&1 == 1)
+				i++
+			}
+		}
+	}
+}
+
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !c.function[y*c.size+x] {
+				c.modules[y*c.size+x] = !c.modules[y*c.size+x]
+			}
+		}
+	}
+}
+
+// finderLike are the 1:1:3:1:1 patterns with 4 light modules on a side, penalty rule 3.
+var finderLike = [2][11]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// penalty scores the code with the 4 rules of the standard, a lower score reads better.
+func (c *Code) penalty() int {
+	penalty, dark := 0, 0
+	for i := 0; i < c.size; i++ {
+		row := func(j int) bool { return c.Dark(j, i) }
+		col := func(j int) bool { return c.Dark(i, j) }
+		penalty += c.linePenalty(row) + c.linePenalty(col)
+	}
+
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			d := c.Dark(x, y)
+			if d {
+				dark++
+			}
+			if x+1 < c.size && y+1 < c.size && d == c.Dark(x+1, y) && d == c.Dark(x, y+1) && d == c.Dark(x+1, y+1) {
+				penalty += 3
+			}
+		}
+	}
+
+	total := c.size * c.size
+	k := (abs(dark*20-total*10) + total - 1) / total
+	penalty += (k - 1) * 10
+	return penalty
+}
+
+// linePenalty scores runs of modules of the same color and finder-like patterns in a line.
+func (c *Code) linePenalty(dark func(int) bool) int {
+	penalty := 0
+	run := 0
+	for i := 0; i < c.size; i++ {
+		if i > 0 && dark(i) == dark(i-1) {
+			run++
+		} else {
+			run = 1
+		}
+		switch {
+		case run == 5:
+			penalty += 3
+		case run > 5:
+			penalty++
+		}
+	}
+
+	for i := 0; i+11 <= c.size; i++ {
+		for _, pattern := range finderLike {
+			match := true
+			for j, d := range pattern {
+				if dark(i+j) != d {
+					match = false
+					break
+				}
+			}
+			if match {
+				penalty += 40
+			}
+		}
+	}
+	return penalty
+}
+
+// alignmentPositions returns centers of alignment patterns on each axis.
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := 26
+	if version != 32 {
+		step = (version*4 + n*2 + 1) / (n*2 - 2) * 2
+	}
+	positions := make([]int, n)
+	positions[0] = 6
+	for i, pos := n-1, version*4+10; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+// bchRemainder returns the remainder of data shifted by n bits divided by the generator.
+func bchRemainder(data, n, generator int) int {
+	rem := data
+	for i := 0; i < n; i++ {
+		rem = rem<<1 ^ (rem>>(n-1))*generator
+	}
+	return rem & (1<<n - 1)
+}
+
+// rsDivisor returns the Reed-Solomon generator polynomial of a degree,
+// without the leading term, coefficients from the highest power.
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder returns error correction codewords of the data.
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMul(d, factor)
+		}
+	}
+	return result
+}
+
+// gfMul multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMul(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+type bitBuffer struct {
+	data []byte
+	n    int // length in bits.
+}
+
+func (bb *bitBuffer) append(value, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		if bb.n%8 == 0 {
+			bb.data = append(bb.data, 0)
+		}
+		bb.data[bb.n/8] |= byte(value>>i&1) << (7 - bb.n%8)
+		bb.n++
+	}
+}
+
+func chebyshev(dx, dy int) int {
+	if dx, dy = abs(dx), abs(dy); dx > dy {
+		return dx
+	}
+	return dy
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
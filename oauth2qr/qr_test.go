@@ -0,0 +1,214 @@
+package oauth2qr
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	testCases := []struct {
+		text  string
+		level Level
+		size  int
+	}{
+		{"", LevelMedium, 21},
+		{"https://example.com/device", LevelLow, 25},
+		{"https://example.com/device", LevelMedium, 25},
+		{"https://example.com/device?user_code=WDJB-MJHT", LevelMedium, 33},
+		{strings.Repeat("a", 100), LevelHigh, 57},
+		{strings.Repeat("a", 2953), LevelLow, 177},
+	}
+
+	for _, tc := range testCases {
+		code, err := Encode(tc.text, tc.level)
+		mustOk(t, err)
+		mustEqual(t, code.Size(), tc.size)
+		mustEqual(t, decode(t, code, tc.level), tc.text)
+	}
+}
+
+func TestEncode_TooLong(t *testing.T) {
+	_, err := Encode(strings.Repeat("a", 2954), LevelLow)
+	mustEqual(t, errors.Is(err, ErrTooLong), true)
+
+	_, err = Encode("a", Level(4))
+	mustFail(t, err)
+}
+
+func TestCapacity(t *testing.T) {
+	mustEqual(t, dataCodewords(1, LevelLow), 19)
+	mustEqual(t, dataCodewords(1, LevelMedium), 16)
+	mustEqual(t, dataCodewords(1, LevelQuartile), 13)
+	mustEqual(t, dataCodewords(1, LevelHigh), 9)
+	mustEqual(t, dataCodewords(10, LevelMedium), 216)
+	mustEqual(t, dataCodewords(40, LevelLow), 2956)
+	mustEqual(t, dataCodewords(40, LevelHigh), 1276)
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	format := func(level Level, mask int) int {
+		data := level.formatBits()<<3 | mask
+		return (data<<10 | bchRemainder(data, 10, 0x537)) ^ 0x5412
+	}
+	mustEqual(t, format(LevelLow, 0), 0b111011111000100)
+	mustEqual(t, format(LevelMedium, 0), 0b101010000010010)
+	mustEqual(t, format(LevelHigh, 7), 0b000100000111011)
+
+	mustEqual(t, 7<<12|bchRemainder(7, 12, 0x1F25), 0x07C94)
+	mustEqual(t, 40<<12|bchRemainder(40, 12, 0x1F25), 0x28C69)
+}
+
+func TestReedSolomon(t *testing.T) {
+	// the example of ISO/IEC 18004 annex I: "01234567" in version 1-M.
+	data := []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+	want := []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55}
+	mustEqual(t, rsRemainder(data, rsDivisor(10)), want)
+}
+
+func TestFinderPatterns(t *testing.T) {
+	code, err := Encode("https://example.com/device", LevelMedium)
+	mustOk(t, err)
+
+	last := code.Size() - 1
+	for _, corner := range [][2]int{{0, 0}, {last - 6, 0}, {0, last - 6}} {
+		x, y := corner[0], corner[1]
+		mustEqual(t, code.Dark(x, y), true)
+		mustEqual(t, code.Dark(x+1, y+1), false)
+		mustEqual(t, code.Dark(x+3, y+3), true)
+	}
+	mustEqual(t, code.Dark(8, code.Size()-8), true)
+	mustEqual(t, code.Dark(-1, 0), false)
+}
+
+func TestPrint(t *testing.T) {
+	var b bytes.Buffer
+	mustOk(t, Print(&b, "https://example.com/device"))
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	mustEqual(t, len(lines), (25+2*quietZone+1)/2)
+	for _, line := range lines {
+		mustEqual(t, len([]rune(line)), 25+2*quietZone)
+	}
+	mustEqual(t, lines[0], strings.Repeat("█", 25+2*quietZone))
+}
+
+// decode reads the text back from the code, it knows the level and the version by the size.
+func decode(tb testing.TB, code *Code, level Level) string {
+	tb.Helper()
+
+	version := (code.Size() - 17) / 4
+	ref := &Code{
+		size:     code.size,
+		modules:  make([]bool, len(code.modules)),
+		function: make([]bool, len(code.modules)),
+	}
+	ref.drawFunctionPatterns(version)
+	ref.drawFormat(level, 0)
+
+	var mask int
+	for m := 0; m < 8; m++ {
+		ref.drawFormat(level, m)
+		if reflect.DeepEqual(formatModules(ref), formatModules(code)) {
+			mask = m
+			break
+		}
+	}
+
+	unmasked := &Code{size: code.size, modules: append([]bool(nil), code.modules...), function: ref.function}
+	unmasked.applyMask(mask)
+
+	// read the modules in the placement order.
+	raw := make([]byte, rawModules(version)/8)
+	i := 0
+	for right := code.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < code.size; vert++ {
+			y := vert
+			if upward {
+				y = code.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if ref.function[y*code.size+x] || i >= len(raw)*8 {
+					continue
+				}
+				if unmasked.modules[y*code.size+x] {
+					raw[i>>3] |= 1 << (7 - i&7)
+				}
+				i++
+			}
+		}
+	}
+
+	// deinterleave data codewords and check error correction of each block.
+	numBlocks := eccBlocks[level][version]
+	eccLen := eccPerBlock[level][version]
+	numShort := numBlocks - len(raw)%numBlocks
+	shortLen := len(raw) / numBlocks
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := 0; i <= shortLen; i++ {
+		for j := range blocks {
+			if i != shortLen-eccLen || j >= numShort {
+				blocks[j] = append(blocks[j], raw[k])
+				k++
+			}
+		}
+	}
+	var data []byte
+	for _, block := range blocks {
+		n := len(block) - eccLen
+		mustEqual(tb, rsRemainder(block[:n], rsDivisor(eccLen)), block[n:])
+		data = append(data, block[:n]...)
+	}
+
+	mustEqual(tb, data[0]>>4, byte(0b0100))
+	bits := func(from, n int) int {
+		v := 0
+		for i := from; i < from+n; i++ {
+			v = v<<1 | int(data[i/8]>>(7-i%8)&1)
+		}
+		return v
+	}
+	length := bits(4, countBits(version))
+	text := make([]byte, length)
+	for i := range text {
+		text[i] = byte(bits(4+countBits(version)+8*i, 8))
+	}
+	return string(text)
+}
+
+func formatModules(c *Code) []bool {
+	var modules []bool
+	for i := 0; i < 9; i++ {
+		modules = append(modules, c.Dark(8, i), c.Dark(i, 8))
+	}
+	return modules
+}
+
+func mustOk(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func mustFail(tb testing.TB, err error) {
+	tb.Helper()
+	if err == nil {
+		tb.Fatal("want error, got nil")
+	}
+}
+
+func mustEqual[T any](tb testing.TB, have, want T) {
+	tb.Helper()
+	if !reflect.DeepEqual(have, want) {
+		tb.Fatalf("\nhave: %+v\nwant: %+v\n", have, want)
+	}
+}
@@ -0,0 +1,104 @@
+// Package oauth2redis implements oauth2 extension points on top of Redis.
+//
+// The package doesn't depend on any Redis library,
+// any client can be adapted to the Client interface.
+package oauth2redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/cristalhq/oauth2"
+)
+
+// Client is a subset of Redis commands used by this package.
+type Client interface {
+	// Get returns the value of the key and reports whether the key exists (GET).
+	Get(ctx context.Context, key string) (string, bool, error)
+
+	// Set sets the key to the value with a TTL, zero TTL means no expiration (SET PX).
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+
+	// Del removes the key (DEL).
+	Del(ctx context.Context, key string) error
+
+	// SetNX sets the key to the value with a TTL if the key doesn't exist (SET NX PX).
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+
+	// Eval runs a Lua script (EVAL).
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+var _ oauth2.Locker = &Locker{}
+
+// Locker is an oauth2.Locker on top of Redis.
+type Locker struct {
+	client Client
+	prefix string
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	owned map[string]string
+}
+
+// NewLocker instantiates a new locker.
+// Every lock key is prefixed with prefix and expires after ttl
+// to not block other replicas if the lock owner dies.
+func NewLocker(client Client, prefix string, ttl time.Duration) *Locker {
+	l := &Locker{
+		client: client,
+		prefix: prefix,
+		ttl:    ttl,
+		owned:  make(map[string]string),
+	}
+	return l
+}
+
+// TryLock implements the oauth2.Locker interface.
+func (l *Locker) TryLock(ctx context.Context, key string) (bool, error) {
+	value, err := randomValue()
+	if err != nil {
+		return false, err
+	}
+
+	ok, err := l.client.SetNX(ctx, l.prefix+key, value, l.ttl)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	l.mu.Lock()
+	l.owned[key] = value
+	l.mu.Unlock()
+	return true, nil
+}
+
+// unlockScript deletes the key only if it's still owned by the caller.
+const unlockScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`
+
+// Unlock implements the oauth2.Locker interface.
+func (l *Locker) Unlock(ctx context.Context, key string) error {
+	l.mu.Lock()
+	value, ok := l.owned[key]
+	delete(l.owned, key)
+	l.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	_, err := l.client.Eval(ctx, unlockScript, []string{l.prefix + key}, value)
+	return err
+}
+
+func randomValue() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
@@ -0,0 +1,123 @@
+package oauth2redis
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLocker(t *testing.T) {
+	ctx := context.Background()
+	client := newMemClient()
+	l1 := NewLocker(client, "lock:", time.Minute)
+	l2 := NewLocker(client, "lock:", time.Minute)
+
+	ok, err := l1.TryLock(ctx, "user-1")
+	mustOk(t, err)
+	mustEqual(t, ok, true)
+
+	ok, err = l2.TryLock(ctx, "user-1")
+	mustOk(t, err)
+	mustEqual(t, ok, false)
+
+	// not an owner, must not release the lock.
+	mustOk(t, l2.Unlock(ctx, "user-1"))
+	mustEqual(t, client.has("lock:user-1"), true)
+
+	mustOk(t, l1.Unlock(ctx, "user-1"))
+	mustEqual(t, client.has("lock:user-1"), false)
+
+	ok, err = l2.TryLock(ctx, "user-1")
+	mustOk(t, err)
+	mustEqual(t, ok, true)
+}
+
+// memClient is an in-memory Client, TTLs are ignored.
+type memClient struct {
+	mu   sync.Mutex
+	data map[string]string
+	ttl  map[string]time.Duration
+}
+
+func newMemClient() *memClient {
+	return &memClient{
+		data: map[string]string{},
+		ttl:  map[string]time.Duration{},
+	}
+}
+
+func (c *memClient) has(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.data[key]
+	return ok
+}
+
+func (c *memClient) Get(ctx context.Context, key string) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.data[key]
+	return value, ok, nil
+}
+
+func (c *memClient) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.data[key] = value
+	c.ttl[key] = ttl
+	return nil
+}
+
+func (c *memClient) Del(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.data, key)
+	delete(c.ttl, key)
+	return nil
+}
+
+func (c *memClient) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.data[key]; ok {
+		return false, nil
+	}
+	c.data[key] = value
+	return true, nil
+}
+
+func (c *memClient) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch script {
+	case unlockScript:
+		if c.data[keys[0]] == args[0] {
+			delete(c.data, keys[0])
+			return int64(1), nil
+		}
+		return int64(0), nil
+	default:
+		panic("unknown script")
+	}
+}
+
+func mustOk(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func mustEqual[T any](tb testing.TB, have, want T) {
+	tb.Helper()
+	if !reflect.DeepEqual(have, want) {
+		tb.Fatalf("\nhave: %+v\nwant: %+v\n", have, want)
+	}
+}
@@ -0,0 +1,78 @@
+package oauth2redis
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/cristalhq/oauth2"
+)
+
+var _ oauth2.TokenStore = &Store{}
+
+// Store is an oauth2.TokenStore on top of Redis.
+//
+// Tokens without a refresh token expire in Redis together with the access token.
+// Tokens with a refresh token are kept until deleted, they can be refreshed.
+type Store struct {
+	client Client
+	prefix string
+}
+
+// NewStore instantiates a new store, every key is prefixed with prefix.
+func NewStore(client Client, prefix string) *Store {
+	s := &Store{
+		client: client,
+		prefix: prefix,
+	}
+	return s
+}
+
+// Load implements the oauth2.TokenStore interface.
+func (s *Store) Load(ctx context.Context, key string) (*oauth2.Token, error) {
+	value, ok, err := s.client.Get(ctx, s.prefix+key)
+	switch {
+	case err != nil:
+		return nil, err
+	case !ok:
+		return nil, oauth2.ErrTokenNotFound
+	}
+
+	var token oauth2.Token
+	if err := json.Unmarshal([]byte(value), &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Save implements the oauth2.TokenStore interface.
+func (s *Store) Save(ctx context.Context, key string, token *oauth2.Token) error {
+	ttl := tokenTTL(token)
+	if ttl < 0 {
+		return s.Delete(ctx, key)
+	}
+
+	value, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.prefix+key, string(value), ttl)
+}
+
+// Delete implements the oauth2.TokenStore interface.
+func (s *Store) Delete(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.prefix+key)
+}
+
+// tokenTTL returns how long the token is useful, zero means forever
+// and a negative value means the token has already expired.
+func tokenTTL(token *oauth2.Token) time.Duration {
+	if token.RefreshToken != "" || token.Expiry.IsZero() {
+		return 0
+	}
+	ttl := time.Until(token.Expiry)
+	if ttl <= 0 {
+		return -1
+	}
+	return ttl
+}
@@ -0,0 +1,58 @@
+package oauth2redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cristalhq/oauth2"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	client := newMemClient()
+	store := NewStore(client, "token:")
+
+	_, err := store.Load(ctx, "user-1")
+	mustEqual(t, errors.Is(err, oauth2.ErrTokenNotFound), true)
+
+	token := &oauth2.Token{
+		AccessToken:  "ACCESS_TOKEN",
+		RefreshToken: "REFRESH_TOKEN",
+		Expiry:       time.Now().Add(time.Hour).Round(0),
+	}
+	mustOk(t, store.Save(ctx, "user-1", token))
+	mustEqual(t, client.ttl["token:user-1"], time.Duration(0))
+
+	loaded, err := store.Load(ctx, "user-1")
+	mustOk(t, err)
+	mustEqual(t, loaded.AccessToken, token.AccessToken)
+	mustEqual(t, loaded.RefreshToken, token.RefreshToken)
+	mustEqual(t, loaded.Expiry.Equal(token.Expiry), true)
+
+	mustOk(t, store.Delete(ctx, "user-1"))
+	mustEqual(t, client.has("token:user-1"), false)
+}
+
+func TestStore_TTL(t *testing.T) {
+	ctx := context.Background()
+	client := newMemClient()
+	store := NewStore(client, "token:")
+
+	token := &oauth2.Token{
+		AccessToken: "ACCESS_TOKEN",
+		Expiry:      time.Now().Add(time.Hour),
+	}
+	mustOk(t, store.Save(ctx, "service", token))
+
+	ttl := client.ttl["token:service"]
+	mustEqual(t, ttl > 59*time.Minute && ttl <= time.Hour, true)
+
+	expired := &oauth2.Token{
+		AccessToken: "ACCESS_TOKEN",
+		Expiry:      time.Now().Add(-time.Hour),
+	}
+	mustOk(t, store.Save(ctx, "service", expired))
+	mustEqual(t, client.has("token:service"), false)
+}
@@ -0,0 +1,268 @@
+// Package oauth2sql implements oauth2.TokenStore on top of database/sql.
+package oauth2sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cristalhq/oauth2"
+)
+
+// Dialect describes SQL syntax differences between databases.
+type Dialect int
+
+const (
+	// MySQL uses `?` placeholders and `ON DUPLICATE KEY UPDATE` upserts.
+	MySQL Dialect = 0
+
+	// Postgres uses `$1`, `$2`, ... placeholders and `ON CONFLICT` upserts.
+	Postgres Dialect = 1
+
+	// SQLite uses `?` placeholders and `ON CONFLICT` upserts, SQLite 3.24 or newer is required.
+	SQLite Dialect = 2
+)
+
+// Schema returns a CREATE TABLE statement for the table used by Store.
+func Schema(table string) string {
+	return `CREATE TABLE ` + table + ` (
+	token_key      VARCHAR(255) NOT NULL PRIMARY KEY,
+	access_token   TEXT NOT NULL,
+	token_type     VARCHAR(64) NOT NULL,
+	refresh_token  TEXT NOT NULL,
+	expiry         BIGINT NOT NULL,
+	refresh_expiry BIGINT NOT NULL,
+	obtained_at    BIGINT NOT NULL,
+	grant_type     VARCHAR(255) NOT NULL,
+	issuer         TEXT NOT NULL,
+	token_url      TEXT NOT NULL,
+	mode           INT NOT NULL,
+	raw            TEXT NOT NULL
+)`
+}
+
+var (
+	_ oauth2.TokenStore   = &Store{}
+	_ oauth2.TokenRotator = &Store{}
+)
+
+// tokenColumns are the columns of a token in the order of tokenArgs, without the key.
+var tokenColumns = []string{
+	"access_token", "token_type", "refresh_token", "expiry", "refresh_expiry",
+	"obtained_at", "grant_type", "issuer", "token_url", "mode", "raw",
+}
+
+// Store is an oauth2.TokenStore on top of an SQL database.
+// See Schema for the expected table structure.
+type Store struct {
+	db *sql.DB
+
+	loadQuery         string
+	upsertQuery       string
+	insertQuery       string
+	rotateQuery       string
+	refreshTokenQuery string
+	deleteQuery       string
+}
+
+// NewStore instantiates a new store for the given table.
+func NewStore(db *sql.DB, table string, dialect Dialect) *Store {
+	columns := strings.Join(tokenColumns, ", ")
+	values := strings.Repeat("?, ", len(tokenColumns)) + "?"
+	insert := `INSERT INTO ` + table + ` (` + columns + `, token_key) VALUES (` + values + `)`
+
+	sets := make([]string, len(tokenColumns))
+	updates := make([]string, len(tokenColumns))
+	for i, col := range tokenColumns {
+		sets[i] = col + " = ?"
+		if dialect == MySQL {
+			updates[i] = col + " = VALUES(" + col + ")"
+		} else {
+			updates[i] = col + " = EXCLUDED." + col
+		}
+	}
+
+	s := &Store{
+		db: db,
+
+		loadQuery:         `SELECT ` + columns + ` FROM ` + table + ` WHERE token_key = ?`,
+		rotateQuery:       `UPDATE ` + table + ` SET ` + strings.Join(sets, ", ") + ` WHERE token_key = ? AND refresh_token = ?`,
+		refreshTokenQuery: `SELECT refresh_token FROM ` + table + ` WHERE token_key = ?`,
+		deleteQuery:       `DELETE FROM ` + table + ` WHERE token_key = ?`,
+	}
+
+	switch dialect {
+	case MySQL:
+		s.upsertQuery = insert + ` ON DUPLICATE KEY UPDATE ` + strings.Join(updates, ", ")
+		s.insertQuery = insert + ` ON DUPLICATE KEY UPDATE token_key = token_key`
+	default:
+		s.upsertQuery = insert + ` ON CONFLICT (token_key) DO UPDATE SET ` + strings.Join(updates, ", ")
+		s.insertQuery = insert + ` ON CONFLICT (token_key) DO NOTHING`
+	}
+
+	if dialect == Postgres {
+		s.loadQuery = numberPlaceholders(s.loadQuery)
+		s.upsertQuery = numberPlaceholders(s.upsertQuery)
+		s.insertQuery = numberPlaceholders(s.insertQuery)
+		s.rotateQuery = numberPlaceholders(s.rotateQuery)
+		s.refreshTokenQuery = numberPlaceholders(s.refreshTokenQuery)
+		s.deleteQuery = numberPlaceholders(s.deleteQuery)
+	}
+	return s
+}
+
+// Load implements the oauth2.TokenStore interface.
+func (s *Store) Load(ctx context.Context, key string) (*oauth2.Token, error) {
+	var token oauth2.Token
+	var expiry, refreshExpiry, obtainedAt int64
+	var mode int
+	var raw string
+
+	row := s.db.QueryRowContext(ctx, s.loadQuery, key)
+	err := row.Scan(&token.AccessToken, &token.TokenType, &token.RefreshToken, &expiry, &refreshExpiry,
+		&obtainedAt, &token.GrantType, &token.Issuer, &token.TokenURL, &mode, &raw)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, oauth2.ErrTokenNotFound
+	case err != nil:
+		return nil, err
+	}
+
+	token.Expiry = fromUnixNano(expiry)
+	token.RefreshExpiry = fromUnixNano(refreshExpiry)
+	token.ObtainedAt = fromUnixNano(obtainedAt)
+	token.Mode = oauth2.Mode(mode)
+	if raw != "" {
+		_ = json.Unmarshal([]byte(raw), &token.Raw) // no error checks for optional fields
+	}
+	return &token, nil
+}
+
+// Save implements the oauth2.TokenStore interface.
+func (s *Store) Save(ctx context.Context, key string, token *oauth2.Token) error {
+	args, err := tokenArgs(key, token)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, s.upsertQuery, args...)
+	return err
+}
+
+// Rotate implements the oauth2.TokenRotator interface.
+// The token is inserted if there is no token for the key, like on the first refresh
+// of a token which was never saved, ErrTokenConflict means a token with another refresh token is stored.
+func (s *Store) Rotate(ctx context.Context, key, refreshToken string, token *oauth2.Token) error {
+	args, err := tokenArgs(key, token)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, s.rotateQuery, append(args, refreshToken)...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		// MySQL reports 0 rows when the values didn't change, so check what is stored.
+		var stored string
+		err := tx.QueryRowContext(ctx, s.refreshTokenQuery, key).Scan(&stored)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			if err := s.insert(ctx, tx, args); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case stored != refreshToken:
+			return oauth2.ErrTokenConflict
+		}
+	}
+	return tx.Commit()
+}
+
+// insert inserts a token unless a token for the key was inserted concurrently.
+func (s *Store) insert(ctx context.Context, tx *sql.Tx, args []interface{}) error {
+	res, err := tx.ExecContext(ctx, s.insertQuery, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	switch {
+	case err != nil:
+		return err
+	case n == 0:
+		return oauth2.ErrTokenConflict
+	default:
+		return nil
+	}
+}
+
+// Delete implements the oauth2.TokenStore interface.
+func (s *Store) Delete(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, s.deleteQuery, key)
+	return err
+}
+
+// tokenArgs returns query arguments in the order of tokenColumns followed by the key.
+func tokenArgs(key string, token *oauth2.Token) ([]interface{}, error) {
+	var raw []byte
+	if token.Raw != nil {
+		var err error
+		raw, err = json.Marshal(token.Raw)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	args := []interface{}{
+		token.AccessToken, token.TokenType, token.RefreshToken,
+		toUnixNano(token.Expiry), toUnixNano(token.RefreshExpiry), toUnixNano(token.ObtainedAt),
+		token.GrantType, token.Issuer, token.TokenURL, int64(token.Mode), string(raw), key,
+	}
+	return args, nil
+}
+
+// toUnixNano returns zero for the zero time, which is before the Unix epoch.
+func toUnixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// numberPlaceholders replaces `?` with `$1`, `$2`, ...
+func numberPlaceholders(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		fmt.Fprintf(&b, "$%d", n)
+	}
+	return b.String()
+}
@@ -0,0 +1,213 @@
+package oauth2sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cristalhq/oauth2"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(openFakeDB(t), "oauth2_tokens", MySQL)
+
+	_, err := store.Load(ctx, "user-1")
+	mustEqual(t, errors.Is(err, oauth2.ErrTokenNotFound), true)
+
+	now := time.Now().Round(0)
+	token := &oauth2.Token{
+		AccessToken:   "ACCESS_TOKEN",
+		TokenType:     "bearer",
+		RefreshToken:  "REFRESH_TOKEN",
+		Expiry:        now.Add(time.Hour),
+		RefreshExpiry: now.Add(24 * time.Hour),
+		Raw:           map[string]interface{}{"scope": "user"},
+		ObtainedAt:    now,
+		GrantType:     "authorization_code",
+		Issuer:        "https://issuer.example.com",
+		TokenURL:      "https://issuer.example.com/token",
+		Mode:          oauth2.InParamsMode,
+	}
+	mustOk(t, store.Save(ctx, "user-1", token))
+	mustOk(t, store.Save(ctx, "user-1", token))
+
+	loaded, err := store.Load(ctx, "user-1")
+	mustOk(t, err)
+	mustEqual(t, loaded.AccessToken, token.AccessToken)
+	mustEqual(t, loaded.TokenType, token.TokenType)
+	mustEqual(t, loaded.RefreshToken, token.RefreshToken)
+	mustEqual(t, loaded.Expiry.Equal(token.Expiry), true)
+	mustEqual(t, loaded.RefreshExpiry.Equal(token.RefreshExpiry), true)
+	mustEqual(t, loaded.ObtainedAt.Equal(token.ObtainedAt), true)
+	mustEqual(t, loaded.GrantType, token.GrantType)
+	mustEqual(t, loaded.Issuer, token.Issuer)
+	mustEqual(t, loaded.TokenURL, token.TokenURL)
+	mustEqual(t, loaded.Mode, token.Mode)
+	mustEqual(t, loaded.Extra("scope"), "user")
+
+	mustOk(t, store.Delete(ctx, "user-1"))
+	_, err = store.Load(ctx, "user-1")
+	mustEqual(t, errors.Is(err, oauth2.ErrTokenNotFound), true)
+}
+
+func TestStore_Rotate(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(openFakeDB(t), "oauth2_tokens", MySQL)
+
+	// the first refresh of a token which was never saved.
+	mustOk(t, store.Rotate(ctx, "user-1", "R0", &oauth2.Token{AccessToken: "A1", RefreshToken: "R1"}))
+	mustOk(t, store.Rotate(ctx, "user-1", "R1", &oauth2.Token{AccessToken: "A2", RefreshToken: "R2"}))
+
+	// the same token again, MySQL reports no affected rows.
+	mustOk(t, store.Rotate(ctx, "user-1", "R2", &oauth2.Token{AccessToken: "A2", RefreshToken: "R2"}))
+
+	err := store.Rotate(ctx, "user-1", "R1", &oauth2.Token{AccessToken: "A3", RefreshToken: "R3"})
+	mustEqual(t, err, oauth2.ErrTokenConflict)
+
+	loaded, err := store.Load(ctx, "user-1")
+	mustOk(t, err)
+	mustEqual(t, loaded.RefreshToken, "R2")
+}
+
+func TestQueries(t *testing.T) {
+	store := NewStore(nil, "t", Postgres)
+	mustEqual(t, store.loadQuery, `SELECT access_token, token_type, refresh_token, expiry, refresh_expiry, obtained_at, grant_type, issuer, token_url, mode, raw FROM t WHERE token_key = $1`)
+	mustEqual(t, store.rotateQuery, `UPDATE t SET access_token = $1, token_type = $2, refresh_token = $3, expiry = $4, refresh_expiry = $5, obtained_at = $6, grant_type = $7, issuer = $8, token_url = $9, mode = $10, raw = $11 WHERE token_key = $12 AND refresh_token = $13`)
+	mustEqual(t, strings.HasSuffix(store.upsertQuery, `ON CONFLICT (token_key) DO UPDATE SET access_token = EXCLUDED.access_token, token_type = EXCLUDED.token_type, refresh_token = EXCLUDED.refresh_token, expiry = EXCLUDED.expiry, refresh_expiry = EXCLUDED.refresh_expiry, obtained_at = EXCLUDED.obtained_at, grant_type = EXCLUDED.grant_type, issuer = EXCLUDED.issuer, token_url = EXCLUDED.token_url, mode = EXCLUDED.mode, raw = EXCLUDED.raw`), true)
+
+	store = NewStore(nil, "t", MySQL)
+	mustEqual(t, strings.HasSuffix(store.upsertQuery, `VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE access_token = VALUES(access_token), token_type = VALUES(token_type), refresh_token = VALUES(refresh_token), expiry = VALUES(expiry), refresh_expiry = VALUES(refresh_expiry), obtained_at = VALUES(obtained_at), grant_type = VALUES(grant_type), issuer = VALUES(issuer), token_url = VALUES(token_url), mode = VALUES(mode), raw = VALUES(raw)`), true)
+}
+
+func openFakeDB(tb testing.TB) *sql.DB {
+	tb.Helper()
+	db := sql.OpenDB(&fakeConnector{rows: map[string][]driver.Value{}})
+	tb.Cleanup(func() { db.Close() })
+	return db
+}
+
+// fakeConnector is a database/sql driver which understands only queries of Store.
+type fakeConnector struct {
+	mu   sync.Mutex
+	rows map[string][]driver.Value // token_key => values of tokenColumns
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) { return &fakeConn{c}, nil }
+func (c *fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ c *fakeConnector }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{c.c, query}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	c     *fakeConnector
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+
+	n := len(tokenColumns)
+	switch {
+	case strings.HasPrefix(s.query, "INSERT"):
+		key := args[n].(string)
+		row, ok := s.c.rows[key]
+		switch {
+		case !ok:
+			s.c.rows[key] = args[:n]
+			return driver.RowsAffected(1), nil
+		case strings.HasSuffix(s.query, "token_key = token_key") || strings.HasSuffix(s.query, "DO NOTHING"):
+			return driver.RowsAffected(0), nil
+		case reflect.DeepEqual(row, args[:n]):
+			return driver.RowsAffected(0), nil // like MySQL for unchanged rows.
+		default:
+			s.c.rows[key] = args[:n]
+			return driver.RowsAffected(2), nil
+		}
+
+	case strings.HasPrefix(s.query, "UPDATE"):
+		key := args[n].(string)
+		row, ok := s.c.rows[key]
+		if !ok || row[2] != args[n+1] || reflect.DeepEqual(row, args[:n]) {
+			return driver.RowsAffected(0), nil
+		}
+		s.c.rows[key] = args[:n]
+		return driver.RowsAffected(1), nil
+
+	case strings.HasPrefix(s.query, "DELETE"):
+		delete(s.c.rows, args[0].(string))
+		return driver.RowsAffected(1), nil
+
+	default:
+		panic("unexpected query: " + s.query)
+	}
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+
+	columns := tokenColumns
+	if strings.HasPrefix(s.query, "SELECT refresh_token FROM") {
+		columns = []string{"refresh_token"}
+	}
+	row, ok := s.c.rows[args[0].(string)]
+	if !ok {
+		return &fakeRows{columns: columns}, nil
+	}
+	if len(columns) == 1 {
+		row = row[2:3]
+	}
+	return &fakeRows{columns: columns, row: row}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	row     []driver.Value
+	done    bool
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.row == nil || r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.row)
+	return nil
+}
+
+func mustOk(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func mustEqual[T any](tb testing.TB, have, want T) {
+	tb.Helper()
+	if !reflect.DeepEqual(have, want) {
+		tb.Fatalf("\nhave: %+v\nwant: %+v\n", have, want)
+	}
+}
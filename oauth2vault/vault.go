@@ -0,0 +1,144 @@
+// Package oauth2vault implements oauth2.SecretProvider on top of HashiCorp Vault KV secrets engine,
+// so client secrets can be rotated in Vault without restarting services.
+//
+// The package doesn't depend on the Vault SDK, it uses the Vault HTTP API directly.
+package oauth2vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cristalhq/oauth2"
+)
+
+// Config describes where the client secret is kept in Vault.
+type Config struct {
+	Address   string        // Address of Vault, like `https://vault.example.com:8200`.
+	Token     string        // Token is a Vault token, used if TokenFunc isn't set.
+	Namespace string        // Namespace is an optional Vault Enterprise namespace.
+	Mount     string        // Mount is a KV version 2 mount path, defaults to `secret`.
+	Path      string        // Path of the secret in the mount, required.
+	Field     string        // Field of the secret with the client secret, defaults to `client_secret`.
+	CacheTTL  time.Duration // CacheTTL is how long a fetched secret is used, zero means fetching it on every call.
+
+	// TokenFunc optionally returns a Vault token per request, for tokens renewed elsewhere.
+	TokenFunc func(ctx context.Context) (string, error)
+
+	_ struct{} // enforce explicit field names.
+}
+
+// NewSecretProvider returns an oauth2.SecretProvider reading the secret from Vault with the HTTP client.
+//
+// The secret is cached for Config.CacheTTL, if Vault fails the last fetched secret is used
+// until it's twice as old as CacheTTL.
+func NewSecretProvider(client *http.Client, config Config) (oauth2.SecretProvider, error) {
+	switch {
+	case config.Address == "":
+		return nil, errors.New("oauth2vault: address is not set")
+	case config.Path == "":
+		return nil, errors.New("oauth2vault: path is not set")
+	case config.Token == "" && config.TokenFunc == nil:
+		return nil, errors.New("oauth2vault: token is not set")
+	}
+	if config.Mount == "" {
+		config.Mount = "secret"
+	}
+	if config.Field == "" {
+		config.Field = "client_secret"
+	}
+
+	p := &provider{
+		client: client,
+		config: config,
+		url: strings.TrimSuffix(config.Address, "/") + "/v1/" +
+			strings.Trim(config.Mount, "/") + "/data/" + strings.TrimPrefix(config.Path, "/"),
+	}
+	return p.secret, nil
+}
+
+type provider struct {
+	client *http.Client
+	config Config
+	url    string
+
+	mu        sync.Mutex
+	value     string
+	fetchedAt time.Time
+}
+
+var timeNow = time.Now
+
+func (p *provider) secret(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	age := timeNow().Sub(p.fetchedAt)
+	if p.value != "" && age < p.config.CacheTTL {
+		return p.value, nil
+	}
+
+	value, err := p.fetch(ctx)
+	if err != nil {
+		if p.value != "" && age < 2*p.config.CacheTTL {
+			return p.value, nil
+		}
+		return "", err
+	}
+	p.value, p.fetchedAt = value, timeNow()
+	return value, nil
+}
+
+func (p *provider) fetch(ctx context.Context) (string, error) {
+	token := p.config.Token
+	if p.config.TokenFunc != nil {
+		var err error
+		if token, err = p.config.TokenFunc(ctx); err != nil {
+			return "", fmt.Errorf("oauth2vault: cannot get vault token: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, http.NoBody)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if p.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.config.Namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("oauth2vault: unexpected status %v: %s", resp.Status, body)
+	}
+
+	var secret struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("oauth2vault: malformed response: %w", err)
+	}
+
+	value, _ := secret.Data.Data[p.config.Field].(string)
+	if value == "" {
+		return "", fmt.Errorf("oauth2vault: field %q not found in %s", p.config.Field, p.config.Path)
+	}
+	return value, nil
+}
@@ -0,0 +1,118 @@
+package oauth2vault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSecretProvider(t *testing.T) {
+	secret, status := "SECRET_1", http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mustEqual(t, r.URL.Path, "/v1/kv/data/apps/billing")
+		mustEqual(t, r.Header.Get("X-Vault-Token"), "VAULT_TOKEN")
+		mustEqual(t, r.Header.Get("X-Vault-Namespace"), "team")
+
+		w.WriteHeader(status)
+		w.Write([]byte(`{"data": {"data": {"oauth_secret": "` + secret + `"}, "metadata": {"version": 1}}}`))
+	}))
+	defer ts.Close()
+
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	provider, err := NewSecretProvider(http.DefaultClient, Config{
+		Address:   ts.URL + "/",
+		Token:     "VAULT_TOKEN",
+		Namespace: "team",
+		Mount:     "kv",
+		Path:      "apps/billing",
+		Field:     "oauth_secret",
+		CacheTTL:  time.Minute,
+	})
+	mustOk(t, err)
+
+	ctx := context.Background()
+	value, err := provider(ctx)
+	mustOk(t, err)
+	mustEqual(t, value, "SECRET_1")
+
+	// rotated in vault, cached value is used until CacheTTL.
+	secret = "SECRET_2"
+	value, err = provider(ctx)
+	mustOk(t, err)
+	mustEqual(t, value, "SECRET_1")
+
+	now = now.Add(time.Minute)
+	value, err = provider(ctx)
+	mustOk(t, err)
+	mustEqual(t, value, "SECRET_2")
+
+	// vault is down, stale value is used until twice CacheTTL.
+	status = http.StatusServiceUnavailable
+	now = now.Add(90 * time.Second)
+	value, err = provider(ctx)
+	mustOk(t, err)
+	mustEqual(t, value, "SECRET_2")
+
+	now = now.Add(time.Minute)
+	_, err = provider(ctx)
+	mustFail(t, err)
+}
+
+func TestSecretProviderFieldNotFound(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mustEqual(t, r.URL.Path, "/v1/secret/data/app")
+		w.Write([]byte(`{"data": {"data": {"other": "value"}}}`))
+	}))
+	defer ts.Close()
+
+	provider, err := NewSecretProvider(http.DefaultClient, Config{
+		Address: ts.URL,
+		Path:    "app",
+		TokenFunc: func(ctx context.Context) (string, error) {
+			return "VAULT_TOKEN", nil
+		},
+	})
+	mustOk(t, err)
+
+	_, err = provider(context.Background())
+	mustFail(t, err)
+	mustEqual(t, err.Error(), `oauth2vault: field "client_secret" not found in app`)
+}
+
+func TestNewSecretProviderValidation(t *testing.T) {
+	_, err := NewSecretProvider(http.DefaultClient, Config{Path: "app", Token: "t"})
+	mustFail(t, err)
+
+	_, err = NewSecretProvider(http.DefaultClient, Config{Address: "http://vault", Token: "t"})
+	mustFail(t, err)
+
+	_, err = NewSecretProvider(http.DefaultClient, Config{Address: "http://vault", Path: "app"})
+	mustFail(t, err)
+}
+
+func mustOk(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func mustFail(tb testing.TB, err error) {
+	tb.Helper()
+	if err == nil {
+		tb.Fatal()
+	}
+}
+
+func mustEqual[T any](tb testing.TB, have, want T) {
+	tb.Helper()
+	if !reflect.DeepEqual(have, want) {
+		tb.Fatalf("\nhave: %+v\nwant: %+v\n", have, want)
+	}
+}
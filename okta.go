@@ -0,0 +1,52 @@
+package oauth2
+
+import (
+	"strings"
+)
+
+// OktaConfig returns a Config with Okta endpoints of the authorization server.
+// Org URL is the Okta organization URL, like `https://example.okta.com`.
+// Auth server ID is a custom authorization server, like `default`,
+// an empty ID means the org authorization server.
+// Client fields must be set by the caller.
+func OktaConfig(orgURL, authServerID string) Config {
+	issuer := strings.TrimSuffix(orgURL, "/")
+	if authServerID != "" {
+		issuer += "/oauth2/" + authServerID
+	}
+
+	// the org authorization server has no ID in paths, but its issuer is the org URL.
+	prefix := issuer + "/v1"
+	if authServerID == "" {
+		prefix = issuer + "/oauth2/v1"
+	}
+
+	return Config{
+		Issuer:           issuer,
+		AuthURL:          prefix + "/authorize",
+		TokenURL:         prefix + "/token",
+		DeviceURL:        prefix + "/device/authorize",
+		IntrospectionURL: prefix + "/introspect",
+		RevocationURL:    prefix + "/revoke",
+		UserInfoURL:      prefix + "/userinfo",
+	}
+}
+
+// Auth0Config returns a Config with Auth0 endpoints of the tenant.
+// Domain is the tenant or custom domain, like `example.us.auth0.com`, the `https://` scheme is optional.
+// Client fields must be set by the caller, Audience is needed to get JWT access tokens for an API.
+func Auth0Config(domain string) Config {
+	base := strings.TrimSuffix(domain, "/")
+	if !strings.Contains(base, "://") {
+		base = "https://" + base
+	}
+
+	return Config{
+		Issuer:        base + "/", // Auth0 issuers have a trailing slash.
+		AuthURL:       base + "/authorize",
+		TokenURL:      base + "/oauth/token",
+		DeviceURL:     base + "/oauth/device/code",
+		RevocationURL: base + "/oauth/revoke",
+		UserInfoURL:   base + "/userinfo",
+	}
+}
@@ -0,0 +1,38 @@
+package oauth2
+
+import (
+	"testing"
+)
+
+func TestOktaConfig(t *testing.T) {
+	cfg := OktaConfig("https://example.okta.com/", "default")
+
+	mustEqual(t, cfg.Issuer, "https://example.okta.com/oauth2/default")
+	mustEqual(t, cfg.AuthURL, "https://example.okta.com/oauth2/default/v1/authorize")
+	mustEqual(t, cfg.TokenURL, "https://example.okta.com/oauth2/default/v1/token")
+	mustEqual(t, cfg.DeviceURL, "https://example.okta.com/oauth2/default/v1/device/authorize")
+	mustEqual(t, cfg.IntrospectionURL, "https://example.okta.com/oauth2/default/v1/introspect")
+	mustEqual(t, cfg.RevocationURL, "https://example.okta.com/oauth2/default/v1/revoke")
+	mustEqual(t, cfg.UserInfoURL, "https://example.okta.com/oauth2/default/v1/userinfo")
+	mustOk(t, cfg.Validate())
+
+	org := OktaConfig("https://example.okta.com", "")
+
+	mustEqual(t, org.Issuer, "https://example.okta.com")
+	mustEqual(t, org.AuthURL, "https://example.okta.com/oauth2/v1/authorize")
+	mustEqual(t, org.TokenURL, "https://example.okta.com/oauth2/v1/token")
+}
+
+func TestAuth0Config(t *testing.T) {
+	cfg := Auth0Config("example.us.auth0.com")
+
+	mustEqual(t, cfg.Issuer, "https://example.us.auth0.com/")
+	mustEqual(t, cfg.AuthURL, "https://example.us.auth0.com/authorize")
+	mustEqual(t, cfg.TokenURL, "https://example.us.auth0.com/oauth/token")
+	mustEqual(t, cfg.DeviceURL, "https://example.us.auth0.com/oauth/device/code")
+	mustEqual(t, cfg.RevocationURL, "https://example.us.auth0.com/oauth/revoke")
+	mustEqual(t, cfg.UserInfoURL, "https://example.us.auth0.com/userinfo")
+	mustOk(t, cfg.Validate())
+
+	mustEqual(t, Auth0Config("https://login.example.com/").TokenURL, "https://login.example.com/oauth/token")
+}
@@ -0,0 +1,71 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// AuthURLTooLongError is returned when an auth code URL is longer than Config.MaxAuthURLLength.
+type AuthURLTooLongError struct {
+	Length int // Length is the length of the URL.
+	Max    int // Max is Config.MaxAuthURLLength.
+}
+
+func (e *AuthURLTooLongError) Error() string {
+	return fmt.Sprintf("oauth2: auth URL is too long: %d > %d", e.Length, e.Max)
+}
+
+// AuthCodeURLContext same as BuildAuthCodeURL but pushes the authorization request to Config.PushedAuthURL
+// when the URL is longer than Config.MaxAuthURLLength, see PushAuthCodeURL.
+func (c *Client) AuthCodeURLContext(ctx context.Context, state string, params url.Values) (string, error) {
+	u, err := c.BuildAuthCodeURL(state, params)
+
+	var tooLong *AuthURLTooLongError
+	if errors.As(err, &tooLong) && c.config.PushedAuthURL != "" {
+		return c.PushAuthCodeURL(ctx, state, params)
+	}
+	return u, err
+}
+
+// PushAuthCodeURL sends the authorization request to Config.PushedAuthURL
+// and returns a short auth code URL with the `request_uri` of it, RFC 9126.
+// The client authenticates like at the token endpoint.
+func (c *Client) PushAuthCodeURL(ctx context.Context, state string, params url.Values) (string, error) {
+	if c.config.PushedAuthURL == "" {
+		return "", errors.New("oauth2: pushed auth URL is not set")
+	}
+	u, err := c.parseAuthURL()
+	if err != nil {
+		return "", err
+	}
+	v, err := c.authCodeParams(state, params)
+	if err != nil {
+		return "", err
+	}
+
+	mode := c.requestMode(ctx, InHeaderMode)
+
+	body, err := c.postForm(ctx, c.config.PushedAuthURL, mode, v)
+	if err != nil {
+		return "", fmt.Errorf("oauth2: cannot push auth request: %w", err)
+	}
+
+	var resp struct {
+		RequestURI string `json:"request_uri"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("oauth2: malformed pushed auth response: %w", err)
+	}
+	if resp.RequestURI == "" {
+		return "", errors.New("oauth2: server response missing request_uri")
+	}
+
+	pushed := url.Values{
+		"client_id":   []string{c.config.ClientID},
+		"request_uri": []string{resp.RequestURI},
+	}
+	return encodeAuthURL(u, pushed), nil
+}
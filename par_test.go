@@ -0,0 +1,70 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMaxAuthURLLength(t *testing.T) {
+	client := newClientWithConfig(Config{
+		ClientID:         "CLIENT_ID",
+		AuthURL:          "https://example.com/auth",
+		MaxAuthURLLength: 100,
+	})
+
+	_, err := client.BuildAuthCodeURL("state", nil)
+	mustOk(t, err)
+
+	details := url.Values{"authorization_details": {strings.Repeat("x", 100)}}
+	_, err = client.BuildAuthCodeURL("state", details)
+
+	var tooLong *AuthURLTooLongError
+	mustEqual(t, errors.As(err, &tooLong), true)
+	mustEqual(t, tooLong.Max, 100)
+	mustEqual(t, client.AuthCodeURLWithParams("state", details), "")
+
+	// no pushed auth URL, nowhere to fall back.
+	_, err = client.AuthCodeURLContext(context.Background(), "state", details)
+	mustEqual(t, errors.As(err, &tooLong), true)
+}
+
+func TestPushAuthCodeURL(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		mustEqual(t, r.URL.Path, "/par")
+		mustEqual(t, r.FormValue("state"), "state")
+		mustEqual(t, r.FormValue("response_type"), "code")
+		mustEqual(t, len(r.FormValue("authorization_details")), 3000)
+
+		user, pass, ok := r.BasicAuth()
+		mustEqual(t, ok, true)
+		mustEqual(t, user, "CLIENT_ID")
+		mustEqual(t, pass, "CLIENT_SECRET")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"request_uri": "urn:ietf:params:oauth:request_uri:6esc_11ACC5bwc014ltc14eY22c", "expires_in": 60}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		ClientID:         "CLIENT_ID",
+		ClientSecret:     "CLIENT_SECRET",
+		AuthURL:          "https://example.com/auth?tenant=acme",
+		PushedAuthURL:    ts.URL + "/par",
+		MaxAuthURLLength: 2048,
+	})
+
+	u, err := client.AuthCodeURLContext(context.Background(), "state", nil)
+	mustOk(t, err)
+	mustEqual(t, strings.Contains(u, "state=state"), true)
+
+	details := url.Values{"authorization_details": {strings.Repeat("x", 3000)}}
+	u, err = client.AuthCodeURLContext(context.Background(), "state", details)
+	mustOk(t, err)
+	mustEqual(t, u, "https://example.com/auth?tenant=acme&client_id=CLIENT_ID&request_uri=urn%3Aietf%3Aparams%3Aoauth%3Arequest_uri%3A6esc_11ACC5bwc014ltc14eY22c")
+}
@@ -0,0 +1,46 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Ping checks that the provider is reachable, for readiness probes of services depending on it.
+// It fetches the discovery metadata when Config.Issuer is set and sends an empty POST
+// to the token endpoint: any response except 5xx means the endpoint is up, as the request
+// is expected to be rejected. TLS errors and unreachable endpoints are reported.
+func (c *Client) Ping(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok && c.config.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.RequestTimeout)
+		defer cancel()
+	}
+
+	if c.config.Issuer != "" {
+		if _, err := Discover(ctx, c.client, c.config.Issuer); err != nil {
+			return err
+		}
+	}
+
+	if err := c.config.checkEndpoint("token URL", c.config.TokenURL); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.TokenURL, http.NoBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("oauth2: token endpoint is unreachable: %w", err)
+	}
+	if _, err := readBody(resp); err != nil {
+		return fmt.Errorf("oauth2: token endpoint is unreachable: %w", err)
+	}
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("oauth2: token endpoint is unavailable: %v", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	status := http.StatusBadRequest
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		mustEqual(t, r.Method, http.MethodPost)
+		mustEqual(t, r.URL.Path, "/token")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprint(w, `{"error": "invalid_request"}`)
+	})
+	defer ts.Close()
+
+	client := newClient(ts.URL)
+	mustOk(t, client.Ping(context.Background()))
+
+	status = http.StatusServiceUnavailable
+	mustFail(t, client.Ping(context.Background()))
+
+	ts.Close()
+	mustFail(t, client.Ping(context.Background()))
+}
+
+func TestPing_Issuer(t *testing.T) {
+	var issuer string
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			fmt.Fprintf(w, `{"issuer": %q}`, issuer)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	defer ts.Close()
+	issuer = ts.URL
+
+	client := newClientWithConfig(Config{Issuer: issuer, TokenURL: ts.URL + "/token"})
+	mustOk(t, client.Ping(context.Background()))
+
+	client = newClientWithConfig(Config{Issuer: issuer + "/other", TokenURL: ts.URL + "/token"})
+	mustFail(t, client.Ping(context.Background()))
+}
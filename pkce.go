@@ -0,0 +1,155 @@
+package oauth2
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// PKCE code challenge methods, RFC 7636 section 4.2.
+const (
+	PKCEMethodS256  = "S256"
+	PKCEMethodPlain = "plain"
+)
+
+// pkceCharset is a set of unreserved characters allowed in a code verifier, RFC 7636 section 4.1.
+const pkceCharset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-._~"
+
+// PKCEPolicy describes how PKCE code verifiers are generated.
+type PKCEPolicy struct {
+	// Method is a code challenge method, defaults to S256.
+	Method string
+
+	// AllowPlain allows the `plain` method, for legacy providers without S256 support only.
+	AllowPlain bool
+
+	// VerifierLength is a length of the code verifier from 43 to 128, defaults to 64.
+	VerifierLength int
+
+	// Charset is a set of characters of the code verifier, defaults to all unreserved characters.
+	// It must be a subset of them.
+	Charset string
+
+	_ struct{} // enforce explicit field names.
+}
+
+// PKCE is a code verifier and its challenge for an authorization request, RFC 7636.
+type PKCE struct {
+	Verifier  string // Verifier is sent with the code exchange, it must be kept secret until then.
+	Challenge string // Challenge is sent with the authorization request.
+	Method    string // Method is a code challenge method.
+}
+
+// NewPKCE generates a new code verifier and challenge according to the policy.
+func NewPKCE(policy PKCEPolicy) (*PKCE, error) {
+	method, err := policy.method()
+	if err != nil {
+		return nil, err
+	}
+
+	length := policy.VerifierLength
+	switch {
+	case length == 0:
+		length = 64
+	case length < 43 || length > 128:
+		return nil, fmt.Errorf("oauth2: PKCE verifier length must be from 43 to 128, got %d", length)
+	}
+
+	charset := policy.Charset
+	if charset == "" {
+		charset = pkceCharset
+	}
+	for _, c := range charset {
+		if !strings.ContainsRune(pkceCharset, c) {
+			return nil, fmt.Errorf("oauth2: PKCE verifier charset has a reserved character %q", c)
+		}
+	}
+	if len(charset) < 2 {
+		return nil, errors.New("oauth2: PKCE verifier charset is too small")
+	}
+
+	verifier, err := randomFromCharset(length, charset)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &PKCE{
+		Verifier:  verifier,
+		Challenge: verifier,
+		Method:    method,
+	}
+	if method == PKCEMethodS256 {
+		sum := sha256.Sum256([]byte(verifier))
+		p.Challenge = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+	return p, nil
+}
+
+// CheckProvider reports an error if the provider doesn't support the policy method,
+// for example from `code_challenge_methods_supported` of the provider metadata.
+// The method is never downgraded to what the provider supports.
+func (policy PKCEPolicy) CheckProvider(supported []string) error {
+	method, err := policy.method()
+	if err != nil {
+		return err
+	}
+	for _, m := range supported {
+		if m == method {
+			return nil
+		}
+	}
+	return fmt.Errorf("oauth2: provider doesn't support PKCE method %s, supported: %q", method, supported)
+}
+
+func (policy PKCEPolicy) method() (string, error) {
+	switch policy.Method {
+	case "", PKCEMethodS256:
+		return PKCEMethodS256, nil
+	case PKCEMethodPlain:
+		if !policy.AllowPlain {
+			return "", errors.New("oauth2: PKCE plain method is not allowed")
+		}
+		return PKCEMethodPlain, nil
+	default:
+		return "", fmt.Errorf("oauth2: unknown PKCE method %q", policy.Method)
+	}
+}
+
+// AuthParams returns parameters for AuthCodeURLWithParams.
+func (p *PKCE) AuthParams() url.Values {
+	return url.Values{
+		"code_challenge":        []string{p.Challenge},
+		"code_challenge_method": []string{p.Method},
+	}
+}
+
+// ExchangeParams returns parameters for ExchangeWithParams.
+func (p *PKCE) ExchangeParams() url.Values {
+	return url.Values{
+		"code_verifier": []string{p.Verifier},
+	}
+}
+
+// randomFromCharset returns a random string of the characters without a modulo bias.
+func randomFromCharset(length int, charset string) (string, error) {
+	// the largest multiple of len(charset) in a byte.
+	limit := 256 - 256%len(charset)
+
+	b := make([]byte, 0, length)
+	buf := make([]byte, length)
+	for len(b) < length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, r := range buf {
+			if int(r) < limit && len(b) < length {
+				b = append(b, charset[int(r)%len(charset)])
+			}
+		}
+	}
+	return string(b), nil
+}
@@ -0,0 +1,59 @@
+package oauth2
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestNewPKCE(t *testing.T) {
+	p, err := NewPKCE(PKCEPolicy{})
+	mustOk(t, err)
+	mustEqual(t, len(p.Verifier), 64)
+	mustEqual(t, p.Method, PKCEMethodS256)
+
+	sum := sha256.Sum256([]byte(p.Verifier))
+	mustEqual(t, p.Challenge, base64.RawURLEncoding.EncodeToString(sum[:]))
+
+	mustEqual(t, p.AuthParams().Get("code_challenge"), p.Challenge)
+	mustEqual(t, p.AuthParams().Get("code_challenge_method"), "S256")
+	mustEqual(t, p.ExchangeParams().Get("code_verifier"), p.Verifier)
+
+	p2, err := NewPKCE(PKCEPolicy{})
+	mustOk(t, err)
+	mustEqual(t, p2.Verifier != p.Verifier, true)
+}
+
+func TestNewPKCE_Policy(t *testing.T) {
+	p, err := NewPKCE(PKCEPolicy{VerifierLength: 43, Charset: "abc"})
+	mustOk(t, err)
+	mustEqual(t, len(p.Verifier), 43)
+	mustEqual(t, strings.Trim(p.Verifier, "abc"), "")
+
+	p, err = NewPKCE(PKCEPolicy{Method: PKCEMethodPlain, AllowPlain: true})
+	mustOk(t, err)
+	mustEqual(t, p.Challenge, p.Verifier)
+	mustEqual(t, p.Method, "plain")
+
+	badPolicies := []PKCEPolicy{
+		{Method: PKCEMethodPlain},
+		{Method: "S512"},
+		{VerifierLength: 42},
+		{VerifierLength: 129},
+		{Charset: "abc/"},
+		{Charset: "a"},
+	}
+	for _, policy := range badPolicies {
+		_, err := NewPKCE(policy)
+		mustFail(t, err)
+	}
+}
+
+func TestPKCEPolicy_CheckProvider(t *testing.T) {
+	mustOk(t, PKCEPolicy{}.CheckProvider([]string{"plain", "S256"}))
+	mustFail(t, PKCEPolicy{}.CheckProvider([]string{"plain"}))
+	mustFail(t, PKCEPolicy{}.CheckProvider(nil))
+	mustOk(t, PKCEPolicy{Method: PKCEMethodPlain, AllowPlain: true}.CheckProvider([]string{"plain"}))
+	mustFail(t, PKCEPolicy{Method: PKCEMethodPlain}.CheckProvider([]string{"plain"}))
+}
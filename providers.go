@@ -0,0 +1,67 @@
+package oauth2
+
+import (
+	"net/http"
+)
+
+// Presets of providers with quirks, the mode is pinned so AutoDetectMode doesn't waste a failing request.
+// Client fields must be set by the caller.
+
+// SpotifyConfig returns a Config with Spotify endpoints.
+// Spotify accepts client credentials only in the Basic Authorization header.
+func SpotifyConfig() Config {
+	return Config{
+		AuthURL:  "https://accounts.spotify.com/authorize",
+		TokenURL: "https://accounts.spotify.com/api/token",
+		Mode:     InHeaderMode,
+	}
+}
+
+// RedditConfig returns a Config with Reddit endpoints.
+// Reddit accepts client credentials only in the Basic Authorization header
+// and throttles requests with generic User-Agents, set a descriptive one in Config.Header.
+func RedditConfig() Config {
+	return Config{
+		AuthURL:       "https://www.reddit.com/api/v1/authorize",
+		TokenURL:      "https://www.reddit.com/api/v1/access_token",
+		RevocationURL: "https://www.reddit.com/api/v1/revoke_token",
+		Mode:          InHeaderMode,
+	}
+}
+
+// FitbitConfig returns a Config with Fitbit endpoints.
+// Fitbit accepts client credentials only in the Basic Authorization header.
+func FitbitConfig() Config {
+	return Config{
+		AuthURL:          "https://www.fitbit.com/oauth2/authorize",
+		TokenURL:         "https://api.fitbit.com/oauth2/token",
+		IntrospectionURL: "https://api.fitbit.com/1.1/oauth2/introspect",
+		RevocationURL:    "https://api.fitbit.com/oauth2/revoke",
+		Mode:             InHeaderMode,
+	}
+}
+
+// XConfig returns a Config with X (formerly Twitter) OAuth 2.0 endpoints.
+// Confidential clients must send credentials in the Basic Authorization header.
+func XConfig() Config {
+	return Config{
+		AuthURL:       "https://twitter.com/i/oauth2/authorize",
+		TokenURL:      "https://api.twitter.com/2/oauth2/token",
+		RevocationURL: "https://api.twitter.com/2/oauth2/revoke",
+		Mode:          InHeaderMode,
+	}
+}
+
+// GitHubConfig returns a Config with GitHub endpoints.
+// GitHub returns form-encoded token responses unless JSON is asked for with the Accept header,
+// and errors with a 200 status, see ErrorFieldResponseError.
+func GitHubConfig() Config {
+	return Config{
+		AuthURL:       "https://github.com/login/oauth/authorize",
+		TokenURL:      "https://github.com/login/oauth/access_token",
+		DeviceURL:     "https://github.com/login/device/code",
+		Mode:          InParamsMode,
+		Header:        http.Header{"Accept": []string{"application/json"}},
+		ResponseError: ErrorFieldResponseError,
+	}
+}
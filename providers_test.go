@@ -0,0 +1,44 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestProviderPresets(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"spotify": SpotifyConfig(),
+		"reddit":  RedditConfig(),
+		"fitbit":  FitbitConfig(),
+		"x":       XConfig(),
+		"github":  GitHubConfig(),
+	} {
+		mustOk(t, cfg.Validate())
+		if cfg.Mode == AutoDetectMode {
+			t.Fatalf("%s: mode is not pinned", name)
+		}
+	}
+}
+
+func TestConfigHeader(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		mustEqual(t, r.Header.Get("Accept"), "application/json")
+		mustEqual(t, r.Header.Get("User-Agent"), "app/1.0")
+		mustEqual(t, r.Header.Get("Content-Type"), "application/x-www-form-urlencoded")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ProperToken"}`)
+	})
+	defer ts.Close()
+
+	cfg := GitHubConfig()
+	cfg.ClientID = "CLIENT_ID"
+	cfg.TokenURL = ts.URL
+	cfg.Header.Set("User-Agent", "app/1.0")
+
+	tok, err := newClientWithConfig(cfg).Exchange(context.Background(), "code")
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "ProperToken")
+}
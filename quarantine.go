@@ -0,0 +1,96 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrTokenQuarantined matches *QuarantineError with errors.Is.
+var ErrTokenQuarantined = errors.New("oauth2: refresh token is quarantined")
+
+// QuarantineError is returned by TokenSource when the refresh token is quarantined
+// after repeated `invalid_grant` failures, see TokenSourceConfig.QuarantineAfter.
+// The token is not refreshed until the quarantine ends, it's released with TokenSource.Release
+// or the token is replaced, so the provider isn't asked again on every request.
+type QuarantineError struct {
+	Until time.Time // Until is when the quarantine ends, zero means until released.
+	Err   error     // Err is the last refresh error.
+}
+
+func (e *QuarantineError) Error() string {
+	msg := "oauth2: refresh token is quarantined"
+	if !e.Until.IsZero() {
+		msg += " until " + e.Until.Format(time.RFC3339)
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *QuarantineError) Is(target error) bool { return target == ErrTokenQuarantined }
+
+func (e *QuarantineError) Unwrap() error { return e.Err }
+
+// quarantine tracks repeatedly rejected refresh tokens of a TokenSource, guarded by TokenSource.mu.
+type quarantine struct {
+	failures     int       // failures is a number of consecutive invalid_grant failures.
+	refreshToken string    // refreshToken is the quarantined refresh token, empty if none.
+	until        time.Time // until is when the quarantine ends, zero means until released.
+	err          error     // err is the refresh error which caused the quarantine.
+}
+
+// Release ends the quarantine of the refresh token, if any, the next Token call refreshes it again.
+func (ts *TokenSource) Release() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.quarantine = quarantine{}
+}
+
+// checkQuarantine returns *QuarantineError if the current refresh token is quarantined.
+func (ts *TokenSource) checkQuarantine() error {
+	q := &ts.quarantine
+	if q.refreshToken == "" {
+		return nil
+	}
+	if q.refreshToken != ts.token.RefreshToken || (!q.until.IsZero() && !timeNow().Before(q.until)) {
+		ts.quarantine = quarantine{}
+		return nil
+	}
+	return &QuarantineError{Until: q.until, Err: q.err}
+}
+
+// recordRefresh counts consecutive invalid_grant failures and quarantines the refresh token
+// after TokenSourceConfig.QuarantineAfter of them, an AuditQuarantine event is sent then.
+// Any other result, including network errors and 5xx, breaks the sequence.
+func (ts *TokenSource) recordRefresh(ctx context.Context, err error) error {
+	after := ts.config.QuarantineAfter
+	if after <= 0 {
+		return err
+	}
+
+	var rerr *RetrieveError
+	if !errors.As(err, &rerr) || rerr.ErrorCode != "invalid_grant" {
+		ts.quarantine.failures = 0
+		return err
+	}
+	ts.quarantine.failures++
+	if ts.quarantine.failures < after {
+		return err
+	}
+
+	ts.quarantine.refreshToken = ts.token.RefreshToken
+	ts.quarantine.err = err
+	if d := ts.config.QuarantineDuration; d > 0 {
+		ts.quarantine.until = timeNow().Add(d)
+	}
+	ts.quarantines.Add(1)
+
+	ts.client.audit(ctx, AuditEvent{
+		Time:      time.Now(),
+		Operation: AuditQuarantine,
+		GrantType: GrantTypeRefreshToken,
+		Key:       ts.config.Key,
+		Err:       err,
+	})
+	return &QuarantineError{Until: ts.quarantine.until, Err: err}
+}
@@ -0,0 +1,62 @@
+package oauth2
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// redacted replaces secrets in String, GoString and Format.
+const redacted = "[redacted]"
+
+func redact(secret string) string {
+	if secret == "" {
+		return `""`
+	}
+	return redacted
+}
+
+// String returns the config with the client secret redacted.
+// Only the main fields are included.
+func (c Config) String() string {
+	return fmt.Sprintf("oauth2.Config{ClientID: %q, ClientSecret: %s, Issuer: %q, AuthURL: %q, TokenURL: %q, RedirectURL: %q, Scopes: %q, Mode: %v}",
+		c.ClientID, redact(c.ClientSecret), c.Issuer, c.AuthURL, c.TokenURL, c.RedirectURL, c.Scopes, c.Mode)
+}
+
+// GoString is the same as String, so %#v doesn't leak the client secret.
+func (c Config) GoString() string {
+	return c.String()
+}
+
+// Format makes every verb print String, so no verb leaks the client secret.
+func (c Config) Format(f fmt.State, verb rune) {
+	io.WriteString(f, c.String())
+}
+
+// String returns the token with the access and refresh tokens redacted.
+// Raw is not included, it has the tokens too.
+func (t Token) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "oauth2.Token{TokenType: %q, AccessToken: %s, RefreshToken: %s",
+		t.TokenType, redact(t.AccessToken), redact(t.RefreshToken))
+
+	if !t.Expiry.IsZero() {
+		b.WriteString(", Expiry: " + t.Expiry.Format(time.RFC3339))
+	}
+	if !t.RefreshExpiry.IsZero() {
+		b.WriteString(", RefreshExpiry: " + t.RefreshExpiry.Format(time.RFC3339))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// GoString is the same as String, so %#v doesn't leak the tokens.
+func (t Token) GoString() string {
+	return t.String()
+}
+
+// Format makes every verb print String, so no verb leaks the tokens.
+func (t Token) Format(f fmt.State, verb rune) {
+	io.WriteString(f, t.String())
+}
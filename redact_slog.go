@@ -0,0 +1,45 @@
+//go:build go1.21
+
+package oauth2
+
+import (
+	"log/slog"
+)
+
+// LogValue implements slog.LogValuer, it logs the fields of String with the client secret redacted.
+func (c Config) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("client_id", c.ClientID),
+		slog.String("client_secret", redactValue(c.ClientSecret)),
+		slog.String("issuer", c.Issuer),
+		slog.String("auth_url", c.AuthURL),
+		slog.String("token_url", c.TokenURL),
+		slog.String("redirect_url", c.RedirectURL),
+		slog.Any("scopes", c.Scopes),
+		slog.String("mode", c.Mode.String()),
+	)
+}
+
+// LogValue implements slog.LogValuer, it logs the fields of String with the tokens redacted.
+func (t Token) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("token_type", t.TokenType),
+		slog.String("access_token", redactValue(t.AccessToken)),
+		slog.String("refresh_token", redactValue(t.RefreshToken)),
+	}
+	if !t.Expiry.IsZero() {
+		attrs = append(attrs, slog.Time("expiry", t.Expiry))
+	}
+	if !t.RefreshExpiry.IsZero() {
+		attrs = append(attrs, slog.Time("refresh_expiry", t.RefreshExpiry))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// redactValue is redact without quotes, an empty secret stays empty.
+func redactValue(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
@@ -0,0 +1,46 @@
+//go:build go1.21
+
+package oauth2
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactLogValue(t *testing.T) {
+	var b bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&b, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	cfg := Config{
+		ClientID:     "CLIENT_ID",
+		ClientSecret: "CLIENT_SECRET",
+		TokenURL:     "https://example.com/token",
+		Scopes:       []string{"read"},
+	}
+	tok := &Token{
+		AccessToken:  "ACCESS_TOKEN",
+		TokenType:    "Bearer",
+		RefreshToken: "REFRESH_TOKEN",
+		Expiry:       time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+		Raw:          map[string]interface{}{"access_token": "ACCESS_TOKEN"},
+	}
+	logger.Info("token", "config", cfg, "token", tok)
+
+	s := b.String()
+	mustEqual(t, strings.Contains(s, "CLIENT_SECRET"), false)
+	mustEqual(t, strings.Contains(s, "ACCESS_TOKEN"), false)
+	mustEqual(t, strings.Contains(s, "REFRESH_TOKEN"), false)
+	mustEqual(t, s, `{"level":"INFO","msg":"token",`+
+		`"config":{"client_id":"CLIENT_ID","client_secret":"[redacted]","issuer":"","auth_url":"","token_url":"https://example.com/token","redirect_url":"","scopes":["read"],"mode":"auto"},`+
+		`"token":{"token_type":"Bearer","access_token":"[redacted]","refresh_token":"[redacted]","expiry":"2030-01-02T03:04:05Z"}}`+"\n")
+}
@@ -0,0 +1,48 @@
+package oauth2
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactConfig(t *testing.T) {
+	cfg := Config{
+		ClientID:     "CLIENT_ID",
+		ClientSecret: "CLIENT_SECRET",
+		TokenURL:     "https://example.com/token",
+		Scopes:       []string{"read"},
+	}
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%d", "%x", "%q"} {
+		for _, v := range []interface{}{cfg, &cfg} {
+			s := fmt.Sprintf(format, v)
+			mustEqual(t, strings.Contains(s, "CLIENT_SECRET"), false)
+			mustEqual(t, strings.Contains(s, "CLIENT_ID"), true)
+		}
+	}
+
+	mustEqual(t, cfg.String(), `oauth2.Config{ClientID: "CLIENT_ID", ClientSecret: [redacted], Issuer: "", AuthURL: "", TokenURL: "https://example.com/token", RedirectURL: "", Scopes: ["read"], Mode: auto}`)
+}
+
+func TestRedactToken(t *testing.T) {
+	tok := &Token{
+		AccessToken:  "ACCESS_TOKEN",
+		TokenType:    "Bearer",
+		RefreshToken: "REFRESH_TOKEN",
+		Expiry:       time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+		Raw:          map[string]interface{}{"access_token": "ACCESS_TOKEN"},
+	}
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%d", "%x", "%q"} {
+		for _, v := range []interface{}{tok, *tok, []*Token{tok}} {
+			s := fmt.Sprintf(format, v)
+			mustEqual(t, strings.Contains(s, "ACCESS_TOKEN"), false)
+			mustEqual(t, strings.Contains(s, "REFRESH_TOKEN"), false)
+		}
+	}
+
+	mustEqual(t, tok.String(), `oauth2.Token{TokenType: "Bearer", AccessToken: [redacted], RefreshToken: [redacted], Expiry: 2030-01-02T03:04:05Z}`)
+	mustEqual(t, Token{}.String(), `oauth2.Token{TokenType: "", AccessToken: "", RefreshToken: ""}`)
+}
@@ -0,0 +1,183 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RegistryConfig describes what providers of a Registry share.
+type RegistryConfig struct {
+	// HTTPClient is used by providers without their own, http.DefaultClient if nil.
+	HTTPClient *http.Client
+
+	// Store keeps tokens of all providers for their TokenManagers, keys are prefixed with the provider name.
+	Store TokenStore
+
+	// Metrics is set for providers without their own Config.Metrics.
+	Metrics Metrics
+
+	// AuditSink is set for providers without their own Config.AuditSink.
+	AuditSink AuditSink
+
+	_ struct{} // enforce explicit field names.
+}
+
+// ProviderConfig describes a provider of a Registry.
+type ProviderConfig struct {
+	// Config is the config of the provider's Client.
+	Config Config
+
+	// HTTPClient sends requests to the provider, like one with a separate egress proxy,
+	// client certificates or timeouts. RegistryConfig.HTTPClient is used if nil.
+	// Config.Proxy and Config.TLSConfig are applied to a copy, so a shared client isn't changed.
+	HTTPClient *http.Client
+
+	_ struct{} // enforce explicit field names.
+}
+
+// Registry keeps clients of several providers by name, each with its own HTTP client,
+// sharing a token store and metrics. It is safe for concurrent use.
+type Registry struct {
+	config RegistryConfig
+
+	mu        sync.RWMutex
+	providers map[string]*registeredProvider
+}
+
+type registeredProvider struct {
+	client  *Client
+	manager *TokenManager
+}
+
+// NewRegistry instantiates a new registry with a given config.
+func NewRegistry(config RegistryConfig) *Registry {
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+
+	r := &Registry{
+		config:    config,
+		providers: make(map[string]*registeredProvider),
+	}
+	return r
+}
+
+// Register adds a provider and returns its client. Names must be unique and must not contain `:`.
+func (r *Registry) Register(name string, provider ProviderConfig) (*Client, error) {
+	switch {
+	case name == "":
+		return nil, errors.New("oauth2: provider name is empty")
+	case strings.Contains(name, ":"):
+		// the name prefixes store keys, `a` + `b:c` must not clash with `a:b` + `c`.
+		return nil, fmt.Errorf("oauth2: provider name %q contains ':'", name)
+	}
+
+	config := provider.Config
+	if config.Metrics == nil {
+		config.Metrics = r.config.Metrics
+	}
+	if config.AuditSink == nil {
+		config.AuditSink = r.config.AuditSink
+	}
+	httpClient := provider.HTTPClient
+	if httpClient == nil {
+		httpClient = r.config.HTTPClient
+	}
+
+	p := &registeredProvider{
+		client: NewClient(httpClient, config),
+	}
+	if r.config.Store != nil {
+		m, err := NewTokenManager(p.client, TokenManagerConfig{
+			Store: &prefixedStore{store: r.config.Store, prefix: name + ":"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		p.manager = m
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.providers[name]; ok {
+		return nil, fmt.Errorf("oauth2: provider %q is already registered", name)
+	}
+	r.providers[name] = p
+	return p.client, nil
+}
+
+// Client returns the client of the provider.
+func (r *Registry) Client(name string) (*Client, bool) {
+	p, ok := r.provider(name)
+	if !ok {
+		return nil, false
+	}
+	return p.client, true
+}
+
+// TokenManager returns the token manager of the provider backed by RegistryConfig.Store.
+func (r *Registry) TokenManager(name string) (*TokenManager, error) {
+	p, ok := r.provider(name)
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("oauth2: provider %q is not registered", name)
+	case p.manager == nil:
+		return nil, errors.New("oauth2: token store is not set")
+	}
+	return p.manager, nil
+}
+
+// Names returns sorted names of the registered providers.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *Registry) provider(name string) (*registeredProvider, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+// prefixedStore is a TokenStore view of a shared store with prefixed keys.
+type prefixedStore struct {
+	store  TokenStore
+	prefix string
+}
+
+func (s *prefixedStore) Load(ctx context.Context, key string) (*Token, error) {
+	return s.store.Load(ctx, s.prefix+key)
+}
+
+func (s *prefixedStore) Save(ctx context.Context, key string, token *Token) error {
+	return s.store.Save(ctx, s.prefix+key, token)
+}
+
+func (s *prefixedStore) Delete(ctx context.Context, key string) error {
+	return s.store.Delete(ctx, s.prefix+key)
+}
+
+// Rotate implements the TokenRotator interface, the shared store is used with Save
+// if it doesn't implement TokenRotator, like without a registry.
+func (s *prefixedStore) Rotate(ctx context.Context, key, refreshToken string, token *Token) error {
+	rotator, ok := s.store.(TokenRotator)
+	if !ok {
+		return s.store.Save(ctx, s.prefix+key, token)
+	}
+	return rotator.Rotate(ctx, s.prefix+key, refreshToken, token)
+}
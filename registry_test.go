@@ -0,0 +1,98 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+type countingTransport struct {
+	calls int
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.calls++
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestRegistry(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": %q, "expires_in": 3600}`, r.FormValue("refresh_token")+"-access")
+	})
+	defer ts.Close()
+
+	azure, google := &countingTransport{}, &countingTransport{}
+	store := &memStore{tokens: map[string]*Token{
+		"azure:user-1":  {RefreshToken: "azure"},
+		"google:user-1": {RefreshToken: "google"},
+	}}
+	reg := NewRegistry(RegistryConfig{Store: store})
+
+	for name, transport := range map[string]*countingTransport{"azure": azure, "google": google} {
+		_, err := reg.Register(name, ProviderConfig{
+			Config:     Config{ClientID: "CLIENT_ID", TokenURL: ts.URL + "/token", Mode: InHeaderMode},
+			HTTPClient: &http.Client{Transport: transport},
+		})
+		mustOk(t, err)
+	}
+	_, err := reg.Register("azure", ProviderConfig{})
+	mustFail(t, err)
+	mustEqual(t, reg.Names(), []string{"azure", "google"})
+
+	ctx := context.Background()
+	m, err := reg.TokenManager("azure")
+	mustOk(t, err)
+	tok, err := m.Token(ctx, "user-1")
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "azure-access")
+	mustEqual(t, azure.calls, 1)
+	mustEqual(t, google.calls, 0)
+
+	m, err = reg.TokenManager("google")
+	mustOk(t, err)
+	tok, err = m.Token(ctx, "user-1")
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "google-access")
+	mustEqual(t, google.calls, 1)
+
+	_, ok := reg.Client("okta")
+	mustEqual(t, ok, false)
+	_, err = reg.TokenManager("okta")
+	mustFail(t, err)
+}
+
+func TestRegistry_Rotate(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "MY_ACCESS_TOKEN", "refresh_token": "MY_REFRESH_TOKEN", "expires_in": 3600}`)
+	})
+	defer ts.Close()
+
+	store := &rotatingStore{memStore: memStore{tokens: map[string]*Token{
+		"azure:user-1": {RefreshToken: "REFRESH_TOKEN"},
+	}}}
+	reg := NewRegistry(RegistryConfig{Store: store})
+	_, err := reg.Register("azure", ProviderConfig{
+		Config: Config{ClientID: "CLIENT_ID", TokenURL: ts.URL + "/token", Mode: InHeaderMode},
+	})
+	mustOk(t, err)
+
+	_, err = reg.Register("azure:eu", ProviderConfig{})
+	mustFail(t, err)
+
+	// another process rotates the token while we're refreshing it.
+	var rotations int
+	store.onRotate = func() {
+		rotations++
+		store.tokens["azure:user-1"] = &Token{AccessToken: "THEIR_ACCESS_TOKEN", RefreshToken: "THEIR_REFRESH_TOKEN"}
+	}
+
+	m, err := reg.TokenManager("azure")
+	mustOk(t, err)
+	tok, err := m.Token(context.Background(), "user-1")
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "THEIR_ACCESS_TOKEN")
+	mustEqual(t, rotations, 1)
+}
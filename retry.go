@@ -0,0 +1,118 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// RetryBudgetError is returned when a token request failed and the retry budget
+// shared by the Client is exhausted, see Config.RetryBudget. Err is the error of the last attempt.
+type RetryBudgetError struct {
+	Err error
+}
+
+func (e *RetryBudgetError) Error() string {
+	return "oauth2: retry budget exhausted: " + e.Err.Error()
+}
+
+func (e *RetryBudgetError) Unwrap() error { return e.Err }
+
+const (
+	defaultRetryBackoff        = 100 * time.Millisecond
+	defaultRetryBudgetInterval = time.Second
+)
+
+// retryBudget is a token bucket of retries, nil budget allows everything.
+type retryBudget struct {
+	capacity float64
+	interval time.Duration
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newRetryBudget(capacity int, interval time.Duration) *retryBudget {
+	if capacity <= 0 {
+		return nil
+	}
+	if interval <= 0 {
+		interval = defaultRetryBudgetInterval
+	}
+	return &retryBudget{
+		capacity: float64(capacity),
+		interval: interval,
+		tokens:   float64(capacity),
+		last:     timeNow(),
+	}
+}
+
+// take reports whether a retry is allowed and withdraws it from the budget.
+func (b *retryBudget) take() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := timeNow()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += float64(elapsed) / float64(b.interval)
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// doRequestRetry sends a token request, retrying transient failures up to Config.MaxRetries times.
+func (c *Client) doRequestRetry(ctx context.Context, mode Mode, params url.Values) (*Token, error) {
+	backoff := c.config.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		token, err := c.doRequestHedged(ctx, mode, params)
+		if err == nil || attempt >= c.config.MaxRetries || ctx.Err() != nil || !isRetryable(err, params) {
+			return token, err
+		}
+		if !c.retries.take() {
+			return nil, &RetryBudgetError{Err: err}
+		}
+
+		timer := time.NewTimer(backoff << attempt)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// isRetryable reports whether a failed token request may succeed when sent again:
+// network errors, 5xx and 429 responses. Requests of grants which are not idempotent,
+// see idempotentGrants, might have been processed, so they are retried only after 429.
+func isRetryable(err error, params url.Values) bool {
+	var rerr *RetrieveError
+	if errors.As(err, &rerr) {
+		if rerr.StatusCode == http.StatusTooManyRequests {
+			return true
+		}
+		return rerr.StatusCode >= 500 && idempotentGrants[params.Get("grant_type")]
+	}
+	var uerr *url.Error
+	return errors.As(err, &uerr) && idempotentGrants[params.Get("grant_type")]
+}
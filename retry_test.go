@@ -0,0 +1,139 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	var calls int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN"}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		ClientID:     "CLIENT_ID",
+		TokenURL:     ts.URL,
+		Mode:         InHeaderMode,
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+	})
+
+	tok, err := client.ClientCredentialsToken(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "ACCESS_TOKEN")
+	mustEqual(t, calls, 3)
+}
+
+func TestRetry_NotRetryable(t *testing.T) {
+	var calls int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error": "invalid_grant"}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		ClientID:     "CLIENT_ID",
+		TokenURL:     ts.URL,
+		Mode:         InHeaderMode,
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+	})
+
+	_, err := client.ClientCredentialsToken(context.Background())
+	mustFail(t, err)
+	mustEqual(t, calls, 1)
+}
+
+func TestRetryBudget(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	var calls int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		ClientID:            "CLIENT_ID",
+		TokenURL:            ts.URL,
+		Mode:                InHeaderMode,
+		MaxRetries:          2,
+		RetryBackoff:        time.Millisecond,
+		RetryBudget:         3,
+		RetryBudgetInterval: time.Minute,
+	})
+	ctx := context.Background()
+
+	_, err := client.ClientCredentialsToken(ctx)
+	var rerr *RetrieveError
+	mustEqual(t, errors.As(err, &rerr), true)
+	mustEqual(t, calls, 3)
+
+	// one retry is left.
+	_, err = client.ClientCredentialsToken(ctx)
+	var budgetErr *RetryBudgetError
+	mustEqual(t, errors.As(err, &budgetErr), true)
+	mustEqual(t, errors.As(err, &rerr), true)
+	mustEqual(t, rerr.StatusCode, http.StatusBadGateway)
+	mustEqual(t, calls, 5)
+
+	_, err = client.ClientCredentialsToken(ctx)
+	mustEqual(t, errors.As(err, &budgetErr), true)
+	mustEqual(t, calls, 6)
+
+	now = now.Add(2 * time.Minute)
+	_, err = client.ClientCredentialsToken(ctx)
+	mustEqual(t, errors.As(err, &budgetErr), false)
+	mustEqual(t, calls, 9)
+}
+
+func TestRetry_SingleUseGrant(t *testing.T) {
+	var calls int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.FormValue("grant_type") == GrantTypeRefreshToken && calls == 2 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		ClientID:     "CLIENT_ID",
+		TokenURL:     ts.URL,
+		Mode:         InHeaderMode,
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+	})
+	ctx := context.Background()
+
+	// the code might be used by the failed request.
+	_, err := client.Exchange(ctx, "CODE")
+	mustFail(t, err)
+	mustEqual(t, calls, 1)
+
+	// throttled requests weren't processed, they're retried.
+	calls = 1
+	_, err = client.Token(ctx, "REFRESH_TOKEN")
+	mustFail(t, err)
+	mustEqual(t, calls, 3)
+}
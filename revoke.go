@@ -0,0 +1,93 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// RevokeToken asks the revocation endpoint to invalidate a token, RFC 7009.
+// The hint is an optional `token_type_hint`, like "access_token" or "refresh_token".
+//
+// Unknown and already revoked tokens are not an error, the endpoint responds with 200 for them.
+func (c *Client) RevokeToken(ctx context.Context, token, hint string) error {
+	if c.config.RevocationURL == "" {
+		return errors.New("oauth2: revocation URL is not set")
+	}
+
+	params := url.Values{
+		"token": []string{token},
+	}
+	if hint != "" {
+		params.Set("token_type_hint", hint)
+	}
+
+	// clients authenticate like at the token endpoint, RFC 7009 section 2.1.
+	mode := c.requestMode(ctx, InHeaderMode)
+
+	if _, err := c.postForm(ctx, c.config.RevocationURL, mode, params); err != nil {
+		return fmt.Errorf("oauth2: cannot revoke token: %w", err)
+	}
+	c.emit(ctx, TokenEvent{Type: TokenRevoked, Hint: hint})
+	return nil
+}
+
+// RevokeResult reports revocation of every part of a token, see Client.RevokeAll.
+type RevokeResult struct {
+	RefreshToken error // RefreshToken is an error of the refresh token revocation, nil if revoked or there is none.
+	AccessToken  error // AccessToken is an error of the access token revocation, nil if revoked or there is none.
+
+	// Cascaded reports that the provider rejected the access token after revoking the refresh token,
+	// because it revoked the access token too, RFC 7009 section 2.1.
+	Cascaded bool
+}
+
+// Err returns the first revocation error, if any.
+func (r *RevokeResult) Err() error {
+	if r.RefreshToken != nil {
+		return r.RefreshToken
+	}
+	return r.AccessToken
+}
+
+// RevokeAll revokes the refresh token and then the access token of the token, for logout flows.
+// The refresh token goes first, so a leaked one cannot mint new access tokens if the second call fails.
+// The access token is revoked even if the refresh token revocation fails.
+//
+// The returned error is RevokeResult.Err.
+func (c *Client) RevokeAll(ctx context.Context, token *Token) (*RevokeResult, error) {
+	res := &RevokeResult{}
+	if token == nil {
+		return res, nil
+	}
+
+	if token.RefreshToken != "" {
+		res.RefreshToken = c.RevokeToken(ctx, token.RefreshToken, "refresh_token")
+	}
+
+	if token.AccessToken != "" {
+		err := c.RevokeToken(ctx, token.AccessToken, "access_token")
+		if err != nil && token.RefreshToken != "" && res.RefreshToken == nil && isRevokedError(err) {
+			res.Cascaded = true
+			err = nil
+		}
+		res.AccessToken = err
+	}
+	return res, res.Err()
+}
+
+// isRevokedError reports whether the endpoint rejected a token because it's already invalid,
+// instead of the RFC 7009 success response.
+func isRevokedError(err error) bool {
+	var re *RetrieveError
+	if !errors.As(err, &re) {
+		return false
+	}
+	switch re.ErrorCode {
+	case "invalid_token", "invalid_grant":
+		return true
+	default:
+		return false
+	}
+}
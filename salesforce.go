@@ -0,0 +1,69 @@
+package oauth2
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ErrSalesforceSignature is returned by VerifySalesforceSignature when the signature doesn't match.
+var ErrSalesforceSignature = errors.New("oauth2: invalid Salesforce token signature")
+
+// SalesforceInstanceURL returns Salesforce's `instance_url` of the token, the base URL for API calls.
+func SalesforceInstanceURL(t *Token) string {
+	s, _ := t.Extra("instance_url").(string)
+	return s
+}
+
+// SalesforceID returns Salesforce's `id` of the token, the identity URL of the user.
+func SalesforceID(t *Token) string {
+	s, _ := t.Extra("id").(string)
+	return s
+}
+
+// SalesforceIssuedAt returns Salesforce's `issued_at` of the token, zero if it's missing or malformed.
+func SalesforceIssuedAt(t *Token) time.Time {
+	ms, err := strconv.ParseInt(salesforceIssuedAt(t), 10, 64)
+	if err != nil || ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// VerifySalesforceSignature checks Salesforce's `signature` of the token,
+// an HMAC-SHA256 of `id` and `issued_at` keyed by the client secret.
+// It proves the identity URL wasn't modified in transit.
+func VerifySalesforceSignature(t *Token, clientSecret string) error {
+	sig, _ := t.Extra("signature").(string)
+	if sig == "" {
+		return errors.New("oauth2: Salesforce token has no signature")
+	}
+	got, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return ErrSalesforceSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(clientSecret))
+	mac.Write([]byte(SalesforceID(t) + salesforceIssuedAt(t)))
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrSalesforceSignature
+	}
+	return nil
+}
+
+// salesforceIssuedAt returns `issued_at` as is, Extra would parse it as a number.
+func salesforceIssuedAt(t *Token) string {
+	switch v := t.Raw.(type) {
+	case map[string]interface{}:
+		s, _ := v["issued_at"].(string)
+		return s
+	case url.Values:
+		return v.Get("issued_at")
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,45 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSalesforceToken(t *testing.T) {
+	const id = "https://login.salesforce.com/id/00Dx0000000BV7z/005x00000012Q9P"
+	const issuedAt = "1700000000123"
+
+	mac := hmac.New(sha256.New, []byte("CLIENT_SECRET"))
+	mac.Write([]byte(id + issuedAt))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{
+			"access_token": "ACCESS_TOKEN",
+			"instance_url": "https://example.my.salesforce.com",
+			"id": %q,
+			"token_type": "Bearer",
+			"issued_at": %q,
+			"signature": %q
+		}`, id, issuedAt, signature)
+	})
+	defer ts.Close()
+
+	tok, err := newClient(ts.URL).Exchange(context.Background(), "code")
+	mustOk(t, err)
+	mustEqual(t, SalesforceInstanceURL(tok), "https://example.my.salesforce.com")
+	mustEqual(t, SalesforceID(tok), id)
+	mustEqual(t, SalesforceIssuedAt(tok), time.UnixMilli(1700000000123))
+	mustOk(t, VerifySalesforceSignature(tok, "CLIENT_SECRET"))
+	mustEqual(t, VerifySalesforceSignature(tok, "OTHER_SECRET"), ErrSalesforceSignature)
+
+	mustFail(t, VerifySalesforceSignature(&Token{}, "CLIENT_SECRET"))
+	mustEqual(t, SalesforceIssuedAt(&Token{}).IsZero(), true)
+}
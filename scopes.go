@@ -0,0 +1,89 @@
+package oauth2
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ParseScopes parses a space-delimited scope string (like `scope` in a token response)
+// into sorted scopes without duplicates.
+func ParseScopes(s string) []string {
+	return normalizeScopes(strings.Fields(s))
+}
+
+// JoinScopes returns sorted scopes without duplicates joined by space.
+func JoinScopes(scopes []string) string {
+	return strings.Join(normalizeScopes(scopes), " ")
+}
+
+// ScopesEqual reports whether both scope sets are equal, ignoring order and duplicates.
+func ScopesEqual(a, b []string) bool {
+	return JoinScopes(a) == JoinScopes(b)
+}
+
+// ScopesContain reports whether granted scopes contain all required scopes.
+func ScopesContain(granted, required []string) bool {
+	set := make(map[string]struct{}, len(granted))
+	for _, s := range granted {
+		set[s] = struct{}{}
+	}
+	for _, s := range required {
+		if _, ok := set[s]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateScopes reports a scope with characters not allowed by RFC 6749 section 3.3.
+func ValidateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if scope == "" {
+			return fmt.Errorf("oauth2: empty scope")
+		}
+		for _, r := range scope {
+			// scope-token = 1*( %x21 / %x23-5B / %x5D-7E )
+			if r < 0x21 || r > 0x7E || r == '"' || r == '\\' {
+				return fmt.Errorf("oauth2: illegal character %q in scope %q", r, scope)
+			}
+		}
+	}
+	return nil
+}
+
+// joinScopes joins scopes for a request with Config.ScopeSeparator.
+func (c *Client) joinScopes(scopes []string) string {
+	sep := c.config.ScopeSeparator
+	if sep == "" {
+		sep = " "
+	}
+	return strings.Join(scopes, sep)
+}
+
+// splitScopes splits scopes joined with Config.ScopeSeparator or spaces.
+func (c *Client) splitScopes(s string) []string {
+	sep := c.config.ScopeSeparator
+	if sep == "" || sep == " " {
+		return strings.Fields(s)
+	}
+
+	var scopes []string
+	for _, part := range strings.Split(s, sep) {
+		scopes = append(scopes, strings.Fields(part)...)
+	}
+	return scopes
+}
+
+func normalizeScopes(scopes []string) []string {
+	s := append([]string(nil), scopes...)
+	sort.Strings(s)
+
+	res := s[:0]
+	for i, scope := range s {
+		if i == 0 || scope != s[i-1] {
+			res = append(res, scope)
+		}
+	}
+	return res
+}
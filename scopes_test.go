@@ -0,0 +1,127 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestParseScopes(t *testing.T) {
+	mustEqual(t, len(ParseScopes("")), 0)
+	mustEqual(t, ParseScopes(" write  read write "), []string{"read", "write"})
+}
+
+func TestJoinScopes(t *testing.T) {
+	testCases := []struct {
+		scopes []string
+		want   string
+	}{
+		{nil, ""},
+		{[]string{"b", "a"}, "a b"},
+		{[]string{"a", "b", "a", "b"}, "a b"},
+	}
+
+	for _, tc := range testCases {
+		mustEqual(t, JoinScopes(tc.scopes), tc.want)
+	}
+}
+
+func TestScopesEqual(t *testing.T) {
+	mustEqual(t, ScopesEqual([]string{"a", "b"}, []string{"b", "a", "a"}), true)
+	mustEqual(t, ScopesEqual([]string{"a"}, []string{"a", "b"}), false)
+	mustEqual(t, ScopesEqual(nil, []string{}), true)
+}
+
+func TestScopesContain(t *testing.T) {
+	mustEqual(t, ScopesContain([]string{"a", "b", "c"}, []string{"c", "a"}), true)
+	mustEqual(t, ScopesContain([]string{"a"}, []string{"a", "b"}), false)
+	mustEqual(t, ScopesContain(nil, nil), true)
+}
+
+func TestValidateScopes(t *testing.T) {
+	mustOk(t, ValidateScopes([]string{"openid", "https://www.googleapis.com/auth/drive", "user:email"}))
+
+	for _, scope := range []string{"", "a b", `a"b`, `a\b`, "ä", "a\n"} {
+		mustFail(t, ValidateScopes([]string{scope}))
+	}
+}
+
+func TestInvalidScopesInRequests(t *testing.T) {
+	client := NewClient(http.DefaultClient, Config{
+		AuthURL:  "https://example.com/auth",
+		TokenURL: "https://example.com/token",
+		Scopes:   []string{"read\"write"},
+	})
+
+	_, err := client.BuildAuthCodeURL("state", nil)
+	mustFail(t, err)
+
+	_, err = client.ClientCredentialsToken(context.Background())
+	mustFail(t, err)
+
+	// scopes are split the same way as in token requests.
+	client = NewClient(http.DefaultClient, Config{
+		AuthURL:        "https://example.com/auth",
+		Scopes:         []string{"openid email", "profile"},
+		ScopeSeparator: ",",
+	})
+	_, err = client.BuildAuthCodeURL("state", nil)
+	mustOk(t, err)
+}
+
+func TestRejectScopeDowngrade(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN", "scope": "scope1"}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		TokenURL:             ts.URL,
+		Mode:                 InHeaderMode,
+		Scopes:               []string{"scope1", "scope2"},
+		RejectScopeDowngrade: true,
+	})
+
+	_, err := client.ClientCredentialsToken(context.Background())
+
+	var downgrade *ScopeDowngradeError
+	mustEqual(t, errors.As(err, &downgrade), true)
+	mustEqual(t, downgrade.Missing, []string{"scope2"})
+	mustEqual(t, downgrade.Token.AccessToken, "ACCESS_TOKEN")
+}
+
+func TestScopeSeparator(t *testing.T) {
+	var scope string
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		scope = r.FormValue("scope")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN", "scope": "email,public_profile"}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		AuthURL:              "https://example.com/auth",
+		TokenURL:             ts.URL,
+		Mode:                 InHeaderMode,
+		Scopes:               []string{"email", "public_profile"},
+		ScopeSeparator:       ",",
+		RejectScopeDowngrade: true,
+	})
+
+	_, err := client.ClientCredentialsToken(context.Background())
+	mustOk(t, err)
+	mustEqual(t, scope, "email,public_profile")
+
+	_, err = client.ClientCredentialsTokenWithScopes(context.Background(), "email", "user_friends")
+	var downgrade *ScopeDowngradeError
+	mustEqual(t, errors.As(err, &downgrade), true)
+	mustEqual(t, downgrade.Missing, []string{"user_friends"})
+
+	u, err := client.BuildAuthCodeURL("state", nil)
+	mustOk(t, err)
+	mustEqual(t, u, "https://example.com/auth?client_id=&response_type=code&scope=email%2Cpublic_profile&state=state")
+}
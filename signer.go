@@ -0,0 +1,201 @@
+package oauth2
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"sync"
+)
+
+// Signer signs JWTs created by the package, like client assertions for PrivateKeyJWTMode.
+// Implementations can keep keys in memory (see NewSigner), an HSM or a cloud KMS.
+type Signer interface {
+	// Algorithm returns a JWS algorithm, like `RS256` or `ES256`.
+	Algorithm() string
+
+	// KeyID returns an optional key ID, used as `kid` in the JWT header.
+	KeyID() string
+
+	// Sign returns a JWS signature of the data (encoded header and payload).
+	Sign(ctx context.Context, data []byte) ([]byte, error)
+}
+
+// NewSigner returns a Signer on top of crypto.Signer, like *rsa.PrivateKey, *ecdsa.PrivateKey or ed25519.PrivateKey.
+// Supported algorithms are RS256/384/512, PS256/384/512, ES256/384/512 and EdDSA.
+func NewSigner(key crypto.Signer, alg, kid string) (Signer, error) {
+	s := &cryptoSigner{
+		key: key,
+		alg: alg,
+		kid: kid,
+	}
+
+	switch key.Public().(type) {
+	case *rsa.PublicKey:
+		switch alg {
+		case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512":
+			return s, nil
+		}
+	case *ecdsa.PublicKey:
+		switch alg {
+		case "ES256", "ES384", "ES512":
+			return s, nil
+		}
+	case ed25519.PublicKey:
+		if alg == "EdDSA" {
+			return s, nil
+		}
+	}
+	return nil, errors.New("oauth2: algorithm " + alg + " doesn't match the key")
+}
+
+type cryptoSigner struct {
+	key crypto.Signer
+	alg string
+	kid string
+}
+
+func (s *cryptoSigner) Algorithm() string { return s.alg }
+func (s *cryptoSigner) KeyID() string     { return s.kid }
+
+func (s *cryptoSigner) Sign(ctx context.Context, data []byte) ([]byte, error) {
+	if s.alg == "EdDSA" {
+		return s.key.Sign(rand.Reader, data, crypto.Hash(0))
+	}
+
+	hash, _ := algHash(s.alg)
+	h := hash.New()
+	h.Write(data)
+	digest := h.Sum(nil)
+
+	var opts crypto.SignerOpts = hash
+	if s.alg[0] == 'P' {
+		opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: hash}
+	}
+
+	sig, err := s.key.Sign(rand.Reader, digest, opts)
+	if err != nil {
+		return nil, err
+	}
+	if s.alg[0] == 'E' {
+		return ecdsaRawSignature(sig, s.key.Public().(*ecdsa.PublicKey))
+	}
+	return sig, nil
+}
+
+// ecdsaRawSignature converts ASN.1 signature into JWS format: R || S with fixed size.
+func ecdsaRawSignature(der []byte, pub *ecdsa.PublicKey) ([]byte, error) {
+	var sig struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, err
+	}
+
+	size := (pub.Curve.Params().BitSize + 7) / 8
+	raw := make([]byte, 2*size)
+	sig.R.FillBytes(raw[:size])
+	sig.S.FillBytes(raw[size:])
+	return raw, nil
+}
+
+// ActiveSigner is an optional interface of a Signer with several keys, like RotatingSigner.
+// JWTs are signed with the returned signer only, so the header and the signature
+// use the same key even if the active one changes in between.
+type ActiveSigner interface {
+	// Active returns the signer of the active key.
+	Active() Signer
+}
+
+var _ ActiveSigner = &RotatingSigner{}
+
+// RotatingSigner is a Signer with several keys, it signs with the active one.
+// Keys are identified by their key IDs, this allows to rotate keys without restarts:
+// add a new key, publish it in the JWKS, then make it active. It is safe for concurrent use.
+type RotatingSigner struct {
+	mu      sync.RWMutex
+	signers map[string]Signer
+	active  string
+}
+
+// NewRotatingSigner returns a RotatingSigner with the given signers, the first one is active.
+func NewRotatingSigner(signers ...Signer) (*RotatingSigner, error) {
+	if len(signers) == 0 {
+		return nil, errors.New("oauth2: no signers")
+	}
+
+	s := &RotatingSigner{
+		signers: make(map[string]Signer, len(signers)),
+		active:  signers[0].KeyID(),
+	}
+	for _, signer := range signers {
+		if err := s.Add(signer); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Add adds a signer, its key ID must be unique and non-empty.
+func (s *RotatingSigner) Add(signer Signer) error {
+	kid := signer.KeyID()
+	if kid == "" {
+		return errors.New("oauth2: signer key ID is empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.signers[kid]; ok {
+		return errors.New("oauth2: duplicate signer key ID: " + kid)
+	}
+	s.signers[kid] = signer
+	return nil
+}
+
+// Remove removes a signer, the active one cannot be removed.
+func (s *RotatingSigner) Remove(kid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if kid == s.active {
+		return errors.New("oauth2: cannot remove the active signer")
+	}
+	delete(s.signers, kid)
+	return nil
+}
+
+// SetActive makes the signer with the key ID active.
+func (s *RotatingSigner) SetActive(kid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.signers[kid]; !ok {
+		return errors.New("oauth2: unknown signer key ID: " + kid)
+	}
+	s.active = kid
+	return nil
+}
+
+// Active returns the active signer.
+func (s *RotatingSigner) Active() Signer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.signers[s.active]
+}
+
+// Algorithm implements the Signer interface.
+func (s *RotatingSigner) Algorithm() string { return s.Active().Algorithm() }
+
+// KeyID implements the Signer interface.
+func (s *RotatingSigner) KeyID() string { return s.Active().KeyID() }
+
+// Sign implements the Signer interface.
+func (s *RotatingSigner) Sign(ctx context.Context, data []byte) ([]byte, error) {
+	return s.Active().Sign(ctx, data)
+}
@@ -0,0 +1,104 @@
+package oauth2
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"math/big"
+	"testing"
+)
+
+func TestNewSigner(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	mustOk(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	mustOk(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	mustOk(t, err)
+
+	data := []byte("header.payload")
+
+	testCases := []struct {
+		key    crypto.Signer
+		alg    string
+		verify func(sig []byte) bool
+	}{
+		{rsaKey, "RS256", func(sig []byte) bool {
+			return rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, hashOf(crypto.SHA256, data), sig) == nil
+		}},
+		{rsaKey, "PS512", func(sig []byte) bool {
+			return rsa.VerifyPSS(&rsaKey.PublicKey, crypto.SHA512, hashOf(crypto.SHA512, data), sig, nil) == nil
+		}},
+		{ecKey, "ES384", func(sig []byte) bool {
+			r, s := new(big.Int).SetBytes(sig[:48]), new(big.Int).SetBytes(sig[48:])
+			return len(sig) == 96 && ecdsa.Verify(&ecKey.PublicKey, hashOf(crypto.SHA384, data), r, s)
+		}},
+		{edKey, "EdDSA", func(sig []byte) bool {
+			return ed25519.Verify(edKey.Public().(ed25519.PublicKey), data, sig)
+		}},
+	}
+
+	for _, tc := range testCases {
+		signer, err := NewSigner(tc.key, tc.alg, "kid")
+		mustOk(t, err)
+		mustEqual(t, signer.Algorithm(), tc.alg)
+		mustEqual(t, signer.KeyID(), "kid")
+
+		sig, err := signer.Sign(context.Background(), data)
+		mustOk(t, err)
+		mustEqual(t, tc.verify(sig), true)
+	}
+
+	_, err = NewSigner(rsaKey, "ES256", "")
+	mustFail(t, err)
+	_, err = NewSigner(ecKey, "none", "")
+	mustFail(t, err)
+}
+
+func hashOf(hash crypto.Hash, data []byte) []byte {
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func TestRotatingSigner(t *testing.T) {
+	key1, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	mustOk(t, err)
+	key2, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	mustOk(t, err)
+
+	signer1, err := NewSigner(key1, "ES256", "key-1")
+	mustOk(t, err)
+	signer2, err := NewSigner(key2, "ES256", "key-2")
+	mustOk(t, err)
+
+	rs, err := NewRotatingSigner(signer1, signer2)
+	mustOk(t, err)
+	mustEqual(t, rs.KeyID(), "key-1")
+
+	mustOk(t, rs.SetActive("key-2"))
+	mustEqual(t, rs.KeyID(), "key-2")
+
+	raw, err := signJWT(context.Background(), rs, map[string]any{"sub": "client"})
+	mustOk(t, err)
+	var claims map[string]any
+	header, err := decodeJWT(raw, &claims)
+	mustOk(t, err)
+	mustEqual(t, header.KeyID, "key-2")
+
+	mustFail(t, rs.Remove("key-2"))
+	mustOk(t, rs.Remove("key-1"))
+	mustFail(t, rs.SetActive("key-1"))
+	mustFail(t, rs.Add(signer2))
+
+	_, err = NewRotatingSigner()
+	mustFail(t, err)
+	noKID, err := NewSigner(key1, "ES256", "")
+	mustOk(t, err)
+	_, err = NewRotatingSigner(noKID)
+	mustFail(t, err)
+}
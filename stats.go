@@ -0,0 +1,82 @@
+package oauth2
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ClientStats are counters of a Client since its creation, see Client.Stats.
+// They can be published with expvar:
+//
+//	expvar.Publish("oauth2", expvar.Func(func() interface{} { return client.Stats() }))
+type ClientStats struct {
+	TokensIssued        uint64 `json:"tokens_issued"`         // TokensIssued is a number of successful token requests.
+	Refreshes           uint64 `json:"refreshes"`             // Refreshes is a number of successful refresh token grants.
+	Failures            uint64 `json:"failures"`              // Failures is a number of failed token requests.
+	AutoDetectFallbacks uint64 `json:"auto_detect_fallbacks"` // AutoDetectFallbacks is how many times AutoDetectMode fell back to InParamsMode.
+	Hedges              uint64 `json:"hedges"`                // Hedges is a number of hedged token requests sent, see Config.HedgeDelay.
+}
+
+// TokenSourceStats are counters of a TokenSource since its creation, see TokenSource.Stats.
+type TokenSourceStats struct {
+	Refreshes       uint64 `json:"refreshes"`        // Refreshes is a number of successful refreshes.
+	RefreshFailures uint64 `json:"refresh_failures"` // RefreshFailures is a number of failed refreshes.
+	Quarantines     uint64 `json:"quarantines"`      // Quarantines is how many times the refresh token was quarantined.
+
+	// Usage of tokens, only with TokenSourceConfig.TrackUsage.
+	Uses     uint64    `json:"uses"`      // Uses is a number of successful Token calls.
+	LastUsed time.Time `json:"last_used"` // LastUsed is when a token was returned last, zero if never.
+}
+
+// TokenCacheStats are counters of a TokenCache since its creation, see TokenCache.Stats.
+type TokenCacheStats struct {
+	Size   int    `json:"size"`   // Size is a number of cached keys.
+	Hits   uint64 `json:"hits"`   // Hits is a number of lookups returning a cached token.
+	Misses uint64 `json:"misses"` // Misses is a number of lookups retrieving a new token.
+}
+
+type clientCounters struct {
+	tokensIssued        atomic.Uint64
+	refreshes           atomic.Uint64
+	failures            atomic.Uint64
+	autoDetectFallbacks atomic.Uint64
+	hedges              atomic.Uint64
+}
+
+// Stats returns counters of the client.
+func (c *Client) Stats() ClientStats {
+	return ClientStats{
+		TokensIssued:        c.counters.tokensIssued.Load(),
+		Refreshes:           c.counters.refreshes.Load(),
+		Failures:            c.counters.failures.Load(),
+		AutoDetectFallbacks: c.counters.autoDetectFallbacks.Load(),
+		Hedges:              c.counters.hedges.Load(),
+	}
+}
+
+// Stats returns counters of the token source.
+func (ts *TokenSource) Stats() TokenSourceStats {
+	stats := TokenSourceStats{
+		Refreshes:       ts.refreshes.Load(),
+		RefreshFailures: ts.refreshFailures.Load(),
+		Quarantines:     ts.quarantines.Load(),
+		Uses:            ts.uses.Load(),
+	}
+	if last := ts.lastUsed.Load(); last != 0 {
+		stats.LastUsed = time.Unix(0, last)
+	}
+	return stats
+}
+
+// Stats returns counters of the token cache.
+func (tc *TokenCache) Stats() TokenCacheStats {
+	tc.mu.Lock()
+	size := len(tc.entries)
+	tc.mu.Unlock()
+
+	return TokenCacheStats{
+		Size:   size,
+		Hits:   tc.hits.Load(),
+		Misses: tc.misses.Load(),
+	}
+}
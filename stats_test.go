@@ -0,0 +1,59 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		// only params authentication is accepted, AutoDetectMode falls back once.
+		if _, _, ok := r.BasicAuth(); ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.FormValue("grant_type") == "refresh_token" && r.FormValue("refresh_token") != "REFRESH_TOKEN" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "invalid_grant"}`)
+			return
+		}
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN", "expires_in": 3600}`)
+	})
+	defer ts.Close()
+
+	client := newClient(ts.URL)
+	cache := NewTokenCache(client, TokenCacheConfig{})
+
+	for i := 0; i < 3; i++ {
+		_, err := cache.Token(context.Background(), TokenKey{Audience: "api"})
+		mustOk(t, err)
+	}
+	mustEqual(t, cache.Stats(), TokenCacheStats{Size: 1, Hits: 2, Misses: 1})
+
+	source := NewTokenSource(client, &Token{RefreshToken: "REFRESH_TOKEN"}, TokenSourceConfig{})
+	_, err := source.Token(context.Background())
+	mustOk(t, err)
+
+	bad := NewTokenSource(client, &Token{RefreshToken: "REVOKED"}, TokenSourceConfig{})
+	_, err = bad.Token(context.Background())
+	mustFail(t, err)
+
+	mustEqual(t, source.Stats(), TokenSourceStats{Refreshes: 1})
+	mustEqual(t, bad.Stats(), TokenSourceStats{RefreshFailures: 1})
+
+	stats := client.Stats()
+	mustEqual(t, stats, ClientStats{
+		TokensIssued:        2,
+		Refreshes:           1,
+		Failures:            1,
+		AutoDetectFallbacks: 1,
+	})
+
+	b, err := json.Marshal(stats)
+	mustOk(t, err)
+	mustEqual(t, string(b), `{"tokens_issued":2,"refreshes":1,"failures":1,"auto_detect_fallbacks":1,"hedges":0}`)
+}
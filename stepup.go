@@ -0,0 +1,218 @@
+package oauth2
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StepUpError is returned when an authentication doesn't satisfy
+// Config.MaxAge or Config.ACRValues, the user must re-authenticate.
+type StepUpError struct {
+	Reason string // Reason describes the unmet requirement.
+}
+
+func (e *StepUpError) Error() string {
+	return "oauth2: step-up authentication required: " + e.Reason
+}
+
+// CheckAuthentication validates `auth_time` and `acr` claims of the ID token
+// against Config.MaxAge and Config.ACRValues, returning *StepUpError when unmet.
+//
+// The ID token must be verified by the caller beforehand.
+func (c *Client) CheckAuthentication(idToken string) error {
+	var claims struct {
+		AuthTime int64  `json:"auth_time"`
+		ACR      string `json:"acr"`
+	}
+	if _, err := decodeJWT(idToken, &claims); err != nil {
+		return err
+	}
+
+	if maxAge := c.config.MaxAge; maxAge > 0 {
+		if claims.AuthTime == 0 {
+			return &StepUpError{Reason: "auth_time is missing"}
+		}
+		authTime := time.Unix(claims.AuthTime, 0)
+		if age := timeNow().Sub(authTime); age > maxAge {
+			return &StepUpError{Reason: fmt.Sprintf("authenticated %v ago, max age is %v", age.Truncate(time.Second), maxAge)}
+		}
+	}
+
+	if acrs := c.config.ACRValues; len(acrs) > 0 {
+		for _, acr := range acrs {
+			if claims.ACR == acr {
+				return nil
+			}
+		}
+		return &StepUpError{Reason: fmt.Sprintf("acr %q is not one of %q", claims.ACR, acrs)}
+	}
+	return nil
+}
+
+// ErrInteractionRequired matches *InteractionRequiredError with errors.Is.
+var ErrInteractionRequired = errors.New("oauth2: interaction required")
+
+// InteractionRequiredError is returned by StepUpFromResponse when a resource server
+// requires a re-authorization of the user with more scopes or a stronger authentication,
+// and by token requests when the token endpoint responds with `consent_required`,
+// `interaction_required` or `login_required`, so refresh loops know when to stop.
+// The caller should redirect the user to AuthURL.
+type InteractionRequiredError struct {
+	AuthURL   string        // AuthURL is the re-authorization URL.
+	ErrorCode string        // ErrorCode is `error` of the challenge, like `insufficient_scope`.
+	Scopes    []string      // Scopes are all scopes requested by AuthURL.
+	ACRValues []string      // ACRValues are authentication context classes required by the resource server.
+	MaxAge    time.Duration // MaxAge is a maximum authentication age required by the resource server.
+
+	// State is a random state of AuthURL when it's built for a token endpoint error,
+	// keep it to validate the callback.
+	State string
+
+	// Err is the token endpoint error, nil for StepUpFromResponse.
+	Err error
+}
+
+func (e *InteractionRequiredError) Error() string {
+	return "oauth2: interaction required: " + e.ErrorCode
+}
+
+func (e *InteractionRequiredError) Is(target error) bool { return target == ErrInteractionRequired }
+
+func (e *InteractionRequiredError) Unwrap() error { return e.Err }
+
+// interactionRequired converts a token endpoint error asking for the user into *InteractionRequiredError.
+// AuthURL is empty when Config.AuthURL is not set.
+func (c *Client) interactionRequired(err error) error {
+	var rerr *RetrieveError
+	if !errors.As(err, &rerr) {
+		return err
+	}
+
+	params := url.Values{}
+	switch rerr.ErrorCode {
+	case "consent_required":
+		params.Set("prompt", "consent")
+	case "login_required":
+		params.Set("prompt", "login")
+	case "interaction_required":
+	default:
+		return err
+	}
+
+	e := &InteractionRequiredError{
+		ErrorCode: rerr.ErrorCode,
+		Scopes:    c.config.Scopes,
+		Err:       err,
+	}
+	if c.config.AuthURL == "" {
+		return e
+	}
+
+	state, serr := randomFromCharset(32, pkceCharset)
+	if serr != nil {
+		return err
+	}
+	authURL, uerr := c.BuildAuthCodeURL(state, params)
+	if uerr != nil {
+		return err
+	}
+	e.AuthURL, e.State = authURL, state
+	return e
+}
+
+// StepUpFromResponse inspects a 401 or 403 response of a resource server for a Bearer challenge
+// with `insufficient_scope` (RFC 6750 section 3.1) or `insufficient_user_authentication` (RFC 9470)
+// and returns *InteractionRequiredError with a re-authorization URL. Required scopes are added to
+// Config.Scopes, `acr_values` and `max_age` hints are passed through and the user is prompted to log in.
+//
+// It returns nil if the response doesn't ask for a step-up.
+func (c *Client) StepUpFromResponse(resp *http.Response, state string) error {
+	if resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden {
+		return nil
+	}
+
+	var challenge map[string]string
+	for _, h := range resp.Header.Values("WWW-Authenticate") {
+		if challenge = parseBearerChallenge(h); challenge != nil {
+			break
+		}
+	}
+
+	code := challenge["error"]
+	if code != "insufficient_scope" && code != "insufficient_user_authentication" {
+		return nil
+	}
+
+	e := &InteractionRequiredError{
+		ErrorCode: code,
+		Scopes:    normalizeScopes(append(ParseScopes(challenge["scope"]), c.config.Scopes...)),
+		ACRValues: strings.Fields(challenge["acr_values"]),
+	}
+	params := url.Values{
+		"prompt": []string{"login"},
+	}
+	if len(e.Scopes) > 0 {
+		params.Set("scope", JoinScopes(e.Scopes))
+	}
+	if len(e.ACRValues) > 0 {
+		params.Set("acr_values", strings.Join(e.ACRValues, " "))
+	}
+	if maxAge, err := strconv.ParseInt(challenge["max_age"], 10, 64); err == nil && maxAge >= 0 {
+		e.MaxAge = time.Duration(maxAge) * time.Second
+		params.Set("max_age", challenge["max_age"])
+	}
+
+	authURL, err := c.BuildAuthCodeURL(state, params)
+	if err != nil {
+		return err
+	}
+	e.AuthURL = authURL
+	return e
+}
+
+// parseBearerChallenge parses auth-params of a Bearer challenge of WWW-Authenticate header,
+// RFC 7235 section 4.1. Returns nil if the header has no Bearer challenge.
+func parseBearerChallenge(header string) map[string]string {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return nil
+	}
+
+	params := map[string]string{}
+	for {
+		rest = strings.TrimLeft(rest, " ,")
+		if rest == "" {
+			return params
+		}
+
+		var key string
+		key, rest, _ = strings.Cut(rest, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				b.WriteByte(rest[i])
+			}
+			value = b.String()
+			if i < len(rest) {
+				i++ // closing quote.
+			}
+			rest = rest[i:]
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+			value = strings.TrimSpace(value)
+		}
+		params[key] = value
+	}
+}
@@ -0,0 +1,151 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMaxAgeAndACRValuesInAuthURL(t *testing.T) {
+	client := NewClient(http.DefaultClient, Config{
+		ClientID:  "CLIENT_ID",
+		AuthURL:   "https://example.com/auth",
+		MaxAge:    5 * time.Minute,
+		ACRValues: []string{"urn:mace:incommon:iap:silver", "mfa"},
+	})
+
+	mustEqual(t, client.AuthCodeURL("state"), "https://example.com/auth?acr_values=urn%3Amace%3Aincommon%3Aiap%3Asilver+mfa&client_id=CLIENT_ID&max_age=300&response_type=code&state=state")
+}
+
+func TestCheckAuthentication(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	client := NewClient(http.DefaultClient, Config{
+		MaxAge:    5 * time.Minute,
+		ACRValues: []string{"mfa"},
+	})
+
+	testCases := []struct {
+		claims map[string]any
+		ok     bool
+	}{
+		{map[string]any{"auth_time": now.Add(-time.Minute).Unix(), "acr": "mfa"}, true},
+		{map[string]any{"auth_time": now.Add(-time.Hour).Unix(), "acr": "mfa"}, false},
+		{map[string]any{"acr": "mfa"}, false},
+		{map[string]any{"auth_time": now.Unix(), "acr": "pwd"}, false},
+		{map[string]any{"auth_time": now.Unix()}, false},
+	}
+
+	for _, tc := range testCases {
+		idToken := makeJWT(t, jwtHeader{Algorithm: "RS256"}, tc.claims)
+		err := client.CheckAuthentication(idToken)
+
+		var stepUp *StepUpError
+		mustEqual(t, errors.As(err, &stepUp), !tc.ok)
+		mustEqual(t, err == nil, tc.ok)
+	}
+}
+
+func TestStepUpFromResponse(t *testing.T) {
+	client := NewClient(http.DefaultClient, Config{
+		ClientID: "CLIENT_ID",
+		AuthURL:  "https://example.com/auth",
+		Scopes:   []string{"read"},
+		MaxAge:   time.Hour,
+	})
+
+	resp := &http.Response{
+		StatusCode: http.StatusForbidden,
+		Header: http.Header{"Www-Authenticate": {
+			`Basic realm="api"`,
+			`Bearer realm="api", error="insufficient_scope", scope="payments:write read"`,
+		}},
+	}
+	err := client.StepUpFromResponse(resp, "state")
+
+	var ire *InteractionRequiredError
+	mustEqual(t, errors.As(err, &ire), true)
+	mustEqual(t, ire.ErrorCode, "insufficient_scope")
+	mustEqual(t, ire.Scopes, []string{"payments:write", "read"})
+	mustEqual(t, ire.AuthURL, "https://example.com/auth?client_id=CLIENT_ID&max_age=3600&prompt=login&response_type=code&scope=payments%3Awrite+read&state=state")
+
+	resp = &http.Response{
+		StatusCode: http.StatusUnauthorized,
+		Header: http.Header{"Www-Authenticate": {
+			`Bearer error="insufficient_user_authentication", error_description="A different authentication level is required", acr_values="myACR", max_age=5`,
+		}},
+	}
+	err = client.StepUpFromResponse(resp, "state")
+
+	mustEqual(t, errors.As(err, &ire), true)
+	mustEqual(t, ire.ACRValues, []string{"myACR"})
+	mustEqual(t, ire.MaxAge, 5*time.Second)
+	mustEqual(t, ire.AuthURL, "https://example.com/auth?acr_values=myACR&client_id=CLIENT_ID&max_age=5&prompt=login&response_type=code&scope=read&state=state")
+}
+
+func TestStepUpFromResponse_NotRequired(t *testing.T) {
+	client := NewClient(http.DefaultClient, Config{AuthURL: "https://example.com/auth"})
+
+	testCases := []*http.Response{
+		{StatusCode: http.StatusOK, Header: http.Header{"Www-Authenticate": {`Bearer error="insufficient_scope"`}}},
+		{StatusCode: http.StatusUnauthorized, Header: http.Header{"Www-Authenticate": {`Bearer error="invalid_token"`}}},
+		{StatusCode: http.StatusUnauthorized, Header: http.Header{"Www-Authenticate": {`Basic realm="insufficient_scope"`}}},
+		{StatusCode: http.StatusForbidden, Header: http.Header{}},
+	}
+
+	for _, resp := range testCases {
+		mustOk(t, client.StepUpFromResponse(resp, "state"))
+	}
+}
+
+func TestParseBearerChallenge(t *testing.T) {
+	testCases := []struct {
+		header string
+		want   map[string]string
+	}{
+		{`Basic realm="x"`, nil},
+		{`Bearer`, map[string]string{}},
+		{`bearer realm="a, b", error=invalid_token`, map[string]string{"realm": "a, b", "error": "invalid_token"}},
+		{`Bearer realm="say \"hi\"",scope="a b"`, map[string]string{"realm": `say "hi"`, "scope": "a b"}},
+		{`Bearer error="unterminated`, map[string]string{"error": "unterminated"}},
+	}
+
+	for _, tc := range testCases {
+		mustEqual(t, parseBearerChallenge(tc.header), tc.want)
+	}
+}
+
+func TestInteractionRequired_TokenEndpoint(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error": "consent_required", "error_description": "new scopes need consent"}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		ClientID: "CLIENT_ID",
+		AuthURL:  "https://example.com/auth",
+		TokenURL: ts.URL,
+		Mode:     InHeaderMode,
+		Scopes:   []string{"read"},
+	})
+
+	_, err := client.Token(context.Background(), "REFRESH_TOKEN")
+	mustEqual(t, errors.Is(err, ErrInteractionRequired), true)
+
+	var ire *InteractionRequiredError
+	mustEqual(t, errors.As(err, &ire), true)
+	mustEqual(t, ire.ErrorCode, "consent_required")
+	mustEqual(t, len(ire.State), 32)
+	mustEqual(t, ire.AuthURL, "https://example.com/auth?client_id=CLIENT_ID&prompt=consent&response_type=code&scope=read&state="+ire.State)
+
+	var rerr *RetrieveError
+	mustEqual(t, errors.As(err, &rerr), true)
+	mustEqual(t, rerr.ErrorDescription, "new scopes need consent")
+}
@@ -0,0 +1,32 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTokenNotFound is returned by TokenStore when there is no token for a key.
+var ErrTokenNotFound = errors.New("oauth2: token not found")
+
+// TokenStore persists tokens so they can be shared between processes and restarts.
+type TokenStore interface {
+	// Load returns a token for the key or ErrTokenNotFound.
+	Load(ctx context.Context, key string) (*Token, error)
+
+	// Save stores a token for the key.
+	Save(ctx context.Context, key string, token *Token) error
+
+	// Delete removes a token for the key.
+	Delete(ctx context.Context, key string) error
+}
+
+// ErrTokenConflict is returned by TokenRotator when the stored token was changed concurrently.
+var ErrTokenConflict = errors.New("oauth2: token was changed concurrently")
+
+// TokenRotator is an optional interface of TokenStore for optimistic concurrency.
+// When the store implements it, TokenSource saves refreshed tokens with Rotate.
+type TokenRotator interface {
+	// Rotate replaces the token for the key only if the stored token still has the given refresh token.
+	// Otherwise ErrTokenConflict is returned.
+	Rotate(ctx context.Context, key, refreshToken string, token *Token) error
+}
@@ -1,6 +1,9 @@
 package oauth2
 
 import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
 	"net/url"
 	"strconv"
 	"strings"
@@ -10,11 +13,19 @@ import (
 // Token represents the credentials used to authorize the requests to access
 // protected resources on the OAuth 2.0 provider's backend.
 type Token struct {
-	AccessToken  string      `json:"access_token"`            // AccessToken is the token that authorizes and authenticates the requests.
-	TokenType    string      `json:"token_type,omitempty"`    // TokenType is the type of token. The Type method returns either this or "Bearer".
-	RefreshToken string      `json:"refresh_token,omitempty"` // RefreshToken is a token that's used by the application to refresh the access token if it expires.
-	Expiry       time.Time   `json:"expiry,omitempty"`        // Expiry is the expiration time of the access token.
-	Raw          interface{} // Raw optionally contains extra metadata from the server when updating a token.
+	AccessToken   string      `json:"access_token"`             // AccessToken is the token that authorizes and authenticates the requests.
+	TokenType     string      `json:"token_type,omitempty"`     // TokenType is the type of token. The Type method returns either this or "Bearer".
+	RefreshToken  string      `json:"refresh_token,omitempty"`  // RefreshToken is a token that's used by the application to refresh the access token if it expires.
+	Expiry        time.Time   `json:"expiry,omitempty"`         // Expiry is the expiration time of the access token.
+	RefreshExpiry time.Time   `json:"refresh_expiry,omitempty"` // RefreshExpiry is the expiration time of the refresh token, if the server reports it.
+	Raw           interface{} // Raw optionally contains extra metadata from the server when updating a token.
+
+	// Grant metadata, set by Client for retrieved tokens, so stored tokens can be traced to their origin.
+	ObtainedAt time.Time `json:"obtained_at,omitempty"` // ObtainedAt is when the token was retrieved.
+	GrantType  string    `json:"grant_type,omitempty"`  // GrantType is the `grant_type` of the token request.
+	Issuer     string    `json:"issuer,omitempty"`      // Issuer is Config.Issuer of the client.
+	TokenURL   string    `json:"token_url,omitempty"`   // TokenURL is the endpoint the token was retrieved from.
+	Mode       Mode      `json:"mode,omitempty"`        // Mode is the authentication mode that succeeded.
 }
 
 // Type returns t.TokenType if non-empty, else "Bearer".
@@ -33,6 +44,36 @@ func (t *Token) Type() string {
 	}
 }
 
+// Equal reports whether the tokens have the same access and refresh tokens, type and expiry times,
+// Raw and grant metadata are ignored. The tokens are compared in constant time.
+func (t *Token) Equal(other *Token) bool {
+	if t == nil || other == nil {
+		return t == other
+	}
+
+	// evaluate everything, so timing doesn't depend on which field differs.
+	same := secretEqual(t.AccessToken, other.AccessToken)
+	same = secretEqual(t.RefreshToken, other.RefreshToken) && same
+	return same &&
+		t.Type() == other.Type() &&
+		t.Expiry.Equal(other.Expiry) &&
+		t.RefreshExpiry.Equal(other.RefreshExpiry)
+}
+
+// SameAccessToken reports whether the tokens have the same non-empty access token.
+// The access tokens are compared in constant time.
+func SameAccessToken(a, b *Token) bool {
+	if a == nil || b == nil || a.AccessToken == "" {
+		return false
+	}
+	return secretEqual(a.AccessToken, b.AccessToken)
+}
+
+// secretEqual compares the secrets in constant time, only their lengths can leak.
+func secretEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
 // Extra returns an extra field.
 // Extra fields are key-value pairs returned by the server as a
 // part of the token retrieval response.
@@ -66,12 +107,83 @@ func (t *Token) Extra(key string) interface{} {
 	}
 }
 
+// DecodeExtra decodes the fields of the token response into v, a pointer to a struct with json tags,
+// for typed access to provider-specific fields like `instance_url` or `unionid`.
+// Values of form-encoded responses are strings, use the `json:",string"` option for numeric fields.
+// JSON numbers beyond 2^53 lose precision.
+func (t *Token) DecodeExtra(v interface{}) error {
+	var raw interface{}
+	switch r := t.Raw.(type) {
+	case nil:
+		return nil
+	case url.Values:
+		m := make(map[string]string, len(r))
+		for k := range r {
+			m[k] = r.Get(k)
+		}
+		raw = m
+	default:
+		raw = r
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("oauth2: cannot decode extra fields: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("oauth2: cannot decode extra fields: %w", err)
+	}
+	return nil
+}
+
+// Scopes returns granted scopes from the `scope` field of the token response.
+// Nil means the response had no scopes, RFC 6749 section 5.1 treats this as all requested scopes granted.
+func (t *Token) Scopes() []string {
+	return ParseScopes(t.scope())
+}
+
+// scope returns the `scope` field of the token response as is.
+func (t *Token) scope() string {
+	switch v := t.Raw.(type) {
+	case map[string]interface{}:
+		s, _ := v["scope"].(string)
+		return s
+	case url.Values:
+		return v.Get("scope") // not Extra, numeric scopes must stay strings.
+	default:
+		return ""
+	}
+}
+
+// MissingScopes returns requested scopes which were not granted.
+func (t *Token) MissingScopes(requested []string) []string {
+	return missingScopes(t.Scopes(), requested)
+}
+
+func missingScopes(granted, requested []string) []string {
+	if granted == nil {
+		return nil
+	}
+
+	var missing []string
+	for _, s := range normalizeScopes(requested) {
+		if !ScopesContain(granted, []string{s}) {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
 // Valid reports whether t is non-nil, has an AccessToken, and is not expired.
 func (t *Token) Valid() bool {
-	return t != nil && t.AccessToken != "" && !t.IsExpired()
+	return t.validAt(timeNow())
+}
+
+func (t *Token) validAt(now time.Time) bool {
+	return t != nil && t.AccessToken != "" && !t.expiredAt(now)
 }
 
-// timeNow is used only in Token.IsExpired, is always time.Now, except some tests.
+// timeNow is used only to check token expiry, is always time.Now, except some tests.
 var timeNow = time.Now
 
 // expiryDelta determines how earlier a token should be considered
@@ -81,8 +193,24 @@ const expiryDelta = 10 * time.Second
 
 // IsExpired reports whether the token is expired.
 func (t *Token) IsExpired() bool {
+	return t.expiredAt(timeNow())
+}
+
+func (t *Token) expiredAt(now time.Time) bool {
 	if t.Expiry.IsZero() {
 		return false
 	}
-	return t.Expiry.Round(0).Add(-expiryDelta).Before(timeNow())
+	return t.Expiry.Round(0).Add(-expiryDelta).Before(now)
+}
+
+// IsRefreshExpired reports whether the refresh token is known to be expired.
+func (t *Token) IsRefreshExpired() bool {
+	return t.refreshExpiredAt(timeNow())
+}
+
+func (t *Token) refreshExpiredAt(now time.Time) bool {
+	if t.RefreshExpiry.IsZero() {
+		return false
+	}
+	return t.RefreshExpiry.Round(0).Add(-expiryDelta).Before(now)
 }
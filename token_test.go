@@ -69,6 +69,26 @@ func TestTokenExpiry(t *testing.T) {
 	}
 }
 
+func TestTokenRefreshExpiry(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	testCases := []struct {
+		token *Token
+		want  bool
+	}{
+		{&Token{}, false},
+		{&Token{RefreshExpiry: now.Add(time.Hour)}, false},
+		{&Token{RefreshExpiry: now.Add(expiryDelta - 1*time.Nanosecond)}, true},
+		{&Token{RefreshExpiry: now.Add(-1 * time.Hour)}, true},
+	}
+
+	for _, tc := range testCases {
+		mustEqual(t, tc.token.IsRefreshExpired(), tc.want)
+	}
+}
+
 func TestExtraValueRetrieval(t *testing.T) {
 	kvmap := map[string]string{
 		"scope":       "user",
@@ -103,3 +123,75 @@ func TestExtraValueRetrieval(t *testing.T) {
 		mustEqual(t, tok.Extra(tc.key), tc.value)
 	}
 }
+
+func TestTokenMissingScopes(t *testing.T) {
+	testCases := []struct {
+		token *Token
+		want  []string
+	}{
+		{&Token{}, nil},
+		{&Token{Raw: map[string]any{"scope": "read write"}}, nil},
+		{&Token{Raw: map[string]any{"scope": "read"}}, []string{"write"}},
+		{&Token{Raw: url.Values{"scope": {"123 read"}}}, []string{"write"}},
+	}
+
+	for _, tc := range testCases {
+		mustEqual(t, tc.token.MissingScopes([]string{"write", "read"}), tc.want)
+	}
+}
+
+func TestTokenEqual(t *testing.T) {
+	expiry := time.Now().Add(time.Hour)
+	a := &Token{AccessToken: "ACCESS", RefreshToken: "REFRESH", TokenType: "bearer", Expiry: expiry}
+
+	mustEqual(t, a.Equal(&Token{AccessToken: "ACCESS", RefreshToken: "REFRESH", Expiry: expiry.UTC(), Raw: "ignored"}), true)
+	mustEqual(t, a.Equal(&Token{AccessToken: "ACCESS", RefreshToken: "OTHER", Expiry: expiry}), false)
+	mustEqual(t, a.Equal(&Token{AccessToken: "OTHER", RefreshToken: "REFRESH", Expiry: expiry}), false)
+	mustEqual(t, a.Equal(&Token{AccessToken: "ACCESS", RefreshToken: "REFRESH", TokenType: "MAC", Expiry: expiry}), false)
+	mustEqual(t, a.Equal(&Token{AccessToken: "ACCESS", RefreshToken: "REFRESH"}), false)
+	mustEqual(t, a.Equal(nil), false)
+
+	var none *Token
+	mustEqual(t, none.Equal(nil), true)
+}
+
+func TestSameAccessToken(t *testing.T) {
+	mustEqual(t, SameAccessToken(&Token{AccessToken: "ACCESS"}, &Token{AccessToken: "ACCESS", RefreshToken: "R"}), true)
+	mustEqual(t, SameAccessToken(&Token{AccessToken: "ACCESS"}, &Token{AccessToken: "ACCESS2"}), false)
+	mustEqual(t, SameAccessToken(&Token{}, &Token{}), false)
+	mustEqual(t, SameAccessToken(nil, &Token{AccessToken: "ACCESS"}), false)
+}
+
+func TestTokenDecodeExtra(t *testing.T) {
+	type extra struct {
+		InstanceURL string `json:"instance_url"`
+		IssuedAt    int64  `json:"issued_at"`
+		UnionID     string `json:"unionid"`
+	}
+
+	var e extra
+	tok := &Token{Raw: map[string]interface{}{
+		"access_token": "ACCESS_TOKEN",
+		"instance_url": "https://example.my.salesforce.com",
+		"issued_at":    float64(1700000000123),
+		"unionid":      "UNION",
+	}}
+	mustOk(t, tok.DecodeExtra(&e))
+	mustEqual(t, e, extra{InstanceURL: "https://example.my.salesforce.com", IssuedAt: 1700000000123, UnionID: "UNION"})
+
+	type formExtra struct {
+		OpenID    string `json:"openid"`
+		ExpiresIn int    `json:"expires_in,string"`
+	}
+
+	var fe formExtra
+	tok = &Token{Raw: url.Values{"openid": {"OPEN_ID"}, "expires_in": {"7200"}}}
+	mustOk(t, tok.DecodeExtra(&fe))
+	mustEqual(t, fe, formExtra{OpenID: "OPEN_ID", ExpiresIn: 7200})
+
+	var bad struct {
+		ExpiresIn int `json:"expires_in"`
+	}
+	mustFail(t, tok.DecodeExtra(&bad))
+	mustOk(t, (&Token{}).DecodeExtra(&e))
+}
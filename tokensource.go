@@ -0,0 +1,459 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Locker serializes token refreshes between processes.
+//
+// It is useful for horizontally scaled deployments with rotating refresh tokens,
+// where a concurrent refresh in one replica invalidates the refresh token of others.
+type Locker interface {
+	// TryLock tries to acquire the lock for the key and reports whether it succeeded.
+	TryLock(ctx context.Context, key string) (bool, error)
+
+	// Unlock releases the lock for the key.
+	Unlock(ctx context.Context, key string) error
+}
+
+// ErrRefreshTokenExpired is returned by TokenSource when the refresh token has expired
+// and the user must go through the authorization again.
+var ErrRefreshTokenExpired = errors.New("oauth2: refresh token expired")
+
+// RefreshDeadlineError is returned by TokenSource when the context deadline is too close
+// to refresh the expired token, see TokenSourceConfig.MinRefreshTime.
+type RefreshDeadlineError struct {
+	Remaining      time.Duration // Remaining is the time left until the context deadline.
+	MinRefreshTime time.Duration // MinRefreshTime is TokenSourceConfig.MinRefreshTime.
+}
+
+func (e *RefreshDeadlineError) Error() string {
+	return fmt.Sprintf("oauth2: not enough time to refresh token: %v left, %v needed", e.Remaining, e.MinRefreshTime)
+}
+
+// defaultRefreshRetryInterval is TokenSourceConfig.RefreshRetryInterval by default.
+const defaultRefreshRetryInterval = 30 * time.Second
+
+// TokenSourceConfig describes how TokenSource refreshes tokens.
+type TokenSourceConfig struct {
+	Key    string     // Key identifies the token in the Locker and the Store.
+	Locker Locker     // Locker is an optional lock taken before refreshing a token.
+	Store  TokenStore // Store is an optional storage shared with other token sources.
+
+	// Params are extra parameters of every refresh request, like `device_id`
+	// for providers binding refresh tokens to a device or a session.
+	Params url.Values
+
+	// QuarantineAfter is a number of consecutive `invalid_grant` refresh failures after which
+	// the refresh token is quarantined, see QuarantineError. Zero disables the quarantine.
+	QuarantineAfter int
+
+	// QuarantineDuration is how long the refresh token stays quarantined, zero means until Release.
+	QuarantineDuration time.Duration
+
+	// RefreshWindow is how long before the expiry the token is refreshed proactively, like 5 minutes.
+	// The current token is returned if the early refresh fails. Zero means refreshing only
+	// when the token is no longer valid, see ExpiryDelta.
+	RefreshWindow time.Duration
+
+	// RefreshRetryInterval is how long early refreshes are skipped after a failed one,
+	// the token is still refreshed when it's no longer valid. Default is 30 seconds.
+	RefreshRetryInterval time.Duration
+
+	// ExpiryDelta is how long before the expiry the token is considered invalid,
+	// to avoid expiry races with the resource server. Default is 10 seconds.
+	ExpiryDelta time.Duration
+
+	// TrackUsage counts Token calls and keeps the last use time, see TokenSource.Stats,
+	// to find unused tokens to revoke. Metrics implementing UsageMetrics are notified about every use.
+	TrackUsage bool
+
+	// MinRefreshTime is how long a refresh needs at least. When the context deadline is closer,
+	// the current token is returned if it hasn't actually expired yet (within the expiry margin),
+	// otherwise *RefreshDeadlineError, instead of starting a refresh which cannot finish.
+	// Zero disables the check.
+	MinRefreshTime time.Duration
+
+	_ struct{} // enforce explicit field names.
+}
+
+// TokenSource returns a valid token, refreshing it when it has expired.
+// It is safe for concurrent use.
+type TokenSource struct {
+	client *Client
+	config TokenSourceConfig
+
+	mu    sync.Mutex
+	token *Token
+	stale string // stale is an invalidated access token, it's not used even if not expired.
+
+	// earlyRetryAt is when an early refresh is tried again after a failed one.
+	earlyRetryAt time.Time
+
+	quarantine quarantine
+
+	refreshes       atomic.Uint64
+	refreshFailures atomic.Uint64
+	quarantines     atomic.Uint64
+	uses            atomic.Uint64
+	lastUsed        atomic.Int64 // unix nanoseconds.
+}
+
+// NewTokenSource instantiates a new token source with a given client, initial token and config.
+func NewTokenSource(client *Client, token *Token, config TokenSourceConfig) *TokenSource {
+	ts := &TokenSource{
+		client: client,
+		config: config,
+		token:  token,
+	}
+	client.seedDetectedMode(token)
+	return ts
+}
+
+// Token returns a valid token, refreshing it if needed.
+func (ts *TokenSource) Token(ctx context.Context) (*Token, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if !ts.config.TrackUsage {
+		return ts.getToken(ctx)
+	}
+
+	refreshes := ts.refreshes.Load()
+	token, err := ts.getToken(ctx)
+	if err == nil {
+		ts.trackUse(refreshes == ts.refreshes.Load())
+	}
+	return token, err
+}
+
+// trackUse counts a use of the token, cached reports whether it was served without a refresh.
+func (ts *TokenSource) trackUse(cached bool) {
+	ts.uses.Add(1)
+	ts.lastUsed.Store(time.Now().UnixNano())
+
+	if m, ok := ts.client.config.Metrics.(UsageMetrics); ok {
+		m.TokenUsed(cached)
+	}
+}
+
+// Prime eagerly loads or refreshes the token, for example at startup,
+// so the first Token call doesn't pay for the round-trip.
+func (ts *TokenSource) Prime(ctx context.Context) error {
+	_, err := ts.Token(ctx)
+	return err
+}
+
+// ForceRefresh refreshes the token even if it's not expired,
+// for example to rotate it proactively on a security event.
+func (ts *TokenSource) ForceRefresh(ctx context.Context) (*Token, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.invalidate()
+	return ts.getToken(ctx)
+}
+
+// Invalidate discards the current access token, for example after a 401 response,
+// the next Token call refreshes it. The refresh token is kept.
+func (ts *TokenSource) Invalidate() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.invalidate()
+}
+
+// SetToken replaces the current token with an externally obtained one,
+// for example after an interactive re-authorization. The token is saved to the store, if any,
+// and a quarantine of the previous refresh token ends.
+func (ts *TokenSource) SetToken(ctx context.Context, token *Token) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if store := ts.config.Store; store != nil {
+		if err := store.Save(ctx, ts.config.Key, token); err != nil {
+			return err
+		}
+	}
+	ts.token = token
+	ts.stale = ""
+	ts.earlyRetryAt = time.Time{}
+	ts.quarantine = quarantine{}
+	return nil
+}
+
+// invalidateToken discards the access token if it's still the current one,
+// a token refreshed concurrently is kept.
+func (ts *TokenSource) invalidateToken(accessToken string) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.token != nil && secretEqual(ts.token.AccessToken, accessToken) {
+		ts.invalidate()
+	}
+}
+
+func (ts *TokenSource) invalidate() {
+	if ts.token != nil {
+		ts.stale = ts.token.AccessToken
+	}
+}
+
+func (ts *TokenSource) getToken(ctx context.Context) (*Token, error) {
+	if ts.fresh(ts.token) {
+		return ts.token, nil
+	}
+	if ts.usable(ts.token) && timeNow().Before(ts.earlyRetryAt) {
+		// an early refresh has failed recently, don't hammer the provider.
+		return ts.token, nil
+	}
+	if err := ts.load(ctx); err != nil {
+		return nil, err
+	}
+	if ts.fresh(ts.token) {
+		return ts.token, nil
+	}
+	if ts.token == nil {
+		return nil, errors.New("oauth2: token is not set")
+	}
+
+	// within RefreshWindow the current token is still valid, a failed early refresh is not an error.
+	current := ts.token
+	if !ts.usable(current) {
+		current = nil
+	}
+
+	if err := ts.checkQuarantine(); err != nil {
+		if current != nil {
+			return current, nil
+		}
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok && ts.config.MinRefreshTime > 0 {
+		if remaining := time.Until(deadline); remaining < ts.config.MinRefreshTime {
+			if ts.validFor(ts.token, 0) {
+				return ts.token, nil
+			}
+			return nil, &RefreshDeadlineError{Remaining: remaining, MinRefreshTime: ts.config.MinRefreshTime}
+		}
+	}
+
+	token, err := ts.refresh(ctx)
+	if err := ts.recordRefresh(ctx, err); err != nil {
+		if current != nil {
+			ts.earlyRetryAt = timeNow().Add(ts.refreshRetryInterval())
+			return current, nil
+		}
+		return nil, err
+	}
+	ts.token = token
+	ts.stale = ""
+	ts.earlyRetryAt = time.Time{}
+	return token, nil
+}
+
+func (ts *TokenSource) refreshRetryInterval() time.Duration {
+	if d := ts.config.RefreshRetryInterval; d > 0 {
+		return d
+	}
+	return defaultRefreshRetryInterval
+}
+
+// usable reports whether the token is valid and not invalidated, see TokenSourceConfig.ExpiryDelta.
+func (ts *TokenSource) usable(token *Token) bool {
+	return ts.validFor(token, ts.expiryDelta())
+}
+
+// fresh reports whether the token is usable and doesn't need an early refresh, see TokenSourceConfig.RefreshWindow.
+func (ts *TokenSource) fresh(token *Token) bool {
+	margin := ts.expiryDelta()
+	if w := ts.config.RefreshWindow; w > margin {
+		margin = w
+	}
+	return ts.validFor(token, margin)
+}
+
+func (ts *TokenSource) expiryDelta() time.Duration {
+	if d := ts.config.ExpiryDelta; d > 0 {
+		return d
+	}
+	return expiryDelta
+}
+
+// validFor reports whether the token is not invalidated and doesn't expire within the margin.
+func (ts *TokenSource) validFor(token *Token, margin time.Duration) bool {
+	if token == nil || token.AccessToken == "" || (ts.stale != "" && secretEqual(token.AccessToken, ts.stale)) {
+		return false
+	}
+	return token.Expiry.IsZero() || !token.Expiry.Round(0).Add(-margin).Before(timeNow())
+}
+
+func (ts *TokenSource) refresh(ctx context.Context) (token *Token, err error) {
+	defer func() {
+		if err != nil {
+			ts.refreshFailures.Add(1)
+		} else {
+			ts.refreshes.Add(1)
+		}
+	}()
+	if m := ts.client.config.Metrics; m != nil {
+		defer func() {
+			if err != nil {
+				m.RefreshFailure(err)
+			}
+		}()
+	}
+	if ts.client.config.AuditSink != nil {
+		event := AuditEvent{
+			Time:      time.Now(),
+			Operation: AuditRefresh,
+			GrantType: GrantTypeRefreshToken,
+			Key:       ts.config.Key,
+		}
+		defer func() {
+			event.Err = err
+			ts.client.audit(ctx, event)
+		}()
+	}
+
+	if ts.config.Locker != nil {
+		if err := ts.lock(ctx); err != nil {
+			return nil, err
+		}
+		defer ts.unlock(ctx)
+
+		// the token might be refreshed by another process while we were waiting.
+		if err := ts.load(ctx); err != nil {
+			return nil, err
+		}
+		if ts.fresh(ts.token) {
+			return ts.token, nil
+		}
+	}
+
+	ctx = context.WithValue(ctx, eventKeyKey{}, ts.config.Key)
+	if ts.token.refreshExpiredAt(timeNow()) {
+		ts.client.emit(ctx, TokenEvent{
+			Type:      TokenRefreshFailed,
+			GrantType: GrantTypeRefreshToken,
+			Err:       ErrRefreshTokenExpired,
+		})
+		return nil, ErrRefreshTokenExpired
+	}
+
+	if len(ts.config.Params) > 0 {
+		ctx = ContextWithParams(ctx, ts.config.Params)
+	}
+	token, err = ts.client.Token(ctx, ts.token.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+	// some providers don't rotate refresh tokens, keep the previous one.
+	if token.RefreshToken == "" {
+		token.RefreshToken = ts.token.RefreshToken
+	}
+	// the expiry belongs to the previous refresh token, a rotated one has its own.
+	if token.RefreshToken == ts.token.RefreshToken && token.RefreshExpiry.IsZero() {
+		token.RefreshExpiry = ts.token.RefreshExpiry
+	}
+
+	return ts.save(ctx, token)
+}
+
+// save stores the refreshed token. If the store reports a concurrent rotation
+// the stored token wins, as it was obtained first.
+func (ts *TokenSource) save(ctx context.Context, token *Token) (*Token, error) {
+	var err error
+	switch store := ts.config.Store.(type) {
+	case nil:
+		return token, nil
+
+	case TokenRotator:
+		err = store.Rotate(ctx, ts.config.Key, ts.token.RefreshToken, token)
+		if errors.Is(err, ErrTokenConflict) {
+			stored, err := ts.config.Store.Load(ctx, ts.config.Key)
+			if !errors.Is(err, ErrTokenNotFound) {
+				return stored, err
+			}
+			// deleted concurrently, keep the rotated refresh token rather than lose it.
+			err = ts.config.Store.Save(ctx, ts.config.Key, token)
+		}
+
+	default:
+		err = store.Save(ctx, ts.config.Key, token)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// load replaces the current token with the stored one, if any.
+func (ts *TokenSource) load(ctx context.Context) error {
+	if ts.config.Store == nil {
+		return nil
+	}
+
+	token, err := ts.config.Store.Load(ctx, ts.config.Key)
+	switch {
+	case errors.Is(err, ErrTokenNotFound):
+		return nil
+	case err != nil:
+		return err
+	default:
+		ts.client.seedDetectedMode(token)
+		ts.token = token
+		return nil
+	}
+}
+
+// lockRetryInterval is how often TokenSource retries to acquire a busy lock.
+const lockRetryInterval = 50 * time.Millisecond
+
+func (ts *TokenSource) lock(ctx context.Context) error {
+	ticker := time.NewTicker(lockRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		ok, err := ts.config.Locker.TryLock(ctx, ts.config.Key)
+		switch {
+		case err != nil:
+			return err
+		case ok:
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// unlockTimeout limits Locker.Unlock, the lock is released even if the refresh context is cancelled.
+const unlockTimeout = 5 * time.Second
+
+func (ts *TokenSource) unlock(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(detachedContext{ctx}, unlockTimeout)
+	defer cancel()
+
+	_ = ts.config.Locker.Unlock(ctx, ts.config.Key)
+}
+
+// detachedContext keeps values of the parent context but not its cancellation and deadline.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
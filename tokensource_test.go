@@ -0,0 +1,676 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTokenSource(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		mustOk(t, err)
+		mustEqual(t, string(body), "grant_type=refresh_token&refresh_token=REFRESH_TOKEN")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "NEW_ACCESS_TOKEN", "token_type": "bearer", "expires_in": 3600}`)
+	})
+	defer ts.Close()
+
+	expired := &Token{
+		AccessToken:  "ACCESS_TOKEN",
+		RefreshToken: "REFRESH_TOKEN",
+		Expiry:       time.Now().Add(-time.Hour),
+	}
+	src := NewTokenSource(newClient(ts.URL), expired, TokenSourceConfig{})
+
+	tok, err := src.Token(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "NEW_ACCESS_TOKEN")
+	mustEqual(t, tok.RefreshToken, "REFRESH_TOKEN")
+
+	tok2, err := src.Token(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok2, tok)
+}
+
+func TestTokenSource_Prime(t *testing.T) {
+	var calls int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "NEW_ACCESS_TOKEN", "expires_in": 3600}`)
+	})
+	defer ts.Close()
+
+	expired := &Token{
+		AccessToken:  "ACCESS_TOKEN",
+		RefreshToken: "REFRESH_TOKEN",
+		Expiry:       time.Now().Add(-time.Hour),
+	}
+	src := NewTokenSource(newClient(ts.URL), expired, TokenSourceConfig{})
+
+	err := src.Prime(context.Background())
+	mustOk(t, err)
+	mustEqual(t, calls, 1)
+
+	tok, err := src.Token(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "NEW_ACCESS_TOKEN")
+	mustEqual(t, calls, 1)
+}
+
+func TestTokenSource_ForceRefresh(t *testing.T) {
+	var calls int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "NEW_ACCESS_TOKEN_%d", "expires_in": 3600}`, calls)
+	})
+	defer ts.Close()
+
+	valid := &Token{
+		AccessToken:  "ACCESS_TOKEN",
+		RefreshToken: "REFRESH_TOKEN",
+		Expiry:       time.Now().Add(time.Hour),
+	}
+	src := NewTokenSource(newClient(ts.URL), valid, TokenSourceConfig{})
+
+	tok, err := src.ForceRefresh(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "NEW_ACCESS_TOKEN_1")
+	mustEqual(t, tok.RefreshToken, "REFRESH_TOKEN")
+
+	tok, err = src.Token(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "NEW_ACCESS_TOKEN_1")
+	mustEqual(t, calls, 1)
+}
+
+func TestTokenSource_Invalidate(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "NEW_ACCESS_TOKEN", "expires_in": 3600}`)
+	})
+	defer ts.Close()
+
+	// the stored token is the same bad one, it must not be loaded back.
+	store := &memStore{tokens: map[string]*Token{
+		"user-1": {
+			AccessToken:  "ACCESS_TOKEN",
+			RefreshToken: "REFRESH_TOKEN",
+			Expiry:       time.Now().Add(time.Hour),
+		},
+	}}
+	src := NewTokenSource(newClient(ts.URL), nil, TokenSourceConfig{
+		Key:   "user-1",
+		Store: store,
+	})
+
+	tok, err := src.Token(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "ACCESS_TOKEN")
+
+	src.Invalidate()
+
+	tok, err = src.Token(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "NEW_ACCESS_TOKEN")
+	mustEqual(t, store.tokens["user-1"], tok)
+}
+
+func TestTokenSource_SetToken(t *testing.T) {
+	store := &memStore{tokens: map[string]*Token{}}
+	src := NewTokenSource(newClient("http://localhost"), nil, TokenSourceConfig{
+		Key:   "user-1",
+		Store: store,
+	})
+
+	_, err := src.Token(context.Background())
+	mustFail(t, err)
+
+	tok := &Token{
+		AccessToken:  "ACCESS_TOKEN",
+		RefreshToken: "REFRESH_TOKEN",
+		Expiry:       time.Now().Add(time.Hour),
+	}
+	err = src.SetToken(context.Background(), tok)
+	mustOk(t, err)
+	mustEqual(t, store.tokens["user-1"], tok)
+
+	got, err := src.Token(context.Background())
+	mustOk(t, err)
+	mustEqual(t, got, tok)
+}
+
+func TestTokenSource_RefreshExpired(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("must not be called")
+	})
+	defer ts.Close()
+
+	expired := &Token{
+		AccessToken:   "ACCESS_TOKEN",
+		RefreshToken:  "REFRESH_TOKEN",
+		Expiry:        time.Now().Add(-time.Hour),
+		RefreshExpiry: time.Now().Add(-time.Minute),
+	}
+	src := NewTokenSource(newClient(ts.URL), expired, TokenSourceConfig{})
+
+	_, err := src.Token(context.Background())
+	mustEqual(t, err, ErrRefreshTokenExpired)
+}
+
+func TestTokenSource_KeepRefreshExpiry(t *testing.T) {
+	refreshExpiry := time.Now().Add(time.Hour).Round(0)
+
+	testCases := []struct {
+		response string
+		keep     bool
+	}{
+		{`{"access_token": "NEW_ACCESS_TOKEN"}`, true},
+		{`{"access_token": "NEW_ACCESS_TOKEN", "refresh_token": "REFRESH_TOKEN"}`, true},
+		{`{"access_token": "NEW_ACCESS_TOKEN", "refresh_token": "NEW_REFRESH_TOKEN"}`, false},
+	}
+
+	for _, tc := range testCases {
+		ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, tc.response)
+		})
+
+		expired := &Token{
+			AccessToken:   "ACCESS_TOKEN",
+			RefreshToken:  "REFRESH_TOKEN",
+			Expiry:        time.Now().Add(-time.Hour),
+			RefreshExpiry: refreshExpiry,
+		}
+		src := NewTokenSource(newClient(ts.URL), expired, TokenSourceConfig{})
+
+		tok, err := src.Token(context.Background())
+		mustOk(t, err)
+		mustEqual(t, tok.RefreshExpiry.Equal(refreshExpiry), tc.keep)
+		mustEqual(t, tok.RefreshExpiry.IsZero(), !tc.keep)
+		ts.Close()
+	}
+}
+
+func TestTokenSource_NoToken(t *testing.T) {
+	src := NewTokenSource(newClient("http://localhost"), nil, TokenSourceConfig{})
+
+	_, err := src.Token(context.Background())
+	mustFail(t, err)
+}
+
+func TestTokenSource_Locker(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "NEW_ACCESS_TOKEN", "refresh_token": "NEW_REFRESH_TOKEN"}`)
+	})
+	defer ts.Close()
+
+	locker := &memLocker{busy: 2, locked: map[string]bool{}}
+	expired := &Token{
+		AccessToken:  "ACCESS_TOKEN",
+		RefreshToken: "REFRESH_TOKEN",
+		Expiry:       time.Now().Add(-time.Hour),
+	}
+	src := NewTokenSource(newClient(ts.URL), expired, TokenSourceConfig{
+		Key:    "user-1",
+		Locker: locker,
+	})
+
+	tok, err := src.Token(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.RefreshToken, "NEW_REFRESH_TOKEN")
+	mustEqual(t, locker.attempts, 3)
+	mustEqual(t, locker.locked["user-1"], false)
+}
+
+func TestTokenSource_LockerCanceled(t *testing.T) {
+	locker := &memLocker{busy: 1 << 30, locked: map[string]bool{}}
+	expired := &Token{
+		AccessToken:  "ACCESS_TOKEN",
+		RefreshToken: "REFRESH_TOKEN",
+		Expiry:       time.Now().Add(-time.Hour),
+	}
+	src := NewTokenSource(newClient("http://localhost"), expired, TokenSourceConfig{
+		Key:    "user-1",
+		Locker: locker,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := src.Token(ctx)
+	mustEqual(t, err, context.DeadlineExceeded)
+}
+
+func TestTokenSource_LockerUnlockCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "NEW_ACCESS_TOKEN"}`)
+	})
+	defer ts.Close()
+
+	locker := &memLocker{locked: map[string]bool{}}
+	expired := &Token{
+		AccessToken:  "ACCESS_TOKEN",
+		RefreshToken: "REFRESH_TOKEN",
+		Expiry:       time.Now().Add(-time.Hour),
+	}
+	src := NewTokenSource(newClientWithConfig(Config{TokenURL: ts.URL, Mode: InHeaderMode}), expired, TokenSourceConfig{
+		Key:    "user-1",
+		Locker: locker,
+	})
+
+	_, _ = src.Token(ctx)
+	mustEqual(t, ctx.Err(), context.Canceled)
+	mustEqual(t, locker.locked["user-1"], false)
+	mustEqual(t, locker.unlockCtxErr, nil)
+}
+
+func TestTokenSource_Store(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		mustOk(t, err)
+		mustEqual(t, string(body), "grant_type=refresh_token&refresh_token=STORED_REFRESH_TOKEN")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "NEW_ACCESS_TOKEN", "refresh_token": "NEW_REFRESH_TOKEN"}`)
+	})
+	defer ts.Close()
+
+	store := &memStore{tokens: map[string]*Token{
+		"user-1": {
+			AccessToken:  "STORED_ACCESS_TOKEN",
+			RefreshToken: "STORED_REFRESH_TOKEN",
+			Expiry:       time.Now().Add(time.Hour),
+		},
+	}}
+	src := NewTokenSource(newClient(ts.URL), nil, TokenSourceConfig{
+		Key:   "user-1",
+		Store: store,
+	})
+
+	tok, err := src.Token(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "STORED_ACCESS_TOKEN")
+
+	store.tokens["user-1"].Expiry = time.Now().Add(-time.Hour)
+	tok, err = src.Token(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "NEW_ACCESS_TOKEN")
+	mustEqual(t, store.tokens["user-1"], tok)
+}
+
+func TestTokenSource_RotateConflict(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "OUR_ACCESS_TOKEN", "refresh_token": "OUR_REFRESH_TOKEN"}`)
+	})
+	defer ts.Close()
+
+	store := &rotatingStore{memStore: memStore{tokens: map[string]*Token{
+		"user-1": {
+			AccessToken:  "ACCESS_TOKEN",
+			RefreshToken: "REFRESH_TOKEN",
+			Expiry:       time.Now().Add(-time.Hour),
+		},
+	}}}
+	src := NewTokenSource(newClient(ts.URL), nil, TokenSourceConfig{
+		Key:   "user-1",
+		Store: store,
+	})
+
+	// another process rotates the token while we're refreshing it.
+	store.onRotate = func() {
+		store.tokens["user-1"] = &Token{AccessToken: "THEIR_ACCESS_TOKEN", RefreshToken: "THEIR_REFRESH_TOKEN"}
+	}
+
+	tok, err := src.Token(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "THEIR_ACCESS_TOKEN")
+}
+
+// rotatingStore is an in-memory TokenRotator.
+type rotatingStore struct {
+	memStore
+	onRotate func()
+}
+
+func (s *rotatingStore) Rotate(ctx context.Context, key, refreshToken string, token *Token) error {
+	if s.onRotate != nil {
+		s.onRotate()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tokens[key].RefreshToken != refreshToken {
+		return ErrTokenConflict
+	}
+	s.tokens[key] = token
+	return nil
+}
+
+// memStore is an in-memory TokenStore.
+type memStore struct {
+	mu     sync.Mutex
+	tokens map[string]*Token
+}
+
+func (s *memStore) Load(ctx context.Context, key string) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tok, ok := s.tokens[key]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	return tok, nil
+}
+
+func (s *memStore) Save(ctx context.Context, key string, token *Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[key] = token
+	return nil
+}
+
+func (s *memStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tokens, key)
+	return nil
+}
+
+// memLocker is a Locker which reports the lock as busy for the first busy attempts.
+type memLocker struct {
+	mu           sync.Mutex
+	busy         int
+	attempts     int
+	locked       map[string]bool
+	unlockCtxErr error
+}
+
+func (l *memLocker) TryLock(ctx context.Context, key string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.attempts++
+	if l.attempts <= l.busy || l.locked[key] {
+		return false, nil
+	}
+	l.locked[key] = true
+	return true, nil
+}
+
+func (l *memLocker) Unlock(ctx context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.locked[key] = false
+	l.unlockCtxErr = ctx.Err()
+	return nil
+}
+
+func TestTokenSource_Params(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		mustOk(t, err)
+		mustEqual(t, string(body), "device_id=DEVICE&grant_type=refresh_token&refresh_token=REFRESH_TOKEN&tenant=acme")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "NEW_ACCESS_TOKEN", "expires_in": 3600}`)
+	})
+	defer ts.Close()
+
+	expired := &Token{
+		AccessToken:  "ACCESS_TOKEN",
+		RefreshToken: "REFRESH_TOKEN",
+		Expiry:       time.Now().Add(-time.Hour),
+	}
+	src := NewTokenSource(newClient(ts.URL), expired, TokenSourceConfig{
+		Params: url.Values{"device_id": {"DEVICE"}},
+	})
+
+	ctx := ContextWithParams(context.Background(), url.Values{"tenant": {"acme"}})
+	tok, err := src.Token(ctx)
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "NEW_ACCESS_TOKEN")
+}
+
+func TestTokenSource_Quarantine(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	var calls int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error": "invalid_grant"}`)
+	})
+	defer ts.Close()
+
+	audit := &auditRecorder{}
+	client := newClientWithConfig(Config{
+		ClientID:  "CLIENT_ID",
+		TokenURL:  ts.URL,
+		Mode:      InHeaderMode,
+		AuditSink: audit,
+	})
+	expired := &Token{
+		AccessToken:  "ACCESS_TOKEN",
+		RefreshToken: "REFRESH_TOKEN",
+		Expiry:       time.Now().Add(-time.Hour),
+	}
+	src := NewTokenSource(client, expired, TokenSourceConfig{
+		Key:                "user",
+		QuarantineAfter:    2,
+		QuarantineDuration: time.Minute,
+	})
+	ctx := context.Background()
+
+	_, err := src.Token(ctx)
+	mustEqual(t, errors.Is(err, ErrTokenQuarantined), false)
+
+	_, err = src.Token(ctx)
+	mustEqual(t, errors.Is(err, ErrTokenQuarantined), true)
+	var qerr *QuarantineError
+	mustEqual(t, errors.As(err, &qerr), true)
+	mustEqual(t, qerr.Until, now.Add(time.Minute))
+	mustEqual(t, calls, 2)
+
+	_, err = src.Token(ctx)
+	mustEqual(t, errors.Is(err, ErrTokenQuarantined), true)
+	mustEqual(t, calls, 2)
+	mustEqual(t, src.Stats().Quarantines, uint64(1))
+	last := audit.events[len(audit.events)-1]
+	mustEqual(t, last.Operation, AuditQuarantine)
+	mustEqual(t, last.Key, "user")
+
+	now = now.Add(time.Minute)
+	_, err = src.Token(ctx)
+	mustEqual(t, errors.Is(err, ErrTokenQuarantined), false)
+	mustEqual(t, calls, 3)
+
+	_, err = src.Token(ctx)
+	mustEqual(t, errors.Is(err, ErrTokenQuarantined), true)
+	mustEqual(t, calls, 4)
+
+	src.Release()
+	_, err = src.Token(ctx)
+	mustFail(t, err)
+	mustEqual(t, calls, 5)
+}
+
+func TestTokenSource_QuarantineReset(t *testing.T) {
+	var calls int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error": "invalid_grant"}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{
+		ClientID: "CLIENT_ID",
+		TokenURL: ts.URL,
+		Mode:     InHeaderMode,
+	})
+	expired := &Token{
+		AccessToken:  "ACCESS_TOKEN",
+		RefreshToken: "REFRESH_TOKEN",
+		Expiry:       time.Now().Add(-time.Hour),
+	}
+	src := NewTokenSource(client, expired, TokenSourceConfig{QuarantineAfter: 2})
+	ctx := context.Background()
+
+	// a 5xx between invalid_grant failures breaks the sequence.
+	for i := 0; i < 3; i++ {
+		_, err := src.Token(ctx)
+		mustEqual(t, errors.Is(err, ErrTokenQuarantined), false)
+	}
+
+	_, err := src.Token(ctx)
+	mustEqual(t, errors.Is(err, ErrTokenQuarantined), true)
+	mustEqual(t, calls, 4)
+}
+
+func TestTokenSource_MinRefreshTime(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("must not be called")
+	})
+	defer ts.Close()
+
+	almostExpired := &Token{
+		AccessToken:  "ACCESS_TOKEN",
+		RefreshToken: "REFRESH_TOKEN",
+		Expiry:       time.Now().Add(5 * time.Second), // within expiryDelta.
+	}
+	config := TokenSourceConfig{MinRefreshTime: time.Second}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	src := NewTokenSource(newClient(ts.URL), almostExpired, config)
+	tok, err := src.Token(ctx)
+	mustOk(t, err)
+	mustEqual(t, tok, almostExpired)
+
+	expired := &Token{
+		AccessToken:  "ACCESS_TOKEN",
+		RefreshToken: "REFRESH_TOKEN",
+		Expiry:       time.Now().Add(-time.Second),
+	}
+	src = NewTokenSource(newClient(ts.URL), expired, config)
+	_, err = src.Token(ctx)
+	var derr *RefreshDeadlineError
+	mustEqual(t, errors.As(err, &derr), true)
+	mustEqual(t, derr.MinRefreshTime, time.Second)
+}
+
+func TestTokenSource_RefreshWindow(t *testing.T) {
+	healthy := true
+	var calls int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"access_token": "NEW_ACCESS_TOKEN", "expires_in": 3600}`)
+	})
+	defer ts.Close()
+
+	soon := &Token{
+		AccessToken:  "ACCESS_TOKEN",
+		RefreshToken: "REFRESH_TOKEN",
+		Expiry:       time.Now().Add(2 * time.Minute),
+	}
+	client := newClientWithConfig(Config{ClientID: "CLIENT_ID", TokenURL: ts.URL, Mode: InHeaderMode})
+	config := TokenSourceConfig{RefreshWindow: 5 * time.Minute}
+	ctx := context.Background()
+
+	// valid with the default delta, not refreshed.
+	src := NewTokenSource(client, soon, TokenSourceConfig{})
+	tok, err := src.Token(ctx)
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "ACCESS_TOKEN")
+	mustEqual(t, calls, 0)
+
+	// a failed early refresh keeps the current token and isn't retried for a while.
+	healthy = false
+	src = NewTokenSource(client, soon, config)
+	for i := 0; i < 20; i++ {
+		tok, err = src.Token(ctx)
+		mustOk(t, err)
+		mustEqual(t, tok.AccessToken, "ACCESS_TOKEN")
+	}
+	mustEqual(t, calls, 1)
+
+	now := time.Now().Add(defaultRefreshRetryInterval)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	healthy = true
+	tok, err = src.Token(ctx)
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "NEW_ACCESS_TOKEN")
+	mustEqual(t, calls, 2)
+
+	// a large delta makes the token invalid, the refresh error is returned.
+	healthy = false
+	src = NewTokenSource(client, soon, TokenSourceConfig{ExpiryDelta: 3 * time.Minute})
+	_, err = src.Token(ctx)
+	mustFail(t, err)
+}
+
+func TestTokenSource_TrackUsage(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "NEW_ACCESS_TOKEN", "expires_in": 3600}`)
+	})
+	defer ts.Close()
+
+	metrics := &metricsRecorder{}
+	client := newClientWithConfig(Config{ClientID: "CLIENT_ID", TokenURL: ts.URL, Mode: InHeaderMode, Metrics: metrics})
+	expired := &Token{
+		AccessToken:  "ACCESS_TOKEN",
+		RefreshToken: "REFRESH_TOKEN",
+		Expiry:       time.Now().Add(-time.Hour),
+	}
+	src := NewTokenSource(client, expired, TokenSourceConfig{TrackUsage: true})
+	mustEqual(t, src.Stats().LastUsed.IsZero(), true)
+
+	for i := 0; i < 3; i++ {
+		_, err := src.Token(context.Background())
+		mustOk(t, err)
+	}
+
+	stats := src.Stats()
+	mustEqual(t, stats.Uses, uint64(3))
+	mustEqual(t, stats.LastUsed.IsZero(), false)
+	mustEqual(t, metrics.uses, []bool{false, true, true})
+}
@@ -0,0 +1,130 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// UserInfo represents standard claims of an OIDC UserInfo response, OIDC Core section 5.1.
+// Custom claims can be decoded with Claims.
+type UserInfo struct {
+	Subject           string `json:"sub"`                          // Subject is an identifier of the user.
+	Name              string `json:"name,omitempty"`               // Name is a full name of the user.
+	GivenName         string `json:"given_name,omitempty"`         // GivenName is a given or first name of the user.
+	FamilyName        string `json:"family_name,omitempty"`        // FamilyName is a surname or last name of the user.
+	PreferredUsername string `json:"preferred_username,omitempty"` // PreferredUsername is a shorthand name of the user.
+	Email             string `json:"email,omitempty"`              // Email is a preferred email address of the user.
+	EmailVerified     bool   `json:"email_verified,omitempty"`     // EmailVerified reports whether the email was verified.
+	Picture           string `json:"picture,omitempty"`            // Picture is a URL of a profile picture of the user.
+	Locale            string `json:"locale,omitempty"`             // Locale is a locale of the user, like `en-US`.
+
+	raw []byte
+}
+
+// Claims decodes the response into v using json tags, for example to get custom claims
+// (roles, tenant) without parsing the response again.
+func (u *UserInfo) Claims(v interface{}) error {
+	if u.raw == nil {
+		return errors.New("oauth2: user info has no claims")
+	}
+	return json.Unmarshal(u.raw, v)
+}
+
+// UserInfo returns claims about the user from the UserInfo endpoint, OIDC Core section 5.3.
+func (c *Client) UserInfo(ctx context.Context, token *Token) (*UserInfo, error) {
+	if c.config.UserInfoURL == "" {
+		return nil, errors.New("oauth2: user info URL is not set")
+	}
+	if err := c.config.checkEndpoint("user info URL", c.config.UserInfoURL); err != nil {
+		return nil, err
+	}
+
+	req, err := c.newUserInfoRequest(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: cannot fetch user info: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("oauth2: cannot fetch user info: %w", c.newRetrieveError(resp, body))
+	}
+
+	u := &UserInfo{raw: body}
+	if err := json.Unmarshal(body, u); err != nil {
+		return nil, err
+	}
+	if u.Subject == "" {
+		return nil, errors.New("oauth2: user info response missing sub")
+	}
+	return u, nil
+}
+
+// newUserInfoRequest returns a request with the token placed according to Config.UserInfoTokenPlacement.
+// The method is GET (POST for TokenInBody) unless set in Config.Methods.
+func (c *Client) newUserInfoRequest(ctx context.Context, token *Token) (*http.Request, error) {
+	placement := c.config.UserInfoTokenPlacement
+
+	method := c.config.Methods[c.config.UserInfoURL]
+	if method == "" {
+		method = http.MethodGet
+		if placement == TokenInBody {
+			method = http.MethodPost
+		}
+	}
+
+	switch placement {
+	case TokenInHeader:
+		req, err := http.NewRequestWithContext(ctx, method, c.config.UserInfoURL, http.NoBody)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", token.Type()+" "+token.AccessToken)
+		return req, nil
+
+	case TokenInBody:
+		if method != http.MethodPost {
+			return nil, fmt.Errorf("oauth2: token in body requires POST, not %s", method)
+		}
+		body := url.Values{"access_token": []string{token.AccessToken}}.Encode()
+		req, err := http.NewRequestWithContext(ctx, method, c.config.UserInfoURL, strings.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header["Content-Type"] = formContentType
+		return req, nil
+
+	case TokenInQuery:
+		req, err := http.NewRequestWithContext(ctx, method, c.config.UserInfoURL, http.NoBody)
+		if err != nil {
+			return nil, err
+		}
+		q := req.URL.Query()
+		q.Set("access_token", token.AccessToken)
+		req.URL.RawQuery = q.Encode()
+		return req, nil
+
+	default:
+		return nil, fmt.Errorf("oauth2: unknown token placement %d", placement)
+	}
+}
+
+// IDTokenClaims decodes claims of the ID token into v using json tags.
+//
+// The ID token must be verified by the caller beforehand.
+func IDTokenClaims(idToken string, v interface{}) error {
+	_, err := decodeJWT(idToken, v)
+	return err
+}
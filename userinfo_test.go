@@ -0,0 +1,123 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestUserInfo(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		mustEqual(t, r.Method, http.MethodGet)
+		mustEqual(t, r.Header.Get("Authorization"), "Bearer ACCESS_TOKEN")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{
+			"sub": "user-1",
+			"name": "Jane Doe",
+			"email": "jane@example.com",
+			"email_verified": true,
+			"roles": ["admin", "dev"],
+			"tenant": "acme"
+		}`)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{UserInfoURL: ts.URL})
+
+	u, err := client.UserInfo(context.Background(), &Token{AccessToken: "ACCESS_TOKEN", TokenType: "bearer"})
+	mustOk(t, err)
+	mustEqual(t, u.Subject, "user-1")
+	mustEqual(t, u.Name, "Jane Doe")
+	mustEqual(t, u.EmailVerified, true)
+
+	var claims struct {
+		Roles  []string `json:"roles"`
+		Tenant string   `json:"tenant"`
+	}
+	err = u.Claims(&claims)
+	mustOk(t, err)
+	mustEqual(t, claims.Roles, []string{"admin", "dev"})
+	mustEqual(t, claims.Tenant, "acme")
+}
+
+func TestUserInfo_Error(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Www-Authenticate", `Bearer error="invalid_token"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	defer ts.Close()
+
+	client := newClientWithConfig(Config{UserInfoURL: ts.URL})
+
+	_, err := client.UserInfo(context.Background(), &Token{AccessToken: "ACCESS_TOKEN"})
+	mustFail(t, err)
+
+	_, err = newClientWithConfig(Config{}).UserInfo(context.Background(), &Token{AccessToken: "ACCESS_TOKEN"})
+	mustFail(t, err)
+}
+
+func TestIDTokenClaims(t *testing.T) {
+	idToken := makeJWT(t, jwtHeader{Algorithm: "RS256"}, map[string]any{
+		"sub":    "user-1",
+		"tenant": "acme",
+	})
+
+	var claims struct {
+		Subject string `json:"sub"`
+		Tenant  string `json:"tenant"`
+	}
+	err := IDTokenClaims(idToken, &claims)
+	mustOk(t, err)
+	mustEqual(t, claims.Subject, "user-1")
+	mustEqual(t, claims.Tenant, "acme")
+
+	mustFail(t, IDTokenClaims("not-a-jwt", &claims))
+}
+
+func TestUserInfo_TokenPlacement(t *testing.T) {
+	var method, auth, token string
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		method, auth, token = r.Method, r.Header.Get("Authorization"), r.FormValue("access_token")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"sub": "user-1"}`)
+	})
+	defer ts.Close()
+
+	testCases := []struct {
+		placement  TokenPlacement
+		method     string
+		wantMethod string
+		wantAuth   string
+		wantToken  string
+	}{
+		{TokenInHeader, "", http.MethodGet, "Bearer ACCESS_TOKEN", ""},
+		{TokenInHeader, http.MethodPost, http.MethodPost, "Bearer ACCESS_TOKEN", ""},
+		{TokenInBody, "", http.MethodPost, "", "ACCESS_TOKEN"},
+		{TokenInQuery, "", http.MethodGet, "", "ACCESS_TOKEN"},
+	}
+
+	for _, tc := range testCases {
+		client := newClientWithConfig(Config{
+			UserInfoURL:            ts.URL,
+			UserInfoTokenPlacement: tc.placement,
+			Methods:                map[string]string{ts.URL: tc.method},
+		})
+
+		_, err := client.UserInfo(context.Background(), &Token{AccessToken: "ACCESS_TOKEN"})
+		mustOk(t, err)
+		mustEqual(t, method, tc.wantMethod)
+		mustEqual(t, auth, tc.wantAuth)
+		mustEqual(t, token, tc.wantToken)
+	}
+
+	client := newClientWithConfig(Config{
+		UserInfoURL:            ts.URL,
+		UserInfoTokenPlacement: TokenInBody,
+		Methods:                map[string]string{ts.URL: http.MethodGet},
+	})
+	_, err := client.UserInfo(context.Background(), &Token{AccessToken: "ACCESS_TOKEN"})
+	mustFail(t, err)
+}
@@ -1,6 +1,11 @@
 package oauth2
 
 import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,7 +14,7 @@ import (
 	"mime"
 	"net/http"
 	"net/url"
-	"strconv"
+	"strings"
 	"time"
 )
 
@@ -25,25 +30,158 @@ func cloneURLValues(vals url.Values) url.Values {
 	return v2
 }
 
-func parseResponse(resp *http.Response) (*Token, error) {
-	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
-	resp.Body.Close()
+// encodeForm is url.Values.Encode with fewer allocations.
+// Static is an encoded form appended as is, skip are keys of v ignored in favor of static.
+func encodeForm(v url.Values, static string, skip ...string) string {
+	var keysArr [8]string
+	keys := keysArr[:0]
+	size := len(static)
 
+next:
+	for k, vs := range v {
+		for _, s := range skip {
+			if k == s {
+				continue next
+			}
+		}
+		keys = append(keys, k)
+		for _, s := range vs {
+			size += len(k) + len(s) + 2
+		}
+	}
+
+	// insertion sort, there are few keys and sort.Strings allocates.
+	for i := 1; i < len(keys); i++ {
+		for j := i; j > 0 && keys[j] < keys[j-1]; j-- {
+			keys[j], keys[j-1] = keys[j-1], keys[j]
+		}
+	}
+
+	var b strings.Builder
+	b.Grow(size + size/4)
+
+	for _, k := range keys {
+		for _, s := range v[k] {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			writeQueryEscaped(&b, k)
+			b.WriteByte('=')
+			writeQueryEscaped(&b, s)
+		}
+	}
+
+	if static != "" {
+		if b.Len() > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(static)
+	}
+	return b.String()
+}
+
+// writeQueryEscaped writes url.QueryEscape(s) without allocations.
+func writeQueryEscaped(b *strings.Builder, s string) {
+	const hex = "0123456789ABCDEF"
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == ' ':
+			b.WriteByte('+')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&15])
+		}
+	}
+}
+
+const (
+	maxBodySize  = 1 << 20  // maxBodySize is how much of a response is read.
+	maxDrainSize = 64 << 10 // maxDrainSize is how much of an unread response is drained.
+)
+
+// readBody reads the response body and closes it. The unread rest of the body is drained,
+// so the connection can be reused, unless it's too large to be worth it.
+//
+// Compressed bodies are decoded, in case the transport doesn't do it (see acceptEncoding),
+// the size limit applies to the decoded body.
+func readBody(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+
+	r, err := decodeBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r, maxBodySize))
+	if err != nil {
+		return nil, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainSize))
+	return body, nil
+}
+
+// acceptEncoding is sent with token requests. Setting it disables transparent decompression
+// of http.Transport, so responses are always decoded by readBody, with any transport.
+var acceptEncoding = []string{"gzip, deflate"}
+
+// decodeBody returns a reader of the body decoded according to Content-Encoding.
+func decodeBody(resp *http.Response) (io.Reader, error) {
+	switch encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+		return resp.Body, nil
+	case "gzip", "x-gzip":
+		r, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("oauth2: malformed gzip response: %w", err)
+		}
+		return r, nil
+	case "deflate":
+		// deflate must be zlib-wrapped, but some servers send raw deflate.
+		br := bufio.NewReader(resp.Body)
+		header, _ := br.Peek(2)
+		if len(header) == 2 && header[0]&0x0f == 8 && (uint(header[0])<<8|uint(header[1]))%31 == 0 {
+			r, err := zlib.NewReader(br)
+			if err != nil {
+				return nil, fmt.Errorf("oauth2: malformed deflate response: %w", err)
+			}
+			return r, nil
+		}
+		return flate.NewReader(br), nil
+	default:
+		return nil, fmt.Errorf("oauth2: unsupported response encoding %q", encoding)
+	}
+}
+
+func (c *Client) parseResponse(resp *http.Response) (*Token, error) {
+	body, err := readBody(resp)
 	if err != nil {
 		return nil, fmt.Errorf("oauth2: cannot fetch token: %w", err)
 	}
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return nil, fmt.Errorf("oauth2: cannot fetch token: %v %v\nResponse: %s",
-			resp.StatusCode, http.StatusText(resp.StatusCode), string(body))
+		return nil, c.newRetrieveError(resp, body)
+	}
+	if detect := c.config.ResponseError; detect != nil {
+		if err := detect(resp, body); err != nil {
+			return nil, c.categorize(err)
+		}
 	}
 
 	var token *Token
+	compat := c.expiresInCompat()
 
-	switch responseContentType(resp) {
-	case "text/plain", "application/x-www-form-urlencoded":
-		token, err = parseText(body)
+	switch contentType := responseContentType(resp); {
+	case contentType == "text/plain" && bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")):
+		// JSON served as text, at least by WeChat.
+		token, err = parseJSON(body, compat)
+	case contentType == "text/plain", contentType == "application/x-www-form-urlencoded":
+		token, err = parseText(body, compat)
 	default:
-		token, err = parseJSON(body)
+		token, err = parseJSON(body, compat)
 	}
 
 	switch {
@@ -61,7 +199,7 @@ func responseContentType(resp *http.Response) string {
 	return content
 }
 
-func parseText(body []byte) (*Token, error) {
+func parseText(body []byte, compat ExpiresInCompat) (*Token, error) {
 	vals, err := url.ParseQuery(string(body))
 	if err != nil {
 		return nil, err
@@ -74,27 +212,68 @@ func parseText(body []byte) (*Token, error) {
 		Raw:          vals,
 	}
 
-	e := vals.Get("expires_in")
-	expires, _ := strconv.Atoi(e)
-	if expires != 0 {
-		token.Expiry = time.Now().Add(time.Duration(expires) * time.Second)
+	// form values are strings anyway, malformed ones were always ignored.
+	if compat != 0 {
+		compat |= ExpiresInString | ExpiresInIgnoreMalformed
+	}
+
+	name := "expires_in"
+	if _, ok := vals[name]; !ok && compat&ExpiresAlias != 0 {
+		name = "expires"
+	}
+	if e := vals.Get(name); e != "" {
+		expires, err := compat.parse(name, e)
+		if err != nil {
+			return nil, err
+		}
+		if expires != 0 {
+			token.Expiry = time.Now().Add(expires)
+		}
+	}
+
+	if re := vals.Get("refresh_expires_in"); re != "" {
+		refreshExpires, err := compat.parse("refresh_expires_in", re)
+		if err != nil {
+			return nil, err
+		}
+		if refreshExpires != 0 {
+			token.RefreshExpiry = time.Now().Add(refreshExpires)
+		}
 	}
 	return token, nil
 }
 
-func parseJSON(body []byte) (*Token, error) {
+func parseJSON(body []byte, compat ExpiresInCompat) (*Token, error) {
 	var tj tokenJSON
 	if err := json.Unmarshal(body, &tj); err != nil {
 		return nil, err
 	}
 
+	expiresIn, name := tj.ExpiresIn, "expires_in"
+	if expiresIn == nil && compat&ExpiresAlias != 0 {
+		expiresIn, name = tj.Expires, "expires"
+	}
+	expires, err := compat.parseJSON(name, expiresIn)
+	if err != nil {
+		return nil, err
+	}
+	refreshExpires, err := compat.parseJSON("refresh_expires_in", tj.RefreshExpiresIn)
+	if err != nil {
+		return nil, err
+	}
+
 	token := &Token{
 		AccessToken:  tj.AccessToken,
 		TokenType:    tj.TokenType,
 		RefreshToken: tj.RefreshToken,
-		Expiry:       tj.expiry(),
 		Raw:          make(map[string]interface{}),
 	}
+	if expires != 0 {
+		token.Expiry = time.Now().Add(expires)
+	}
+	if refreshExpires != 0 {
+		token.RefreshExpiry = time.Now().Add(refreshExpires)
+	}
 
 	_ = json.Unmarshal(body, &token.Raw) // no error checks for optional fields
 
@@ -102,18 +281,15 @@ func parseJSON(body []byte) (*Token, error) {
 }
 
 // tokenJSON represens the HTTP response from OAuth2 providers.
+// Expiration times are parsed according to ExpiresInCompat.
 type tokenJSON struct {
-	AccessToken  string         `json:"access_token"`
-	TokenType    string         `json:"token_type"`
-	RefreshToken string         `json:"refresh_token"`
-	ExpiresIn    expirationTime `json:"expires_in"` // at least PayPal returns string, while most return number
-}
+	AccessToken  string          `json:"access_token"`
+	TokenType    string          `json:"token_type"`
+	RefreshToken string          `json:"refresh_token"`
+	ExpiresIn    json.RawMessage `json:"expires_in"`
+	Expires      json.RawMessage `json:"expires"` // older Facebook APIs
 
-func (e *tokenJSON) expiry() time.Time {
-	if v := e.ExpiresIn; v != 0 {
-		return time.Now().Add(time.Duration(v) * time.Second)
-	}
-	return time.Time{}
+	RefreshExpiresIn json.RawMessage `json:"refresh_expires_in"` // Keycloak and some others
 }
 
 type expirationTime int32
@@ -0,0 +1,44 @@
+package oauth2
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestEncodeForm(t *testing.T) {
+	testCases := []url.Values{
+		{},
+		{"a": {"1"}},
+		{"grant_type": {"client_credentials"}, "scope": {"read write"}, "audience": {"https://api.example.com/?x=1&y=ä"}},
+		{"k": {"v1", "v2"}, "Z": {""}, "~-_.": {"!*'();:@&=+$,/?#[]"}},
+	}
+
+	for _, v := range testCases {
+		mustEqual(t, encodeForm(v, ""), v.Encode())
+	}
+
+	v := url.Values{"client_id": {"other"}, "scope": {"read"}}
+	mustEqual(t, encodeForm(v, "client_id=ID", "client_id"), "scope=read&client_id=ID")
+	mustEqual(t, encodeForm(url.Values{}, "client_id=ID"), "client_id=ID")
+}
+
+func BenchmarkEncodeForm(b *testing.B) {
+	v := url.Values{
+		"grant_type": {"client_credentials"},
+		"scope":      {"scope1 scope2"},
+		"audience":   {"https://api.example.com"},
+	}
+
+	b.Run("url.Values", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = v.Encode()
+		}
+	})
+	b.Run("encodeForm", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = encodeForm(v, "")
+		}
+	})
+}
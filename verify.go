@@ -0,0 +1,74 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// CredentialsStatus is a result of Client.VerifyCredentials.
+type CredentialsStatus int
+
+const (
+	// CredentialsUnknown means the response didn't tell whether the credentials are valid.
+	CredentialsUnknown CredentialsStatus = 0
+
+	// CredentialsValid means the token endpoint accepted the client authentication.
+	CredentialsValid CredentialsStatus = 1
+
+	// CredentialsInvalid means the token endpoint rejected the client authentication,
+	// like after a client secret rotation.
+	CredentialsInvalid CredentialsStatus = 2
+
+	// CredentialsUnreachable means the token endpoint is unreachable or failing.
+	CredentialsUnreachable CredentialsStatus = 3
+)
+
+func (s CredentialsStatus) String() string {
+	switch s {
+	case CredentialsUnknown:
+		return "unknown"
+	case CredentialsValid:
+		return "valid"
+	case CredentialsInvalid:
+		return "invalid"
+	case CredentialsUnreachable:
+		return "unreachable"
+	default:
+		return "invalid status"
+	}
+}
+
+// VerifyCredentials checks the client credentials at startup with a client credentials request
+// without scopes. Rejections of the grant or the scopes (`unauthorized_client`, `unsupported_grant_type`,
+// `invalid_scope`) mean the client was authenticated, so the credentials are valid
+// for clients not allowed to use the grant too. The error of the request is returned for other statuses.
+func (c *Client) VerifyCredentials(ctx context.Context) (CredentialsStatus, error) {
+	params := url.Values{
+		"grant_type": []string{GrantTypeClientCredentials},
+	}
+	_, err := c.retrieveToken(ctx, params)
+	if err == nil {
+		return CredentialsValid, nil
+	}
+
+	var rerr *RetrieveError
+	if !errors.As(err, &rerr) {
+		return CredentialsUnreachable, err
+	}
+
+	switch {
+	case rerr.ErrorCode == "invalid_client", rerr.ErrorCode == "incorrect_client_credentials":
+		return CredentialsInvalid, err
+	case rerr.ErrorCode == "unauthorized_client", rerr.ErrorCode == "unsupported_grant_type",
+		rerr.ErrorCode == "invalid_scope":
+		return CredentialsValid, nil
+	case rerr.StatusCode == http.StatusUnauthorized:
+		return CredentialsInvalid, err
+	case rerr.StatusCode >= 500:
+		return CredentialsUnreachable, err
+	default:
+		return CredentialsUnknown, err
+	}
+}
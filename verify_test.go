@@ -0,0 +1,51 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestVerifyCredentials(t *testing.T) {
+	testCases := []struct {
+		status int
+		body   string
+		want   CredentialsStatus
+	}{
+		{http.StatusOK, `{"access_token": "ACCESS_TOKEN"}`, CredentialsValid},
+		{http.StatusUnauthorized, `{"error": "invalid_client"}`, CredentialsInvalid},
+		{http.StatusBadRequest, `{"error": "unauthorized_client"}`, CredentialsValid},
+		{http.StatusUnauthorized, `Unauthorized`, CredentialsInvalid},
+		{http.StatusBadGateway, `Bad Gateway`, CredentialsUnreachable},
+		{http.StatusBadRequest, `{"error": "invalid_request"}`, CredentialsUnknown},
+	}
+
+	for _, tc := range testCases {
+		ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+			mustEqual(t, r.FormValue("grant_type"), "client_credentials")
+			mustEqual(t, r.FormValue("scope"), "")
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(tc.status)
+			fmt.Fprint(w, tc.body)
+		})
+
+		client := newClientWithConfig(Config{
+			ClientID:     "CLIENT_ID",
+			ClientSecret: "CLIENT_SECRET",
+			TokenURL:     ts.URL,
+			Mode:         InHeaderMode,
+			Scopes:       []string{"scope1"},
+		})
+		status, err := client.VerifyCredentials(context.Background())
+		mustEqual(t, status, tc.want)
+		mustEqual(t, err == nil, tc.want == CredentialsValid)
+		ts.Close()
+	}
+
+	client := newClient("http://127.0.0.1:1")
+	status, err := client.VerifyCredentials(context.Background())
+	mustFail(t, err)
+	mustEqual(t, status, CredentialsUnreachable)
+}
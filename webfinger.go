@@ -0,0 +1,88 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// issuerRel is a WebFinger link relation of the OpenID Provider issuer.
+const issuerRel = "http://openid.net/specs/connect/1.0/issuer"
+
+// WebFingerIssuer resolves a user identifier (like `joe@example.com`, `acct:joe@example.com`
+// or `https://example.com/joe`) to the issuer of the user's OpenID Provider with WebFinger, OIDC Discovery section 2.
+func WebFingerIssuer(ctx context.Context, client *http.Client, identifier string) (string, error) {
+	resource, host, err := normalizeIdentifier(identifier)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"resource": []string{resource},
+		"rel":      []string{issuerRel},
+	}
+	endpoint := "https://" + host + "/.well-known/webfinger?" + query.Encode()
+
+	var jrd struct {
+		Links []struct {
+			Rel  string `json:"rel"`
+			Href string `json:"href"`
+		} `json:"links"`
+	}
+	if err := getJSON(ctx, client, endpoint, &jrd); err != nil {
+		return "", fmt.Errorf("oauth2: webfinger failed: %w", err)
+	}
+
+	for _, link := range jrd.Links {
+		if link.Rel == issuerRel && strings.HasPrefix(link.Href, "https://") {
+			return link.Href, nil
+		}
+	}
+	return "", fmt.Errorf("oauth2: webfinger has no issuer for %q", identifier)
+}
+
+// DiscoverByIdentifier resolves the user identifier to the issuer with WebFingerIssuer
+// and fetches its metadata with Discover.
+func DiscoverByIdentifier(ctx context.Context, client *http.Client, identifier string) (*ProviderMetadata, error) {
+	issuer, err := WebFingerIssuer(ctx, client, identifier)
+	if err != nil {
+		return nil, err
+	}
+	return Discover(ctx, client, issuer)
+}
+
+// normalizeIdentifier returns a WebFinger resource and host of the user identifier, OIDC Discovery section 2.1.
+func normalizeIdentifier(identifier string) (resource, host string, err error) {
+	identifier = strings.TrimSpace(identifier)
+	if identifier == "" {
+		return "", "", errors.New("oauth2: identifier is empty")
+	}
+
+	if strings.HasPrefix(identifier, "acct:") {
+		at := strings.LastIndexByte(identifier, '@')
+		if at < 0 || at == len(identifier)-1 {
+			return "", "", fmt.Errorf("oauth2: malformed identifier %q", identifier)
+		}
+		return identifier, identifier[at+1:], nil
+	}
+
+	// an email-like identifier without a scheme and a path is an account.
+	if !strings.Contains(identifier, "://") {
+		at := strings.LastIndexByte(identifier, '@')
+		slash := strings.IndexByte(identifier, '/')
+		if at > 0 && (slash < 0 || slash > at) {
+			return normalizeIdentifier("acct:" + identifier)
+		}
+		identifier = "https://" + identifier
+	}
+
+	u, err := url.Parse(identifier)
+	if err != nil || u.Host == "" {
+		return "", "", fmt.Errorf("oauth2: malformed identifier %q", identifier)
+	}
+	u.Fragment = ""
+	return u.String(), u.Host, nil
+}
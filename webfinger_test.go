@@ -0,0 +1,96 @@
+package oauth2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeIdentifier(t *testing.T) {
+	testCases := []struct {
+		identifier string
+		resource   string
+		host       string
+	}{
+		{"joe@example.com", "acct:joe@example.com", "example.com"},
+		{"acct:joe@example.com", "acct:joe@example.com", "example.com"},
+		{"joe@example.com:8080", "acct:joe@example.com:8080", "example.com:8080"},
+		{"example.com", "https://example.com", "example.com"},
+		{"example.com:8080", "https://example.com:8080", "example.com:8080"},
+		{"https://example.com/joe#fragment", "https://example.com/joe", "example.com"},
+		{"example.com/joe@work", "https://example.com/joe@work", "example.com"},
+	}
+
+	for _, tc := range testCases {
+		resource, host, err := normalizeIdentifier(tc.identifier)
+		mustOk(t, err)
+		mustEqual(t, resource, tc.resource)
+		mustEqual(t, host, tc.host)
+	}
+
+	for _, identifier := range []string{"", "acct:joe@", "https://"} {
+		_, _, err := normalizeIdentifier(identifier)
+		mustFail(t, err)
+	}
+}
+
+func TestDiscoverByIdentifier(t *testing.T) {
+	var issuer string
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/.well-known/webfinger":
+			mustEqual(t, r.URL.Query().Get("rel"), "http://openid.net/specs/connect/1.0/issuer")
+			if !strings.HasPrefix(r.URL.Query().Get("resource"), "acct:joe@") {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(`{"subject": "acct:joe@example.com", "links": [
+				{"rel": "http://webfinger.net/rel/profile-page", "href": "https://example.com/joe"},
+				{"rel": "http://openid.net/specs/connect/1.0/issuer", "href": "` + issuer + `/tenant"}
+			]}`))
+
+		case "/tenant/.well-known/openid-configuration":
+			w.Write([]byte(`{
+				"issuer": "` + issuer + `/tenant",
+				"authorization_endpoint": "` + issuer + `/tenant/auth",
+				"token_endpoint": "` + issuer + `/tenant/token",
+				"userinfo_endpoint": "` + issuer + `/tenant/userinfo",
+				"token_endpoint_auth_methods_supported": ["client_secret_post", "private_key_jwt"],
+				"authorization_response_iss_parameter_supported": true
+			}`))
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	issuer = ts.URL
+
+	host := strings.TrimPrefix(ts.URL, "https://")
+	ctx := context.Background()
+
+	got, err := WebFingerIssuer(ctx, ts.Client(), "joe@"+host)
+	mustOk(t, err)
+	mustEqual(t, got, issuer+"/tenant")
+
+	m, err := DiscoverByIdentifier(ctx, ts.Client(), "joe@"+host)
+	mustOk(t, err)
+
+	config := m.Config()
+	mustEqual(t, config.Issuer, issuer+"/tenant")
+	mustEqual(t, config.AuthURL, issuer+"/tenant/auth")
+	mustEqual(t, config.TokenURL, issuer+"/tenant/token")
+	mustEqual(t, config.UserInfoURL, issuer+"/tenant/userinfo")
+	mustEqual(t, config.Mode, InParamsMode)
+	mustEqual(t, config.RequireResponseIssuer, true)
+
+	_, err = WebFingerIssuer(ctx, ts.Client(), host+"/joe")
+	mustFail(t, err)
+
+	_, err = Discover(ctx, ts.Client(), issuer+"/tenant/")
+	mustFail(t, err)
+}
@@ -0,0 +1,33 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// WeChatResponseError is a Config.ResponseError detecting WeChat-style `errcode` and `errmsg` envelopes,
+// which are returned with a 200 status. A non-zero `errcode` is returned as *RetrieveError
+// with the code in ErrorCode and the message in ErrorDescription.
+//
+// WeChat also uses GET token endpoints (see Config.Methods) and `appid` and `secret` parameters,
+// pass them with ExchangeWithParams and leave client fields empty.
+func WeChatResponseError(resp *http.Response, body []byte) error {
+	var envelope struct {
+		ErrCode json.Number `json:"errcode"`
+		ErrMsg  string      `json:"errmsg"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil // not an envelope, let the response be parsed as usual.
+	}
+	if code, err := envelope.ErrCode.Int64(); err != nil || code == 0 {
+		return nil
+	}
+
+	return &RetrieveError{
+		StatusCode:       resp.StatusCode,
+		Body:             body,
+		ContentType:      responseContentType(resp),
+		ErrorCode:        string(envelope.ErrCode),
+		ErrorDescription: envelope.ErrMsg,
+	}
+}
@@ -0,0 +1,46 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestWeChatEndpoint(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		mustEqual(t, r.Method, http.MethodGet)
+		mustEqual(t, r.URL.Query().Get("appid"), "APP_ID")
+		mustEqual(t, r.URL.Query().Get("secret"), "SECRET")
+		mustEqual(t, r.URL.Query().Get("grant_type"), "authorization_code")
+
+		w.Header().Set("Content-Type", "text/plain")
+		if r.URL.Query().Get("code") == "BAD_CODE" {
+			fmt.Fprint(w, `{"errcode": 40029, "errmsg": "invalid code"}`)
+			return
+		}
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN", "expires_in": 7200, "openid": "OPENID"}`)
+	})
+	defer ts.Close()
+
+	tokenURL := ts.URL + "/sns/oauth2/access_token"
+	client := newClientWithConfig(Config{
+		TokenURL:      tokenURL,
+		Mode:          InHeaderMode,
+		Methods:       map[string]string{tokenURL: http.MethodGet},
+		ResponseError: WeChatResponseError,
+	})
+	params := url.Values{"appid": {"APP_ID"}, "secret": {"SECRET"}}
+
+	_, err := client.ExchangeWithParams(context.Background(), "BAD_CODE", params)
+	var re *RetrieveError
+	mustEqual(t, errors.As(err, &re), true)
+	mustEqual(t, re.ErrorCode, "40029")
+	mustEqual(t, re.ErrorDescription, "invalid code")
+
+	tok, err := client.ExchangeWithParams(context.Background(), "CODE", params)
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "ACCESS_TOKEN")
+}
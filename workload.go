@@ -0,0 +1,100 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+)
+
+// KubernetesTokenFile is a path of the service account token in Kubernetes pods.
+// Projected tokens with a custom audience are mounted to a path from the pod spec.
+const KubernetesTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// SubjectTokenFunc returns a subject token for a token exchange, like a Kubernetes service account token.
+type SubjectTokenFunc func(ctx context.Context) (string, error)
+
+// SubjectTokenFromFile returns a SubjectTokenFunc reading the token from the file.
+// The file is read on every call, as Kubernetes rotates projected tokens in place.
+func SubjectTokenFromFile(path string) SubjectTokenFunc {
+	return func(ctx context.Context) (string, error) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		token := strings.TrimSpace(string(b))
+		if token == "" {
+			return "", errors.New("oauth2: subject token file is empty: " + path)
+		}
+		return token, nil
+	}
+}
+
+// FederationConfig describes how FederatedTokenSource obtains tokens.
+type FederationConfig struct {
+	SubjectToken       SubjectTokenFunc // SubjectToken returns a token to exchange, required.
+	SubjectTokenType   string           // SubjectTokenType is a type of the subject token, defaults to TokenTypeJWT.
+	RequestedTokenType string           // RequestedTokenType is a type of the requested token, optional.
+	Audience           string           // Audience is a target service of the token, optional.
+	Resource           string           // Resource is a target URI of the token, optional.
+	Scopes             []string         // Scopes are requested scopes, optional.
+
+	_ struct{} // enforce explicit field names.
+}
+
+// FederatedTokenSource exchanges a workload identity token (like a projected Kubernetes
+// service account token) for provider access tokens with the token exchange grant, RFC 8693.
+// The token is exchanged again shortly before it expires. It is safe for concurrent use.
+type FederatedTokenSource struct {
+	client *Client
+	config FederationConfig
+
+	mu    sync.Mutex
+	token *Token
+}
+
+// NewFederatedTokenSource instantiates a new federated token source with a given client and config.
+func NewFederatedTokenSource(client *Client, config FederationConfig) (*FederatedTokenSource, error) {
+	if config.SubjectToken == nil {
+		return nil, errors.New("oauth2: subject token func is not set")
+	}
+	if config.SubjectTokenType == "" {
+		config.SubjectTokenType = TokenTypeJWT
+	}
+
+	ts := &FederatedTokenSource{
+		client: client,
+		config: config,
+	}
+	return ts, nil
+}
+
+// Token returns a valid token, exchanging the subject token if needed.
+func (ts *FederatedTokenSource) Token(ctx context.Context) (*Token, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.token.validAt(timeNow()) {
+		return ts.token, nil
+	}
+
+	subject, err := ts.config.SubjectToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := ts.client.ExchangeToken(ctx, TokenExchange{
+		SubjectToken:       subject,
+		SubjectTokenType:   ts.config.SubjectTokenType,
+		RequestedTokenType: ts.config.RequestedTokenType,
+		Audience:           ts.config.Audience,
+		Resource:           ts.config.Resource,
+		Scopes:             ts.config.Scopes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ts.token = token
+	return token, nil
+}
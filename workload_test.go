@@ -0,0 +1,79 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFederatedTokenSource(t *testing.T) {
+	var calls int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		mustEqual(t, r.FormValue("subject_token_type"), TokenTypeJWT)
+		mustEqual(t, r.FormValue("audience"), "//iam.example.com/pool")
+
+		w.Header().Set("Content-Type", "application/json")
+		// expires right away, so the next call exchanges again.
+		fmt.Fprintf(w, `{"access_token": "ACCESS_TOKEN_FOR_%s", "expires_in": 1}`, r.FormValue("subject_token"))
+	})
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "token")
+	mustOk(t, os.WriteFile(path, []byte("SA_TOKEN_1\n"), 0o600))
+
+	src, err := NewFederatedTokenSource(newClient(ts.URL), FederationConfig{
+		SubjectToken: SubjectTokenFromFile(path),
+		Audience:     "//iam.example.com/pool",
+	})
+	mustOk(t, err)
+
+	tok, err := src.Token(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "ACCESS_TOKEN_FOR_SA_TOKEN_1")
+
+	// the kubelet rotated the token.
+	mustOk(t, os.WriteFile(path, []byte("SA_TOKEN_2\n"), 0o600))
+
+	tok, err = src.Token(context.Background())
+	mustOk(t, err)
+	mustEqual(t, tok.AccessToken, "ACCESS_TOKEN_FOR_SA_TOKEN_2")
+	mustEqual(t, calls, 2)
+}
+
+func TestFederatedTokenSource_Cached(t *testing.T) {
+	var calls int
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "ACCESS_TOKEN", "expires_in": 3600}`)
+	})
+	defer ts.Close()
+
+	src, err := NewFederatedTokenSource(newClient(ts.URL), FederationConfig{
+		SubjectToken: func(ctx context.Context) (string, error) { return "SUBJECT_TOKEN", nil },
+	})
+	mustOk(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := src.Token(context.Background())
+		mustOk(t, err)
+	}
+	mustEqual(t, calls, 1)
+}
+
+func TestSubjectTokenFromFile(t *testing.T) {
+	_, err := SubjectTokenFromFile(filepath.Join(t.TempDir(), "missing"))(context.Background())
+	mustFail(t, err)
+
+	path := filepath.Join(t.TempDir(), "empty")
+	mustOk(t, os.WriteFile(path, nil, 0o600))
+	_, err = SubjectTokenFromFile(path)(context.Background())
+	mustFail(t, err)
+
+	_, err = NewFederatedTokenSource(newClient("http://localhost"), FederationConfig{})
+	mustFail(t, err)
+}
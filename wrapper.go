@@ -1,6 +1,9 @@
 package oauth2
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"net/http"
 )
 
@@ -8,15 +11,35 @@ import (
 // The header will be `Authorization`.
 // All the params cannot be empty or nil.
 func Wrap(header, value string, c *http.Client) (*http.Client, error) {
+	return WrapHeaders(http.Header{header: []string{value}}, c)
+}
+
+// WrapHeaders adds several headers to the given http.Client, like `Authorization` with a custom scheme
+// and `X-Api-Key`. Wrapping an already wrapped client merges the headers, so requests are cloned once.
+func WrapHeaders(headers http.Header, c *http.Client) (*http.Client, error) {
+	if len(headers) == 0 {
+		return nil, errors.New("oauth2: no headers to wrap")
+	}
+
 	transport := http.DefaultTransport
 	if c.Transport != nil {
 		transport = c.Transport
 	}
 
+	merged := http.Header{}
+	if w, ok := transport.(*wrappedTransport); ok {
+		for k, v := range w.headers {
+			merged[k] = v
+		}
+		transport = w.transport
+	}
+	for k, v := range headers {
+		merged[http.CanonicalHeaderKey(k)] = append([]string(nil), v...)
+	}
+
 	wrapped := &http.Client{
 		Transport: &wrappedTransport{
-			header:    header,
-			value:     value,
+			headers:   merged,
 			transport: transport,
 		},
 	}
@@ -24,24 +47,141 @@ func Wrap(header, value string, c *http.Client) (*http.Client, error) {
 }
 
 type wrappedTransport struct {
-	header    string
-	value     string
+	headers   http.Header
 	transport http.RoundTripper
 }
 
 // RoundTrip implements the http.RoundTripper interface.
 func (t *wrappedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	req = cloneRequest(req)
-	req.Header.Set(t.header, t.value)
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header[k] = v
+	}
 	return t.transport.RoundTrip(req)
 }
 
-func cloneRequest(r *http.Request) *http.Request {
-	r2 := &http.Request{}
-	*r2 = *r
-	r2.Header = make(http.Header, len(r.Header))
-	for k, s := range r.Header {
-		r2.Header[k] = append([]string(nil), s...)
+// Transport is an http.RoundTripper authorizing requests with tokens of a TokenSource,
+// which refreshes them when they expire.
+type Transport struct {
+	// Source provides tokens, required.
+	Source *TokenSource
+
+	// Base is the underlying transport, http.DefaultTransport is used if nil.
+	Base http.RoundTripper
+
+	// ModifyRequest is an optional hook called with a copy of every request after the Authorization
+	// header is set, to add tenant headers or to adjust the authorization scheme.
+	ModifyRequest func(req *http.Request)
+
+	// RetryUnauthorized retries a request once with a refreshed token when the response is 401,
+	// for tokens revoked before their expiry. The body is replayed with Request.GetBody or buffered
+	// up to MaxReplayBodySize, requests with larger bodies are not retried.
+	RetryUnauthorized bool
+
+	// MaxReplayBodySize is how much of a request body without GetBody is buffered for a retry.
+	// Default is 64 KiB.
+	MaxReplayBodySize int64
+
+	_ struct{} // enforce explicit field names.
+}
+
+const defaultMaxReplayBodySize = 64 << 10
+
+// TokenAcquisitionError is returned by Transport when no token could be obtained for a request,
+// so an authorization outage is distinguishable from a failure of the API call itself.
+type TokenAcquisitionError struct {
+	Err error // Err is the error of TokenSource.
+}
+
+func (e *TokenAcquisitionError) Error() string {
+	return "oauth2: cannot obtain token: " + e.Err.Error()
+}
+
+func (e *TokenAcquisitionError) Unwrap() error { return e.Err }
+
+// RoundTrip implements the http.RoundTripper interface.
+// A token refresh is bounded by the context of the request, including its deadline.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	token, err := t.Source.Token(ctx)
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, &TokenAcquisitionError{Err: err}
+	}
+
+	req = req.Clone(ctx)
+	var getBody func() (io.ReadCloser, error)
+	if t.RetryUnauthorized {
+		getBody = t.replayableBody(req)
+	}
+
+	resp, err := t.send(req, token)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || getBody == nil {
+		return resp, err
+	}
+
+	// the token might be revoked, retry once with a new one.
+	t.Source.invalidateToken(token.AccessToken)
+	fresh, err := t.Source.Token(ctx)
+	if err != nil || fresh.AccessToken == token.AccessToken {
+		return resp, nil
+	}
+	body, err := getBody()
+	if err != nil {
+		return resp, nil
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainSize))
+	resp.Body.Close()
+
+	req = req.Clone(ctx)
+	req.Body = body
+	return t.send(req, fresh)
+}
+
+func (t *Transport) send(req *http.Request, token *Token) (*http.Response, error) {
+	req.Header.Set("Authorization", token.Type()+" "+token.AccessToken)
+	if t.ModifyRequest != nil {
+		t.ModifyRequest(req)
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// replayableBody returns a function to get the body of the request again, buffering it if needed,
+// or nil if the body is too large to replay.
+func (t *Transport) replayableBody(req *http.Request) func() (io.ReadCloser, error) {
+	switch {
+	case req.Body == nil || req.Body == http.NoBody:
+		return func() (io.ReadCloser, error) { return http.NoBody, nil }
+	case req.GetBody != nil:
+		return req.GetBody
+	}
+
+	limit := t.MaxReplayBodySize
+	if limit <= 0 {
+		limit = defaultMaxReplayBodySize
+	}
+	buf, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
+	if err != nil || int64(len(buf)) > limit {
+		// a streaming or large body, send what was read and the rest as is.
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf), req.Body), req.Body}
+		return nil
+	}
+
+	req.Body.Close()
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf)), nil
 	}
-	return r2
+	req.Body, _ = req.GetBody()
+	return req.GetBody
 }
@@ -1,7 +1,12 @@
 package oauth2
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 )
@@ -24,3 +29,159 @@ func TestWrap(t *testing.T) {
 	mustOk(t, err)
 	mustEqual(t, resp.StatusCode, http.StatusOK)
 }
+
+func TestTransport(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		mustEqual(t, r.Header.Get("Authorization"), "DPoP ACCESS_TOKEN")
+		mustEqual(t, r.Header.Get("X-Tenant"), "acme")
+
+		w.WriteHeader(http.StatusOK)
+	})
+	defer ts.Close()
+
+	token := &Token{AccessToken: "ACCESS_TOKEN", Expiry: time.Now().Add(time.Hour)}
+	src := NewTokenSource(newClient(ts.URL), token, TokenSourceConfig{})
+
+	c := &http.Client{
+		Transport: &Transport{
+			Source: src,
+			ModifyRequest: func(req *http.Request) {
+				req.Header.Set("X-Tenant", "acme")
+				req.Header.Set("Authorization", strings.Replace(req.Header.Get("Authorization"), "Bearer", "DPoP", 1))
+			},
+		},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL, http.NoBody)
+	mustOk(t, err)
+	resp, err := c.Do(req)
+	mustOk(t, err)
+	mustEqual(t, resp.StatusCode, http.StatusOK)
+	mustEqual(t, req.Header.Get("X-Tenant"), "")
+}
+
+func TestWrapHeaders(t *testing.T) {
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		mustEqual(t, r.Header.Get("Authorization"), "Token ACCESS_TOKEN")
+		mustEqual(t, r.Header.Get("X-Api-Key"), "API_KEY")
+
+		w.WriteHeader(http.StatusOK)
+	})
+	defer ts.Close()
+
+	wc, err := WrapHeaders(http.Header{"x-api-key": {"API_KEY"}}, &http.Client{})
+	mustOk(t, err)
+	wc, err = Wrap("Authorization", "Token ACCESS_TOKEN", wc)
+	mustOk(t, err)
+
+	wt := wc.Transport.(*wrappedTransport)
+	mustEqual(t, len(wt.headers), 2)
+	mustEqual(t, wt.transport, http.DefaultTransport)
+
+	resp, err := wc.Get(ts.URL)
+	mustOk(t, err)
+	mustEqual(t, resp.StatusCode, http.StatusOK)
+
+	_, err = WrapHeaders(nil, &http.Client{})
+	mustFail(t, err)
+}
+
+func TestTransport_RefreshDeadline(t *testing.T) {
+	block := make(chan struct{})
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	})
+	defer ts.Close()
+	defer close(block)
+
+	expired := &Token{
+		AccessToken:  "ACCESS_TOKEN",
+		RefreshToken: "REFRESH_TOKEN",
+		Expiry:       time.Now().Add(-time.Hour),
+	}
+	src := NewTokenSource(newClient(ts.URL), expired, TokenSourceConfig{})
+	c := &http.Client{Transport: &Transport{Source: src}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	body := strings.NewReader("payload")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ts.URL, body)
+	mustOk(t, err)
+	_, err = c.Do(req)
+	mustEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+
+	var terr *TokenAcquisitionError
+	mustEqual(t, errors.As(err, &terr), true)
+}
+
+func TestWrap_KeepsGetBody(t *testing.T) {
+	var bodies []string
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if len(bodies) == 1 {
+			// a redirect preserving the method makes the client resend the body with GetBody.
+			http.Redirect(w, r, "/again", http.StatusTemporaryRedirect)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	defer ts.Close()
+
+	wc, err := Wrap("Authorization", "KEY", &http.Client{})
+	mustOk(t, err)
+
+	resp, err := wc.Post(ts.URL, "text/plain", strings.NewReader("payload"))
+	mustOk(t, err)
+	mustEqual(t, resp.StatusCode, http.StatusOK)
+	mustEqual(t, bodies, []string{"payload", "payload"})
+}
+
+func TestTransport_RetryUnauthorized(t *testing.T) {
+	var bodies []string
+	ts := newServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"access_token": "NEW_ACCESS_TOKEN", "expires_in": 3600}`)
+			return
+		}
+
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if r.Header.Get("Authorization") != "Bearer NEW_ACCESS_TOKEN" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	defer ts.Close()
+
+	newTransport := func(maxBody int64) *Transport {
+		revoked := &Token{
+			AccessToken:  "REVOKED_ACCESS_TOKEN",
+			RefreshToken: "REFRESH_TOKEN",
+			Expiry:       time.Now().Add(time.Hour),
+		}
+		return &Transport{
+			Source:            NewTokenSource(newClient(ts.URL), revoked, TokenSourceConfig{}),
+			RetryUnauthorized: true,
+			MaxReplayBodySize: maxBody,
+		}
+	}
+	// hides GetBody, like a streaming body.
+	stream := func(s string) io.Reader { return struct{ io.Reader }{strings.NewReader(s)} }
+
+	c := &http.Client{Transport: newTransport(0)}
+	resp, err := c.Post(ts.URL+"/api", "text/plain", stream("payload"))
+	mustOk(t, err)
+	mustEqual(t, resp.StatusCode, http.StatusOK)
+	mustEqual(t, bodies, []string{"payload", "payload"})
+
+	bodies = nil
+	c = &http.Client{Transport: newTransport(4)}
+	resp, err = c.Post(ts.URL+"/api", "text/plain", stream("payload"))
+	mustOk(t, err)
+	mustEqual(t, resp.StatusCode, http.StatusUnauthorized)
+	mustEqual(t, bodies, []string{"payload"})
+}